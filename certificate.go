@@ -0,0 +1,83 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Certificate is a chain presented by a server, leaf first, together with the
+// private key of the leaf.
+type Certificate struct {
+	Chain      []*x509.Certificate
+	PrivateKey crypto.Signer
+}
+
+// LoadX509KeyPair reads a PEM encoded chain and private key from files.
+func LoadX509KeyPair(certFile, keyFile string) (Certificate, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return Certificate{}, err
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return Certificate{}, err
+	}
+
+	return X509KeyPair(certPEM, keyPEM)
+}
+
+// X509KeyPair parses a PEM encoded chain and a PKCS #1, SEC 1 or PKCS #8 private key.
+func X509KeyPair(certPEM, keyPEM []byte) (Certificate, error) {
+	var certificate Certificate
+
+	for rest := certPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		parsed, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return Certificate{}, err
+		}
+		certificate.Chain = append(certificate.Chain, parsed)
+	}
+	if len(certificate.Chain) == 0 {
+		return Certificate{}, errors.New("no certificates found in PEM data")
+	}
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return Certificate{}, errors.New("no private key found in PEM data")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return Certificate{}, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return Certificate{}, fmt.Errorf("private key of type %T cannot sign", key)
+	}
+	certificate.PrivateKey = signer
+
+	return certificate, nil
+}
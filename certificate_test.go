@@ -0,0 +1,84 @@
+package tls
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestX509KeyPair(t *testing.T) {
+	certificate := newTestCertificate(t)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Chain[0].Raw})
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(certificate.PrivateKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		keyPEM []byte
+	}{
+		{
+			name:   "PKCS #8 key",
+			keyPEM: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+		},
+		{
+			name:   "PKCS #1 key",
+			keyPEM: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(certificate.PrivateKey.(*rsa.PrivateKey))}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := X509KeyPair(certPEM, tc.keyPEM)
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(parsed.Chain) != 1 || !parsed.Chain[0].Equal(certificate.Chain[0]) {
+				t.Errorf("Expected the parsed chain to contain the certificate")
+			}
+			if parsed.PrivateKey == nil {
+				t.Error("Expected a private key")
+			}
+		})
+	}
+}
+
+func TestX509KeyPair_InvalidInput(t *testing.T) {
+	certificate := newTestCertificate(t)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Chain[0].Raw})
+
+	testCases := []struct {
+		name     string
+		certPEM  []byte
+		keyPEM   []byte
+		expected string
+	}{
+		{
+			name:     "no certificates",
+			certPEM:  []byte("not PEM"),
+			expected: "no certificates found in PEM data",
+		},
+		{
+			name:     "no private key",
+			certPEM:  certPEM,
+			keyPEM:   []byte("not PEM"),
+			expected: "no private key found in PEM data",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := X509KeyPair(tc.certPEM, tc.keyPEM)
+
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if err.Error() != tc.expected {
+				t.Errorf("Expected error message %q, got %q", tc.expected, err.Error())
+			}
+		})
+	}
+}
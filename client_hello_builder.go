@@ -1,4 +1,4 @@
-package main
+package tls
 
 import (
 	"errors"
@@ -11,9 +11,9 @@ import (
 )
 
 func newClientHello(
+	config *Config,
 	random []byte,
 	sessionID []byte,
-	cipherSuites []spec.CipherSuite,
 	extensions []spec.Extension,
 ) (*spec.ClientHello, error) {
 	if len(random) != 32 {
@@ -24,6 +24,15 @@ func newClientHello(
 		return nil, errors.New("session ID cannot be longer than 32 bytes")
 	}
 
+	versions := config.supportedVersions()
+	if len(versions) == 0 {
+		return nil, errors.New("at least one supported version is required")
+	}
+
+	cipherSuites := config.cipherSuites()
+	if config.RenegotiationSCSV && !slices.Contains(cipherSuites, spec.CipherSuiteEMPTY_RENEGOTIATION_INFO_SCSV) {
+		cipherSuites = append(slices.Clone(cipherSuites), spec.CipherSuiteEMPTY_RENEGOTIATION_INFO_SCSV)
+	}
 	if 2*len(cipherSuites) > math.MaxUint16 {
 		return nil, fmt.Errorf("raw cipher suites cannot exceed %v bytes", math.MaxUint16)
 	}
@@ -32,9 +41,10 @@ func newClientHello(
 		return nil, errors.New("at least one cipher suite is required")
 	}
 	seenCipherSuites := make(map[spec.CipherSuite]bool)
-	supportedCipherSuites := spec.SupportedCipherSuites()
 	for _, cipherSuite := range cipherSuites {
-		if !slices.Contains(supportedCipherSuites, cipherSuite) {
+		// the SCSV is a signal rather than a suite that could be negotiated
+		isSCSV := cipherSuite == spec.CipherSuiteEMPTY_RENEGOTIATION_INFO_SCSV
+		if !isSCSV && !implementedCipherSuite(cipherSuite) {
 			return nil, fmt.Errorf("unsupported cipher suite: %v", cipherSuite)
 		}
 
@@ -68,7 +78,7 @@ func newClientHello(
 	compressionMethods := []spec.CompressionMethod{spec.CompressionMethodNull}
 
 	return &spec.ClientHello{
-		ClientTlsVersion:   spec.Tls12ProtocolVersion(),
+		ClientTlsVersion:   versions[0],
 		Random:             utils.CopySlice(random),
 		SessionID:          utils.CopySlice(sessionID),
 		CipherSuites:       utils.CopySlice(cipherSuites),
@@ -1,7 +1,8 @@
-package main
+package tls
 
 import (
 	"encoding/binary"
+	"slices"
 	"testing"
 	"time"
 
@@ -13,11 +14,11 @@ func TestCreateClientHello_ValidInput(t *testing.T) {
 	random := make([]byte, 32)
 	binary.BigEndian.PutUint32(random[:4], uint32(time.Now().Unix())) // Valid timestamp
 	sessionID := []byte{0x01, 0x02}
-	cipherSuites := []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	config := &Config{CipherSuites: []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256}}
 	extensions := []spec.Extension{}
 
 	// Act
-	clientHello, err := newClientHello(random, sessionID, cipherSuites, extensions)
+	clientHello, err := newClientHello(config, random, sessionID, extensions)
 
 	// Assert
 	if err != nil {
@@ -44,11 +45,11 @@ func TestCreateClientHello_InvalidRandomLength(t *testing.T) {
 	// Arrange
 	random := make([]byte, 31) // Too short
 	sessionID := []byte{}
-	cipherSuites := []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA}
+	config := &Config{CipherSuites: []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA}}
 	extensions := []spec.Extension{}
 
 	// Act
-	_, err := newClientHello(random, sessionID, cipherSuites, extensions)
+	_, err := newClientHello(config, random, sessionID, extensions)
 
 	// Assert
 	if err == nil {
@@ -64,11 +65,11 @@ func TestCreateClientHello_UnsupportedCipherSuite(t *testing.T) {
 	random := make([]byte, 32)
 	binary.BigEndian.PutUint32(random[:4], uint32(time.Now().Unix()))
 	sessionID := []byte{}
-	cipherSuites := []spec.CipherSuite{spec.CipherSuite(0x1337)} // Invalid
+	config := &Config{CipherSuites: []spec.CipherSuite{spec.CipherSuite(0x1337)}} // Invalid
 	extensions := []spec.Extension{}
 
 	// Act
-	_, err := newClientHello(random, sessionID, cipherSuites, extensions)
+	_, err := newClientHello(config, random, sessionID, extensions)
 
 	// Assert
 	if err == nil {
@@ -85,14 +86,14 @@ func TestCreateClientHello_DuplicateExtension(t *testing.T) {
 	random := make([]byte, 32)
 	binary.BigEndian.PutUint32(random[:4], uint32(time.Now().Unix()))
 	sessionID := []byte{}
-	cipherSuites := []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	config := &Config{CipherSuites: []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256}}
 	extensions := []spec.Extension{
 		{Type: spec.ExtensionTypeServerName, Opaque: []byte{}},
 		{Type: spec.ExtensionTypeServerName, Opaque: []byte{}}, // Duplicate
 	}
 
 	// Act
-	_, err := newClientHello(random, sessionID, cipherSuites, extensions)
+	_, err := newClientHello(config, random, sessionID, extensions)
 
 	// Assert
 	if err == nil {
@@ -108,13 +109,13 @@ func TestCreateClientHello_UnsupportedExtension(t *testing.T) {
 	random := make([]byte, 32)
 	binary.BigEndian.PutUint32(random[:4], uint32(time.Now().Unix()))
 	sessionID := []byte{}
-	cipherSuites := []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	config := &Config{CipherSuites: []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256}}
 	extensions := []spec.Extension{
 		{Type: spec.ExtensionType(0x1337), Opaque: []byte{}}, // Unknown type
 	}
 
 	// Act
-	_, err := newClientHello(random, sessionID, cipherSuites, extensions)
+	_, err := newClientHello(config, random, sessionID, extensions)
 
 	// Assert
 	if err == nil {
@@ -124,3 +125,27 @@ func TestCreateClientHello_UnsupportedExtension(t *testing.T) {
 		t.Errorf("Expected error message 'unsupported extension ExtensionType(0x1337)', got %q", err.Error())
 	}
 }
+
+func TestCreateClientHello_RenegotiationSCSV(t *testing.T) {
+	// Arrange
+	random := make([]byte, 32)
+	config := &Config{
+		CipherSuites:      []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		RenegotiationSCSV: true,
+	}
+
+	// Act
+	clientHello, err := newClientHello(config, random, nil, nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256, spec.CipherSuiteEMPTY_RENEGOTIATION_INFO_SCSV}
+	if !slices.Equal(clientHello.CipherSuites, expected) {
+		t.Errorf("Expected %v, got %v", expected, clientHello.CipherSuites)
+	}
+	if len(config.CipherSuites) != 1 {
+		t.Errorf("Expected the config to be left unchanged, got %v", config.CipherSuites)
+	}
+}
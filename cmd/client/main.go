@@ -1,5 +1,47 @@
 package main
 
+import (
+	"bufio"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/piligrimm/tls"
+)
+
 func main() { // coverage-ignore
+	addr := flag.String("addr", "127.0.0.1:6969", "server address")
+	serverName := flag.String("servername", "localhost", "expected name in the server certificate")
+	caFile := flag.String("ca", "", "PEM file with the root CA of the server, system roots are used when empty")
+	message := flag.String("message", "hello", "application data to send")
+	flag.Parse()
+
+	config := &tls.Config{ServerName: *serverName}
+	if *caFile != "" {
+		pem, err := os.ReadFile(*caFile)
+		if err != nil {
+			panic(err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			panic("no certificates found in " + *caFile)
+		}
+	}
+
+	client, err := tls.Dial("tcp", *addr, config)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	if _, err := client.Write([]byte(*message + "\n")); err != nil {
+		panic(err)
+	}
 
+	reply, err := bufio.NewReader(client).ReadString('\n')
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(reply)
 }
@@ -1,15 +1,50 @@
 package main
 
 import (
+	"bufio"
+	"errors"
+	"flag"
 	"net"
+
+	"github.com/piligrimm/tls"
 )
 
+// echoLines writes every line received on conn back to it.
+func echoLines(conn net.Conn) { // coverage-ignore
+	defer conn.Close()
+
+	lines := bufio.NewScanner(conn)
+	for lines.Scan() {
+		if _, err := conn.Write(append(lines.Bytes(), '\n')); err != nil {
+			return
+		}
+	}
+}
+
 func main() { // coverage-ignore
-	listener, err := net.Listen("tcp", "127.0.0.1:6969")
+	addr := flag.String("addr", "127.0.0.1:6969", "listen address")
+	certFile := flag.String("cert", "server.crt", "PEM file with the server certificate chain")
+	keyFile := flag.String("key", "server.key", "PEM file with the server private key")
+	flag.Parse()
 
+	certificate, err := tls.LoadX509KeyPair(*certFile, *keyFile)
 	if err != nil {
 		panic(err)
 	}
 
-	_, _ = listener.Accept()
+	listener, err := tls.Listen("tcp", *addr, &tls.Config{Certificates: []tls.Certificate{certificate}})
+	if err != nil {
+		panic(err)
+	}
+
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			panic(err)
+		}
+		go echoLines(conn)
+	}
 }
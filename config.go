@@ -0,0 +1,281 @@
+package tls
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/piligrimm/tls/internal/session"
+	"github.com/piligrimm/tls/spec"
+)
+
+// supportedCipherSuites are the suites enabled by default, in preference order.
+// The server only selects the suites matching the key of its certificate.
+var supportedCipherSuites = []spec.CipherSuite{
+	spec.CipherSuiteECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	spec.CipherSuiteECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	spec.CipherSuiteECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	spec.CipherSuiteECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// legacyCipherSuites are implemented for peers that offer nothing better, they
+// are only enabled when listed in Config.CipherSuites.
+var legacyCipherSuites = []spec.CipherSuite{
+	spec.CipherSuiteECDHE_ECDSA_WITH_AES_128_CBC_SHA256,
+	spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA256,
+	spec.CipherSuiteECDHE_ECDSA_WITH_AES_256_CBC_SHA384,
+	spec.CipherSuiteECDHE_RSA_WITH_AES_256_CBC_SHA384,
+	spec.CipherSuiteECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA,
+	spec.CipherSuiteECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	spec.CipherSuiteECDHE_RSA_WITH_AES_256_CBC_SHA,
+	// static RSA has no forward secrecy
+	spec.CipherSuiteRSA_WITH_AES_128_GCM_SHA256,
+	spec.CipherSuiteRSA_WITH_AES_256_GCM_SHA384,
+	spec.CipherSuiteRSA_WITH_AES_128_CBC_SHA256,
+	spec.CipherSuiteRSA_WITH_AES_256_CBC_SHA256,
+	spec.CipherSuiteRSA_WITH_AES_128_CBC_SHA,
+	spec.CipherSuiteRSA_WITH_AES_256_CBC_SHA,
+}
+
+func implementedCipherSuite(cipherSuite spec.CipherSuite) bool {
+	return slices.Contains(supportedCipherSuites, cipherSuite) || slices.Contains(legacyCipherSuites, cipherSuite)
+}
+
+// supportedVersions are the versions this package implements, highest first.
+var supportedVersions = []spec.ProtocolVersion{
+	spec.Tls12ProtocolVersion(),
+}
+
+var supportedGroups = []spec.SupportedGroup{
+	spec.SupportedGroupsX25519,
+	spec.SupportedGroupsSecp256r1,
+	spec.SupportedGroupsSecp384r1,
+	spec.SupportedGroupsSecp521r1,
+}
+
+// supportedPointFormats is fixed, compressed points are deprecated by RFC 8422.
+var supportedPointFormats = []spec.ECPointFormat{
+	spec.ECPointFormatUncompressed,
+}
+
+var supportedSignatureAlgorithms = []spec.SignatureAlgorithm{
+	spec.SignatureAlgorithmRsaPkcs1Sha256,
+	spec.SignatureAlgorithmRsaPssRsaeSha256,
+	spec.SignatureAlgorithmEcdsaSecp256r1Sha256,
+	spec.SignatureAlgorithmRsaPkcs1Sha384,
+	spec.SignatureAlgorithmRsaPssRsaeSha384,
+	spec.SignatureAlgorithmEcdsaSecp384r1Sha384,
+	spec.SignatureAlgorithmRsaPkcs1Sha512,
+	spec.SignatureAlgorithmRsaPssRsaeSha512,
+	spec.SignatureAlgorithmEcdsaSecp521r1Sha512,
+	spec.SignatureAlgorithmEd25519,
+}
+
+// Config configures a client or a server. A Config may be shared between
+// connections and must not be modified after it has been passed to one.
+type Config struct {
+	// Certificates are the chains a server can present, the first one is used
+	Certificates []Certificate
+	// RootCAs verifies the server chain on the client, the system pool is used when nil
+	RootCAs *x509.CertPool
+	// ServerName is checked against the server certificate on the client
+	ServerName string
+
+	// CipherSuites are the enabled suites in preference order. The server
+	// selects by its own preference. The ECDHE AEAD suites are enabled when
+	// nil, the CBC and static RSA suites only when listed.
+	CipherSuites []spec.CipherSuite
+	// SupportedGroups are the enabled ECDHE groups in preference order
+	SupportedGroups []spec.SupportedGroup
+	// SignatureAlgorithms are the enabled ServerKeyExchange signature schemes in
+	// preference order
+	SignatureAlgorithms []spec.SignatureAlgorithm
+	// DisableSHA1Fallback makes the server refuse clients that send no
+	// signature_algorithms instead of signing with SHA-1, the RFC 5246 default
+	DisableSHA1Fallback bool
+	// RenegotiationSCSV makes the client signal secure renegotiation with
+	// TLS_EMPTY_RENEGOTIATION_INFO_SCSV instead of an empty renegotiation_info
+	RenegotiationSCSV bool
+	// RequireSecureRenegotiation refuses a peer that does not signal support
+	// for secure renegotiation (RFC 5746)
+	RequireSecureRenegotiation bool
+	// RequireExtendedMasterSecret refuses a peer that does not support the
+	// extended master secret (RFC 7627), which defeats triple-handshake attacks
+	RequireExtendedMasterSecret bool
+	// SessionTicketsDisabled turns off session tickets (RFC 5077) on both sides
+	SessionTicketsDisabled bool
+	// SessionTicketKey seals the session state into tickets on the server.
+	// Servers sharing the key resume each other's sessions, a random key is
+	// generated for the Config when it is zero
+	SessionTicketKey [32]byte
+	// ClientSessionCache keeps the sessions a client can resume, sessions are
+	// not kept and no session tickets are asked for when nil
+	ClientSessionCache ClientSessionCache
+	// SessionCache keeps the sessions a server resumes by session ID, the
+	// server issues no session IDs when nil
+	SessionCache SessionCache
+	// MinVersion and MaxVersion bound the negotiated version, a zero value
+	// stands for TLS 1.2, the only version implemented
+	MinVersion spec.ProtocolVersion
+	MaxVersion spec.ProtocolVersion
+
+	// Rand is the source of randomness, crypto/rand is used when nil
+	Rand io.Reader
+	// Time returns the current time, time.Now is used when nil
+	Time func() time.Time
+
+	ticketKeyOnce sync.Once
+	ticketKey     *session.TicketKey
+	ticketKeyErr  error
+}
+
+// Clone returns a copy of c that can be modified, c itself may be in use.
+func (c *Config) Clone() *Config {
+	return &Config{
+		Certificates:                c.Certificates,
+		RootCAs:                     c.RootCAs,
+		ServerName:                  c.ServerName,
+		CipherSuites:                c.CipherSuites,
+		SupportedGroups:             c.SupportedGroups,
+		SignatureAlgorithms:         c.SignatureAlgorithms,
+		DisableSHA1Fallback:         c.DisableSHA1Fallback,
+		RenegotiationSCSV:           c.RenegotiationSCSV,
+		RequireSecureRenegotiation:  c.RequireSecureRenegotiation,
+		RequireExtendedMasterSecret: c.RequireExtendedMasterSecret,
+		SessionTicketsDisabled:      c.SessionTicketsDisabled,
+		SessionTicketKey:            c.SessionTicketKey,
+		ClientSessionCache:          c.ClientSessionCache,
+		SessionCache:                c.SessionCache,
+		MinVersion:                  c.MinVersion,
+		MaxVersion:                  c.MaxVersion,
+		Rand:                        c.Rand,
+		Time:                        c.Time,
+	}
+}
+
+func (c *Config) random() io.Reader {
+	if c.Rand == nil {
+		return rand.Reader
+	}
+	return c.Rand
+}
+
+func (c *Config) now() time.Time {
+	if c.Time == nil {
+		return time.Now()
+	}
+	return c.Time()
+}
+
+// sessionTicketKey returns the key sealing tickets, generating a random one on
+// first use when SessionTicketKey is zero.
+func (c *Config) sessionTicketKey() (*session.TicketKey, error) {
+	c.ticketKeyOnce.Do(func() {
+		secret := c.SessionTicketKey
+		if secret == ([32]byte{}) {
+			if _, err := io.ReadFull(c.random(), secret[:]); err != nil {
+				c.ticketKeyErr = fmt.Errorf("failed to generate session ticket key: %w", err)
+				return
+			}
+		}
+		c.ticketKey, c.ticketKeyErr = session.NewTicketKey(secret)
+	})
+
+	return c.ticketKey, c.ticketKeyErr
+}
+
+func (c *Config) cipherSuites() []spec.CipherSuite {
+	if c.CipherSuites == nil {
+		return supportedCipherSuites
+	}
+	return c.CipherSuites
+}
+
+func (c *Config) supportedGroups() []spec.SupportedGroup {
+	if c.SupportedGroups == nil {
+		return supportedGroups
+	}
+	return c.SupportedGroups
+}
+
+func (c *Config) signatureAlgorithms() []spec.SignatureAlgorithm {
+	if c.SignatureAlgorithms == nil {
+		return supportedSignatureAlgorithms
+	}
+	return c.SignatureAlgorithms
+}
+
+func versionNumber(version spec.ProtocolVersion) uint16 {
+	return uint16(version.Major)<<8 | uint16(version.Minor)
+}
+
+func (c *Config) versionRange() (spec.ProtocolVersion, spec.ProtocolVersion) {
+	minVersion, maxVersion := c.MinVersion, c.MaxVersion
+	if minVersion == (spec.ProtocolVersion{}) {
+		minVersion = spec.Tls12ProtocolVersion()
+	}
+	if maxVersion == (spec.ProtocolVersion{}) {
+		maxVersion = spec.Tls12ProtocolVersion()
+	}
+	return minVersion, maxVersion
+}
+
+// supportedVersions returns the implemented versions between MinVersion and
+// MaxVersion, highest first.
+func (c *Config) supportedVersions() []spec.ProtocolVersion {
+	minVersion, maxVersion := c.versionRange()
+	var versions []spec.ProtocolVersion
+	for _, version := range supportedVersions {
+		if versionNumber(version) >= versionNumber(minVersion) && versionNumber(version) <= versionNumber(maxVersion) {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// check returns an error if the config enables a version, a cipher suite, a
+// group or a signature algorithm this package does not implement.
+func (c *Config) check() error {
+	if len(c.supportedVersions()) == 0 {
+		minVersion, maxVersion := c.versionRange()
+		return fmt.Errorf("version range %d.%d to %d.%d does not include TLS 1.2",
+			minVersion.Major, minVersion.Minor, maxVersion.Major, maxVersion.Minor)
+	}
+
+	if len(c.cipherSuites()) == 0 {
+		return errors.New("at least one cipher suite is required")
+	}
+	for _, cipherSuite := range c.cipherSuites() {
+		if !implementedCipherSuite(cipherSuite) {
+			return fmt.Errorf("unsupported cipher suite: %v", cipherSuite)
+		}
+	}
+
+	if len(c.supportedGroups()) == 0 {
+		return errors.New("at least one group is required")
+	}
+	for _, group := range c.supportedGroups() {
+		if !slices.Contains(supportedGroups, group) {
+			return fmt.Errorf("unsupported group %#04x", uint16(group))
+		}
+	}
+
+	if len(c.signatureAlgorithms()) == 0 {
+		return errors.New("at least one signature algorithm is required")
+	}
+	for _, signatureAlgorithm := range c.signatureAlgorithms() {
+		if !slices.Contains(supportedSignatureAlgorithms, signatureAlgorithm) {
+			return fmt.Errorf("unsupported signature algorithm %#04x", uint16(signatureAlgorithm))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package tls
+
+import (
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestConfig_Check(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   *Config
+		expected string
+	}{
+		{
+			name:   "defaults",
+			config: &Config{},
+		},
+		{
+			name: "explicit TLS 1.2 range",
+			config: &Config{
+				MinVersion: spec.ProtocolVersion{Major: 3, Minor: 1},
+				MaxVersion: spec.Tls12ProtocolVersion(),
+			},
+		},
+		{
+			name:     "range below TLS 1.2",
+			config:   &Config{MaxVersion: spec.ProtocolVersion{Major: 3, Minor: 1}},
+			expected: "version range 3.3 to 3.1 does not include TLS 1.2",
+		},
+		{
+			name:     "range above TLS 1.2",
+			config:   &Config{MinVersion: spec.ProtocolVersion{Major: 3, Minor: 4}, MaxVersion: spec.ProtocolVersion{Major: 3, Minor: 4}},
+			expected: "version range 3.4 to 3.4 does not include TLS 1.2",
+		},
+		{
+			name:     "no cipher suites",
+			config:   &Config{CipherSuites: []spec.CipherSuite{}},
+			expected: "at least one cipher suite is required",
+		},
+		{
+			name:     "unsupported cipher suite",
+			config:   &Config{CipherSuites: []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_RC4_128_SHA}},
+			expected: "unsupported cipher suite: TLS_ECDHE_RSA_WITH_RC4_128_SHA",
+		},
+		{
+			name:     "no groups",
+			config:   &Config{SupportedGroups: []spec.SupportedGroup{}},
+			expected: "at least one group is required",
+		},
+		{
+			name:     "unsupported group",
+			config:   &Config{SupportedGroups: []spec.SupportedGroup{0x001e}},
+			expected: "unsupported group 0x001e",
+		},
+		{
+			name:     "no signature algorithms",
+			config:   &Config{SignatureAlgorithms: []spec.SignatureAlgorithm{}},
+			expected: "at least one signature algorithm is required",
+		},
+		{
+			name:     "unsupported signature algorithm",
+			config:   &Config{SignatureAlgorithms: []spec.SignatureAlgorithm{spec.SignatureAlgorithmEd448}},
+			expected: "unsupported signature algorithm 0x0808",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.check()
+
+			if tc.expected == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if err.Error() != tc.expected {
+				t.Errorf("Expected error message %q, got %q", tc.expected, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package tls
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/internal/handshake"
+	"github.com/piligrimm/tls/internal/record"
+	"github.com/piligrimm/tls/spec"
+)
+
+// alertTimeout bounds how long sending an alert waits for a peer that stopped reading.
+const alertTimeout = 5 * time.Second
+
+// AlertError is returned when the peer ends the connection with a fatal alert,
+// or with close_notify before the handshake has completed.
+type AlertError = alert.RemoteError
+
+// Conn is a TLS connection over an underlying net.Conn. The handshake runs on
+// the first Read or Write unless Handshake is called first.
+type Conn struct {
+	conn     net.Conn
+	config   *Config
+	isClient bool
+
+	records  *record.Reader
+	writer   *record.Writer
+	messages *handshake.Reader
+
+	handshakeMutex    sync.Mutex
+	handshakeErr      error
+	handshakeComplete atomic.Bool
+	version           spec.ProtocolVersion
+	cipherSuite       spec.CipherSuite
+	serverName        string
+
+	// secureRenegotiation is set when the peer supports RFC 5746, the
+	// verify_data of the last handshake binds a renegotiation to it
+	secureRenegotiation bool
+	clientVerifyData    []byte
+	serverVerifyData    []byte
+
+	extendedMasterSecret bool
+	encryptThenMAC       bool
+	didResume            bool
+
+	inMutex sync.Mutex
+	input   []byte
+	// postHandshake frames the handshake messages that arrive after the
+	// handshake has completed
+	postHandshake *handshake.Framer
+
+	outMutex sync.Mutex
+
+	// writeDeadline is the deadline set through SetDeadline or
+	// SetWriteDeadline, an alert shortens it for a moment
+	deadlineMutex sync.Mutex
+	writeDeadline time.Time
+}
+
+// ConnectionState describes the parameters negotiated by the handshake.
+type ConnectionState struct {
+	HandshakeComplete bool
+	Version           spec.ProtocolVersion
+	CipherSuite       spec.CipherSuite
+	// ServerName is, on the server, the host name the client sent in the
+	// server_name extension. It is empty when the client sent none.
+	ServerName string
+	// SecureRenegotiation reports whether the peer supports secure
+	// renegotiation (RFC 5746)
+	SecureRenegotiation bool
+	// ExtendedMasterSecret reports whether the master secret is bound to the
+	// session hash (RFC 7627)
+	ExtendedMasterSecret bool
+	// EncryptThenMAC reports whether the CBC records of the session MAC the
+	// ciphertext (RFC 7366)
+	EncryptThenMAC bool
+	// DidResume reports whether the connection resumed an earlier session
+	// through the abbreviated handshake
+	DidResume bool
+}
+
+// Client returns the client side of a connection, config must not be nil.
+func Client(conn net.Conn, config *Config) *Conn {
+	return newConn(conn, config, true)
+}
+
+// Server returns the server side of a connection, config must contain a certificate.
+func Server(conn net.Conn, config *Config) *Conn {
+	return newConn(conn, config, false)
+}
+
+func newConn(conn net.Conn, config *Config, isClient bool) *Conn {
+	records := record.NewReader(conn)
+	return &Conn{
+		conn:     conn,
+		config:   config,
+		isClient: isClient,
+		records:  records,
+		writer:   record.NewWriter(conn, spec.Tls12ProtocolVersion()),
+		messages: handshake.NewReader(records),
+
+		postHandshake: handshake.NewFramer(),
+	}
+}
+
+// Handshake runs the handshake if it has not run yet. A failed handshake is
+// not retried, the same error is returned on every later call.
+func (c *Conn) Handshake() error {
+	c.handshakeMutex.Lock()
+	defer c.handshakeMutex.Unlock()
+
+	if c.handshakeErr != nil {
+		return c.handshakeErr
+	}
+	if c.handshakeComplete.Load() {
+		return nil
+	}
+
+	// a config that cannot work fails before anything is sent to the peer
+	if err := c.checkConfig(); err != nil {
+		c.handshakeErr = err
+		return err
+	}
+
+	if c.isClient {
+		c.handshakeErr = c.clientHandshake()
+	} else {
+		c.handshakeErr = c.serverHandshake()
+	}
+	if c.handshakeErr != nil {
+		c.handshakeFailed(c.handshakeErr)
+		return c.handshakeErr
+	}
+
+	c.handshakeComplete.Store(true)
+	return nil
+}
+
+// ConnectionState returns the negotiated parameters, it waits for a handshake
+// in progress.
+func (c *Conn) ConnectionState() ConnectionState {
+	c.handshakeMutex.Lock()
+	defer c.handshakeMutex.Unlock()
+
+	if !c.handshakeComplete.Load() {
+		return ConnectionState{}
+	}
+	return ConnectionState{
+		HandshakeComplete:    true,
+		Version:              c.version,
+		CipherSuite:          c.cipherSuite,
+		ServerName:           c.serverName,
+		SecureRenegotiation:  c.secureRenegotiation,
+		ExtendedMasterSecret: c.extendedMasterSecret,
+		EncryptThenMAC:       c.encryptThenMAC,
+		DidResume:            c.didResume,
+	}
+}
+
+// newProtector returns the record protector for one direction of the session,
+// in the encrypt_then_mac mode when it was negotiated.
+func (c *Conn) newProtector(params *ciphersuite.Parameters, key, iv, macKey []byte) (record.Protector, error) {
+	if c.encryptThenMAC {
+		return record.NewEncryptThenMACProtector(params, key, macKey)
+	}
+	return record.NewProtector(params, key, iv, macKey)
+}
+
+func (c *Conn) checkConfig() error {
+	if c.isClient && c.config.ServerName == "" {
+		return errors.New("server name must be set in the client config")
+	}
+	if !c.isClient && len(c.config.Certificates) == 0 {
+		return errors.New("server config must contain at least one certificate")
+	}
+	if !c.isClient {
+		for i, certificate := range c.config.Certificates {
+			if len(certificate.Chain) == 0 {
+				return fmt.Errorf("server certificate %d has an empty chain", i)
+			}
+		}
+	}
+
+	return c.config.check()
+}
+
+// handshakeFailed reports a failed handshake to the peer with a fatal alert,
+// unless the peer sent one itself or the transport is gone.
+func (c *Conn) handshakeFailed(err error) {
+	var remoteErr *AlertError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &remoteErr):
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.ErrClosedPipe), errors.As(err, &netErr):
+	default:
+		c.sendAlert(spec.AlertLevelFatal, alert.DescriptionOf(err))
+	}
+}
+
+// sendAlert makes a best-effort attempt to send an alert. After a fatal alert
+// the connection cannot be used anymore.
+func (c *Conn) sendAlert(level spec.AlertLevel, description spec.AlertDescription) {
+	c.outMutex.Lock()
+	defer c.outMutex.Unlock()
+
+	c.sendAlertLocked(level, description)
+}
+
+// sendAlertLocked sends an alert with outMutex held. The write waits at most
+// alertTimeout, the deadline set by the user is restored afterwards.
+func (c *Conn) sendAlertLocked(level spec.AlertLevel, description spec.AlertDescription) {
+	c.deadlineMutex.Lock()
+	deadline := time.Now().Add(alertTimeout)
+	if c.writeDeadline.IsZero() || c.writeDeadline.After(deadline) {
+		_ = c.conn.SetWriteDeadline(deadline)
+	}
+	c.deadlineMutex.Unlock()
+
+	_ = c.writer.WriteRecords(spec.ContentTypeAlert, alert.MarshalAlert(&spec.Alert{Level: level, Description: description}))
+
+	c.deadlineMutex.Lock()
+	_ = c.conn.SetWriteDeadline(c.writeDeadline)
+	c.deadlineMutex.Unlock()
+}
+
+func (c *Conn) writeHandshake(transcript *handshake.Transcript, message *spec.Handshake) error {
+	raw := handshake.MarshalHandshake(message)
+	transcript.Write(raw)
+
+	return c.writer.WriteRecords(spec.ContentTypeHandshake, raw)
+}
+
+func (c *Conn) Write(data []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+
+	c.outMutex.Lock()
+	defer c.outMutex.Unlock()
+
+	if err := c.writer.WriteRecords(spec.ContentTypeApplicationData, data); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (c *Conn) Read(p []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+
+	c.inMutex.Lock()
+	defer c.inMutex.Unlock()
+
+	for len(c.input) == 0 {
+		rec, err := c.records.ReadRecord()
+		if err != nil {
+			var alertErr *alert.Error
+			if errors.As(err, &alertErr) {
+				c.sendAlert(spec.AlertLevelFatal, alertErr.Description)
+			}
+			return 0, err
+		}
+
+		switch rec.ContentType {
+		case spec.ContentTypeApplicationData:
+			c.input = rec.Fragment
+		case spec.ContentTypeAlert:
+			if err := alert.Received(rec.Fragment); err != nil {
+				var remoteErr *AlertError
+				if errors.As(err, &remoteErr) && remoteErr.Description == spec.AlertDescriptionCloseNotify {
+					return 0, io.EOF
+				}
+				return 0, err
+			}
+		case spec.ContentTypeHandshake:
+			if err := c.handlePostHandshake(rec.Fragment); err != nil {
+				var alertErr *alert.Error
+				if errors.As(err, &alertErr) {
+					c.sendAlert(spec.AlertLevelFatal, alertErr.Description)
+				}
+				return 0, err
+			}
+		default:
+			c.sendAlert(spec.AlertLevelFatal, spec.AlertDescriptionUnexpectedMessage)
+			return 0, fmt.Errorf("unexpected %v record after handshake", rec.ContentType)
+		}
+	}
+
+	n := copy(p, c.input)
+	c.input = c.input[n:]
+	return n, nil
+}
+
+// handlePostHandshake declines the renegotiations the peer asks for after the
+// handshake. Renegotiation is not supported, a HelloRequest to the client and
+// a ClientHello to the server are answered with a no_renegotiation warning and
+// the connection carries on (RFC 5246, section 7.4.1.1 and 7.2.2).
+func (c *Conn) handlePostHandshake(fragment []byte) error {
+	c.postHandshake.Write(fragment)
+	for {
+		message, ok, err := c.postHandshake.Next()
+		if err != nil || !ok {
+			return err
+		}
+
+		switch {
+		case c.isClient && message.MsgType == spec.HandshakeTypeHelloRequest:
+			if len(message.Body) != 0 {
+				return alert.New(spec.AlertDescriptionDecodeError, "malformed HelloRequest message")
+			}
+		case !c.isClient && message.MsgType == spec.HandshakeTypeClientHello:
+		default:
+			return alert.Errorf(spec.AlertDescriptionUnexpectedMessage, "unexpected %v message after handshake", message.MsgType)
+		}
+		c.sendAlert(spec.AlertLevelWarning, spec.AlertDescriptionNoRenegotiation)
+	}
+}
+
+// Close closes the underlying connection. If the handshake has completed, it
+// first makes a best-effort attempt to send close_notify. That is skipped
+// while a Write is in progress, so that closing unblocks it rather than
+// waiting behind it.
+func (c *Conn) Close() error {
+	if c.handshakeComplete.Load() && c.outMutex.TryLock() {
+		c.sendAlertLocked(spec.AlertLevelWarning, spec.AlertDescriptionCloseNotify)
+		c.outMutex.Unlock()
+	}
+
+	return c.conn.Close()
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.deadlineMutex.Lock()
+	defer c.deadlineMutex.Unlock()
+
+	c.writeDeadline = t
+	return c.conn.SetDeadline(t)
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.deadlineMutex.Lock()
+	defer c.deadlineMutex.Unlock()
+
+	c.writeDeadline = t
+	return c.conn.SetWriteDeadline(t)
+}
+
+// NetConn returns the underlying connection.
+func (c *Conn) NetConn() net.Conn {
+	return c.conn
+}
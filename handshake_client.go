@@ -0,0 +1,707 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/internal/handshake"
+	"github.com/piligrimm/tls/internal/prf"
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+type clientHandshakeState uint8
+
+const (
+	clientStateStart clientHandshakeState = iota
+	clientStateWaitServerHello
+	clientStateWaitCertificate
+	clientStateWaitServerKeyExchange
+	clientStateWaitServerHelloDone
+	clientStateWaitNewSessionTicket
+	clientStateWaitChangeCipherSpec
+	clientStateWaitFinished
+	clientStateDone
+)
+
+func (s clientHandshakeState) String() string {
+	switch s {
+	case clientStateStart:
+		return "Start"
+	case clientStateWaitServerHello:
+		return "WaitServerHello"
+	case clientStateWaitCertificate:
+		return "WaitCertificate"
+	case clientStateWaitServerKeyExchange:
+		return "WaitServerKeyExchange"
+	case clientStateWaitServerHelloDone:
+		return "WaitServerHelloDone"
+	case clientStateWaitNewSessionTicket:
+		return "WaitNewSessionTicket"
+	case clientStateWaitChangeCipherSpec:
+		return "WaitChangeCipherSpec"
+	case clientStateWaitFinished:
+		return "WaitFinished"
+	case clientStateDone:
+		return "Done"
+	default:
+		return fmt.Sprintf("clientHandshakeState(%d)", uint8(s))
+	}
+}
+
+var clientExpectedMessages = map[clientHandshakeState]spec.HandshakeType{
+	clientStateWaitServerHello:       spec.HandshakeTypeServerHello,
+	clientStateWaitCertificate:       spec.HandshakeTypeCertificate,
+	clientStateWaitServerKeyExchange: spec.HandshakeTypeServerKeyExchange,
+	clientStateWaitServerHelloDone:   spec.HandshakeTypeServerHelloDone,
+	clientStateWaitNewSessionTicket:  spec.HandshakeTypeNewSessionTicket,
+	clientStateWaitFinished:          spec.HandshakeTypeFinished,
+}
+
+type clientHandshake struct {
+	c          *Conn
+	state      clientHandshakeState
+	transcript *handshake.Transcript
+
+	hello             *spec.ClientHello
+	serverHello       *spec.ServerHello
+	params            *ciphersuite.Parameters
+	serverCertificate *spec.ServerCertificate
+	serverKeyExchange *spec.ServerKeyExchange
+	masterSecret      []byte
+	keyBlock          *prf.KeyBlock
+	clientVerifyData  []byte
+	serverVerifyData  []byte
+
+	// session is the cached session offered in the ClientHello, resumed is set
+	// when the server accepted it. expectTicket is set when the server promised
+	// a NewSessionTicket, which is kept in ticket.
+	session      *ClientSessionState
+	resumed      bool
+	expectTicket bool
+	ticket       *spec.NewSessionTicket
+}
+
+func (c *Conn) clientHandshake() error {
+	hs := &clientHandshake{
+		c:          c,
+		state:      clientStateStart,
+		transcript: handshake.NewTranscript(),
+	}
+	return hs.run()
+}
+
+func (hs *clientHandshake) run() error {
+	if err := hs.sendClientHello(); err != nil {
+		return err
+	}
+
+	for hs.state != clientStateDone {
+		if hs.state == clientStateWaitChangeCipherSpec {
+			if err := hs.processChangeCipherSpec(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		message, err := hs.c.messages.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if err := hs.handleMessage(message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (hs *clientHandshake) handleMessage(message *spec.Handshake) error {
+	expected, ok := clientExpectedMessages[hs.state]
+	if !ok || message.MsgType != expected {
+		return alert.Errorf(spec.AlertDescriptionUnexpectedMessage, "unexpected %v message in state %v", message.MsgType, hs.state)
+	}
+
+	// the server Finished is verified against the transcript without itself
+	if hs.state == clientStateWaitFinished {
+		return hs.processServerFinished(message)
+	}
+	hs.transcript.Write(handshake.MarshalHandshake(message))
+
+	switch hs.state {
+	case clientStateWaitServerHello:
+		return hs.processServerHello(message.Body)
+	case clientStateWaitCertificate:
+		return hs.processCertificate(message.Body)
+	case clientStateWaitServerKeyExchange:
+		return hs.processServerKeyExchange(message.Body)
+	case clientStateWaitNewSessionTicket:
+		return hs.processNewSessionTicket(message.Body)
+	default:
+		return hs.processServerHelloDone(message.Body)
+	}
+}
+
+func clientHelloExtensions(config *Config) ([]spec.Extension, error) {
+	pointFormats, err := handshake.MarshalECPointFormats(&spec.ECPointFormatList{Formats: supportedPointFormats})
+	if err != nil {
+		return nil, err
+	}
+
+	supportedGroups, err := handshake.MarshalSupportedGroups(&spec.SupportedGroupList{Groups: config.supportedGroups()})
+	if err != nil {
+		return nil, err
+	}
+
+	signatureAlgorithms, err := handshake.MarshalSignatureAlgorithms(&spec.SignatureAlgorithmList{Algorithms: config.signatureAlgorithms()})
+	if err != nil {
+		return nil, err
+	}
+
+	extensions := []spec.Extension{
+		{Type: spec.ExtensionTypeSupportedGroups, Opaque: supportedGroups},
+		{Type: spec.ExtensionTypeECPointFormats, Opaque: pointFormats},
+		{Type: spec.ExtensionTypeSignatureAlgorithms, Opaque: signatureAlgorithms},
+	}
+
+	if !config.RenegotiationSCSV {
+		renegotiationInfo, err := handshake.MarshalRenegotiationInfo(&spec.RenegotiationInfo{})
+		if err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, spec.Extension{Type: spec.ExtensionTypeRenegotiationInfo, Opaque: renegotiationInfo})
+	}
+
+	extensions = append(extensions, spec.Extension{Type: spec.ExtensionTypeExtendedMasterSecret})
+
+	// encrypt_then_mac only changes the CBC suites
+	if slices.ContainsFunc(config.cipherSuites(), func(cipherSuite spec.CipherSuite) bool {
+		params, err := ciphersuite.Lookup(cipherSuite)
+		return err == nil && !params.AEAD()
+	}) {
+		extensions = append(extensions, spec.Extension{Type: spec.ExtensionTypeEncryptThenMAC})
+	}
+
+	// SNI carries DNS names only, a server addressed by IP gets no server_name
+	hostName := strings.TrimSuffix(config.ServerName, ".")
+	if handshake.ValidateHostName(hostName) == nil {
+		serverNameList, err := handshake.MarshalServerNameList(&spec.ServerNameList{
+			ServerNames: []spec.ServerName{{NameType: spec.ServerNameTypeHostName, HostName: hostName}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, spec.Extension{Type: spec.ExtensionTypeServerName, Opaque: serverNameList})
+	}
+
+	return extensions, nil
+}
+
+func (hs *clientHandshake) sendClientHello() error {
+	random := make([]byte, 32)
+	if _, err := io.ReadFull(hs.c.config.random(), random); err != nil {
+		return fmt.Errorf("failed to generate client random: %w", err)
+	}
+
+	extensions, err := clientHelloExtensions(hs.c.config)
+	if err != nil {
+		return err
+	}
+
+	var sessionID, ticket []byte
+	hs.session = hs.cachedSession()
+	if hs.session != nil {
+		sessionID = hs.session.sessionID
+		if !hs.c.config.SessionTicketsDisabled && len(hs.session.ticket) != 0 {
+			ticket = hs.session.ticket
+			// RFC 5077, section 3.4: the server echoes this session ID when it
+			// accepts the ticket
+			sessionID = make([]byte, 32)
+			if _, err := io.ReadFull(hs.c.config.random(), sessionID); err != nil {
+				return fmt.Errorf("failed to generate session ID: %w", err)
+			}
+		}
+		// a session known by a ticket alone cannot be offered without tickets
+		if len(sessionID) == 0 {
+			hs.session = nil
+		}
+	}
+	// without a cache a ticket could never be used, so none is asked for
+	if !hs.c.config.SessionTicketsDisabled && hs.c.config.ClientSessionCache != nil {
+		extensions = append(extensions, spec.Extension{Type: spec.ExtensionTypeSessionTicket, Opaque: ticket})
+	}
+
+	hs.hello, err = newClientHello(hs.c.config, random, sessionID, extensions)
+	if err != nil {
+		return err
+	}
+
+	err = hs.c.writeHandshake(hs.transcript, &spec.Handshake{
+		MsgType: spec.HandshakeTypeClientHello,
+		Body:    handshake.MarshalClientHello(hs.hello),
+	})
+	if err != nil {
+		return err
+	}
+
+	hs.state = clientStateWaitServerHello
+	return nil
+}
+
+// cachedSession returns the cached session for the server that the current
+// Config allows resuming, or nil.
+func (hs *clientHandshake) cachedSession() *ClientSessionState {
+	config := hs.c.config
+	if config.ClientSessionCache == nil {
+		return nil
+	}
+	session, ok := cacheGet(config.ClientSessionCache, clientSessionKey(config, hs.c.RemoteAddr()), config.now())
+	if !ok || session == nil || session.expired(config.now()) {
+		return nil
+	}
+	if !slices.Contains(config.cipherSuites(), session.cipherSuite) {
+		return nil
+	}
+	if config.RequireExtendedMasterSecret && !session.extendedMasterSecret {
+		return nil
+	}
+	return session
+}
+
+func (hs *clientHandshake) processServerHello(body []byte) error {
+	serverHello, err := handshake.UnmarshalServerHello(body)
+	if err != nil {
+		return alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+
+	version := serverHello.ServerTlsVersion
+	if !slices.Contains(hs.c.config.supportedVersions(), version) || versionNumber(version) > versionNumber(hs.hello.ClientTlsVersion) {
+		return alert.Errorf(spec.AlertDescriptionProtocolVersion, "server selected unsupported version %d.%d", version.Major, version.Minor)
+	}
+	hs.c.version = version
+
+	if !slices.Contains(hs.hello.CipherSuites, serverHello.CipherSuite) {
+		return alert.Errorf(spec.AlertDescriptionIllegalParameter, "server selected cipher suite %v that was not offered", serverHello.CipherSuite)
+	}
+
+	if serverHello.CompressionMethod != spec.CompressionMethodNull {
+		return alert.Errorf(spec.AlertDescriptionIllegalParameter, "server selected unsupported compression method %d", serverHello.CompressionMethod)
+	}
+
+	for _, extension := range serverHello.Extensions {
+		offered := slices.ContainsFunc(hs.hello.Extensions, func(e spec.Extension) bool {
+			return e.Type == extension.Type
+		})
+		// the SCSV asks for renegotiation_info just like the extension does
+		if extension.Type == spec.ExtensionTypeRenegotiationInfo && slices.Contains(hs.hello.CipherSuites, spec.CipherSuiteEMPTY_RENEGOTIATION_INFO_SCSV) {
+			offered = true
+		}
+		if !offered {
+			return alert.Errorf(spec.AlertDescriptionUnsupportedExtension, "server sent extension %v that was not offered", extension.Type)
+		}
+	}
+
+	if err := hs.processRenegotiationInfo(serverHello); err != nil {
+		return err
+	}
+
+	if err := hs.processExtendedMasterSecret(serverHello); err != nil {
+		return err
+	}
+
+	if extension, ok := utils.FindExtension(serverHello.Extensions, spec.ExtensionTypeECPointFormats); ok {
+		pointFormats, err := handshake.UnmarshalECPointFormats(extension.Opaque)
+		if err != nil {
+			return alert.Wrap(spec.AlertDescriptionDecodeError, err)
+		}
+		if !slices.Contains(pointFormats.Formats, spec.ECPointFormatUncompressed) {
+			return alert.New(spec.AlertDescriptionIllegalParameter, "server did not offer the uncompressed point format")
+		}
+	}
+
+	hs.params, err = ciphersuite.Lookup(serverHello.CipherSuite)
+	if err != nil {
+		return err
+	}
+	if err := hs.transcript.SetHash(hs.params.PRFHash); err != nil {
+		return err
+	}
+
+	if err := hs.processEncryptThenMAC(serverHello); err != nil {
+		return err
+	}
+
+	hs.serverHello = serverHello
+	hs.c.cipherSuite = serverHello.CipherSuite
+
+	if extension, ok := utils.FindExtension(serverHello.Extensions, spec.ExtensionTypeSessionTicket); ok {
+		if len(extension.Opaque) != 0 {
+			return alert.New(spec.AlertDescriptionDecodeError, "server sent a non-empty session_ticket extension")
+		}
+		hs.expectTicket = true
+	}
+
+	if hs.session != nil && len(serverHello.SessionID) != 0 && bytes.Equal(serverHello.SessionID, hs.hello.SessionID) {
+		return hs.resumeSession()
+	}
+
+	hs.state = clientStateWaitCertificate
+	return nil
+}
+
+// resumeSession continues the abbreviated handshake after the server accepted
+// the offered session, its ChangeCipherSpec and Finished come next.
+func (hs *clientHandshake) resumeSession() error {
+	if hs.serverHello.CipherSuite != hs.session.cipherSuite {
+		return alert.Errorf(spec.AlertDescriptionIllegalParameter, "server resumed a session with cipher suite %v instead of %v", hs.serverHello.CipherSuite, hs.session.cipherSuite)
+	}
+	// RFC 7627, section 5.3
+	if hs.c.extendedMasterSecret != hs.session.extendedMasterSecret {
+		return alert.New(spec.AlertDescriptionHandshakeFailure, "server resumed a session with a different extended master secret use")
+	}
+	// RFC 7366, section 3.1
+	if hs.c.encryptThenMAC != hs.session.encryptThenMAC {
+		return alert.New(spec.AlertDescriptionHandshakeFailure, "server resumed a session with a different encrypt_then_mac use")
+	}
+
+	hs.resumed = true
+	hs.c.didResume = true
+	hs.masterSecret = hs.session.masterSecret
+	hs.keyBlock = prf.NewKeyBlock(hs.params, hs.masterSecret, hs.hello.Random, hs.serverHello.Random)
+
+	hs.state = clientStateWaitChangeCipherSpec
+	if hs.expectTicket {
+		hs.state = clientStateWaitNewSessionTicket
+	}
+	return nil
+}
+
+func (hs *clientHandshake) processEncryptThenMAC(serverHello *spec.ServerHello) error {
+	extension, ok := utils.FindExtension(serverHello.Extensions, spec.ExtensionTypeEncryptThenMAC)
+	if !ok {
+		return nil
+	}
+	if len(extension.Opaque) != 0 {
+		return alert.New(spec.AlertDescriptionDecodeError, "encrypt_then_mac must be empty")
+	}
+	if hs.params.AEAD() {
+		return alert.Errorf(spec.AlertDescriptionIllegalParameter, "server negotiated encrypt_then_mac for AEAD cipher suite %v", hs.params.ID)
+	}
+
+	hs.c.encryptThenMAC = true
+	return nil
+}
+
+// processRenegotiationInfo checks that the server's renegotiation_info carries
+// the verify_data of the previous handshake, which is empty on the first one.
+func (hs *clientHandshake) processRenegotiationInfo(serverHello *spec.ServerHello) error {
+	extension, ok := utils.FindExtension(serverHello.Extensions, spec.ExtensionTypeRenegotiationInfo)
+	if !ok {
+		if hs.c.config.RequireSecureRenegotiation {
+			return alert.New(spec.AlertDescriptionHandshakeFailure, "server does not support secure renegotiation")
+		}
+		return nil
+	}
+
+	renegotiationInfo, err := handshake.UnmarshalRenegotiationInfo(extension.Opaque)
+	if err != nil {
+		return alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+	expected := append(slices.Clone(hs.c.clientVerifyData), hs.c.serverVerifyData...)
+	if subtle.ConstantTimeCompare(renegotiationInfo.RenegotiatedConnection, expected) != 1 {
+		return alert.New(spec.AlertDescriptionHandshakeFailure, "renegotiation_info does not match the previous handshake")
+	}
+
+	hs.c.secureRenegotiation = true
+	return nil
+}
+
+func (hs *clientHandshake) processExtendedMasterSecret(serverHello *spec.ServerHello) error {
+	extension, ok := utils.FindExtension(serverHello.Extensions, spec.ExtensionTypeExtendedMasterSecret)
+	if ok && len(extension.Opaque) != 0 {
+		return alert.New(spec.AlertDescriptionDecodeError, "extended_master_secret must be empty")
+	}
+	if !ok && hs.c.config.RequireExtendedMasterSecret {
+		return alert.New(spec.AlertDescriptionHandshakeFailure, "server does not support the extended master secret")
+	}
+
+	hs.c.extendedMasterSecret = ok
+	return nil
+}
+
+func (hs *clientHandshake) processCertificate(body []byte) error {
+	serverCertificate, err := handshake.UnmarshalServerCertificate(body)
+	if err != nil {
+		return alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+
+	if err := verifyServerCertificate(serverCertificate, hs.c.config); err != nil {
+		return err
+	}
+	// a verified chain proves nothing for a suite its key cannot sign for
+	if publicKey := serverCertificate.Certificates[0].PublicKey; !hs.params.UsableWith(publicKey) {
+		return alert.Errorf(spec.AlertDescriptionUnsupportedCertificate, "server certificate key of type %T does not match cipher suite %v", publicKey, hs.params.ID)
+	}
+
+	hs.serverCertificate = serverCertificate
+	hs.state = clientStateWaitServerKeyExchange
+	// the RSA key exchange encrypts to the certificate key, the server sends no
+	// ServerKeyExchange
+	if hs.params.KeyExchange == ciphersuite.KeyExchangeRSA {
+		hs.state = clientStateWaitServerHelloDone
+	}
+	return nil
+}
+
+func verifyServerCertificate(serverCertificate *spec.ServerCertificate, config *Config) error {
+	if len(serverCertificate.Certificates) == 0 {
+		return alert.New(spec.AlertDescriptionBadCertificate, "server sent an empty certificate chain")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range serverCertificate.Certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	_, err := serverCertificate.Certificates[0].Verify(x509.VerifyOptions{
+		DNSName:       config.ServerName,
+		Roots:         config.RootCAs,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		return alert.Wrap(certificateAlert(err), fmt.Errorf("failed to verify server certificate: %w", err))
+	}
+
+	return nil
+}
+
+// certificateAlert picks the alert for a failed verification of the server chain.
+func certificateAlert(err error) spec.AlertDescription {
+	var unknownAuthority x509.UnknownAuthorityError
+	if errors.As(err, &unknownAuthority) {
+		return spec.AlertDescriptionUnknownCA
+	}
+
+	var invalid x509.CertificateInvalidError
+	if errors.As(err, &invalid) && invalid.Reason == x509.Expired {
+		return spec.AlertDescriptionCertificateExpired
+	}
+
+	return spec.AlertDescriptionBadCertificate
+}
+
+func (hs *clientHandshake) processServerKeyExchange(body []byte) error {
+	serverKeyExchange, err := handshake.UnmarshalServerKeyExchange(body)
+	if err != nil {
+		return alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+
+	if !slices.Contains(hs.c.config.supportedGroups(), serverKeyExchange.Params.NamedCurve) {
+		return alert.Errorf(spec.AlertDescriptionIllegalParameter, "server selected group %#04x that was not offered", uint16(serverKeyExchange.Params.NamedCurve))
+	}
+
+	if !slices.Contains(hs.c.config.signatureAlgorithms(), serverKeyExchange.SignatureAlgorithm) {
+		return alert.Errorf(spec.AlertDescriptionIllegalParameter, "server used signature algorithm %#04x that was not offered", uint16(serverKeyExchange.SignatureAlgorithm))
+	}
+
+	err = handshake.VerifyServerKeyExchange(serverKeyExchange, hs.hello.Random, hs.serverHello.Random, hs.serverCertificate)
+	if err != nil {
+		return err
+	}
+
+	hs.serverKeyExchange = serverKeyExchange
+	hs.state = clientStateWaitServerHelloDone
+	return nil
+}
+
+func (hs *clientHandshake) processServerHelloDone(body []byte) error {
+	if _, err := handshake.UnmarshalServerHelloDone(body); err != nil {
+		return alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+
+	clientKeyExchange, preMasterSecret, err := hs.newClientKeyExchange()
+	if err != nil {
+		return err
+	}
+
+	err = hs.c.writeHandshake(hs.transcript, &spec.Handshake{
+		MsgType: spec.HandshakeTypeClientKeyExchange,
+		Body:    clientKeyExchange,
+	})
+	if err != nil {
+		return err
+	}
+
+	hs.masterSecret, err = handshake.MasterSecret(hs.params, preMasterSecret, hs.hello.Random, hs.serverHello.Random, hs.transcript, hs.c.extendedMasterSecret)
+	if err != nil {
+		return err
+	}
+	hs.keyBlock = prf.NewKeyBlock(hs.params, hs.masterSecret, hs.hello.Random, hs.serverHello.Random)
+
+	if err := hs.sendFinished(); err != nil {
+		return err
+	}
+
+	hs.state = clientStateWaitChangeCipherSpec
+	if hs.expectTicket {
+		hs.state = clientStateWaitNewSessionTicket
+	}
+	return nil
+}
+
+// newClientKeyExchange returns the encoded ClientKeyExchange of the negotiated
+// suite's key exchange together with the pre-master secret.
+func (hs *clientHandshake) newClientKeyExchange() ([]byte, []byte, error) {
+	random := hs.c.config.random()
+	if hs.params.KeyExchange == ciphersuite.KeyExchangeRSA {
+		clientKeyExchange, preMasterSecret, err := handshake.NewRSAClientKeyExchange(random, hs.serverCertificate.Certificates[0].PublicKey, hs.hello.ClientTlsVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+		body, err := handshake.MarshalEncryptedPreMasterSecret(clientKeyExchange)
+		return body, preMasterSecret, err
+	}
+
+	clientKeyExchange, preMasterSecret, err := handshake.NewClientKeyExchange(random, &hs.serverKeyExchange.Params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handshake.MarshalClientKeyExchange(clientKeyExchange), preMasterSecret, nil
+}
+
+func (hs *clientHandshake) sendFinished() error {
+	if err := handshake.WriteChangeCipherSpec(hs.c.writer); err != nil {
+		return err
+	}
+	protector, err := hs.c.newProtector(hs.params, hs.keyBlock.ClientKey, hs.keyBlock.ClientIV, hs.keyBlock.ClientMACKey)
+	if err != nil {
+		return err
+	}
+	hs.c.writer.SetProtector(protector)
+
+	finished, err := handshake.NewFinished(hs.params, hs.masterSecret, prf.ClientFinishedLabel, hs.transcript)
+	if err != nil {
+		return err
+	}
+	err = hs.c.writeHandshake(hs.transcript, &spec.Handshake{
+		MsgType: spec.HandshakeTypeFinished,
+		Body:    handshake.MarshalFinished(finished),
+	})
+	if err != nil {
+		return err
+	}
+	hs.clientVerifyData = finished.VerifyData
+
+	return nil
+}
+
+func (hs *clientHandshake) processNewSessionTicket(body []byte) error {
+	ticket, err := handshake.UnmarshalNewSessionTicket(body)
+	if err != nil {
+		return alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+	hs.ticket = ticket
+
+	hs.state = clientStateWaitChangeCipherSpec
+	return nil
+}
+
+func (hs *clientHandshake) processChangeCipherSpec() error {
+	if err := hs.c.messages.ReadChangeCipherSpec(); err != nil {
+		return err
+	}
+
+	protector, err := hs.c.newProtector(hs.params, hs.keyBlock.ServerKey, hs.keyBlock.ServerIV, hs.keyBlock.ServerMACKey)
+	if err != nil {
+		return err
+	}
+	hs.c.records.SetProtector(protector)
+
+	hs.state = clientStateWaitFinished
+	return nil
+}
+
+func (hs *clientHandshake) processServerFinished(message *spec.Handshake) error {
+	finished, err := handshake.UnmarshalFinished(message.Body)
+	if err != nil {
+		return alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+
+	if err := handshake.VerifyFinished(finished, hs.params, hs.masterSecret, prf.ServerFinishedLabel, hs.transcript); err != nil {
+		return err
+	}
+	hs.serverVerifyData = finished.VerifyData
+
+	// in the abbreviated handshake the client answers the server's Finished
+	if hs.resumed {
+		hs.transcript.Write(handshake.MarshalHandshake(message))
+		if err := hs.sendFinished(); err != nil {
+			return err
+		}
+	}
+	hs.c.clientVerifyData = hs.clientVerifyData
+	hs.c.serverVerifyData = hs.serverVerifyData
+	hs.storeSession()
+
+	hs.state = clientStateDone
+	return nil
+}
+
+// storeSession caches the session established by a full handshake under the
+// session ID or ticket the server issued, and replaces the ticket of a resumed
+// one with a renewed ticket. A cached session the server declined is dropped
+// when nothing replaces it.
+func (hs *clientHandshake) storeSession() {
+	config := hs.c.config
+	if config.ClientSessionCache == nil {
+		return
+	}
+	sessionKey := clientSessionKey(config, hs.c.RemoteAddr())
+
+	var ticket []byte
+	if hs.ticket != nil {
+		ticket = hs.ticket.Ticket
+	}
+
+	if hs.resumed {
+		if len(ticket) != 0 {
+			renewed := *hs.session
+			renewed.ticket = ticket
+			renewed.receivedAt = config.now()
+			renewed.lifetime = time.Duration(hs.ticket.LifetimeHint) * time.Second
+			cachePut(config.ClientSessionCache, sessionKey, &renewed, config.now())
+		}
+		return
+	}
+
+	if len(ticket) == 0 && len(hs.serverHello.SessionID) == 0 {
+		if hs.session != nil {
+			config.ClientSessionCache.Delete(sessionKey)
+		}
+		return
+	}
+
+	state := &ClientSessionState{
+		sessionID:            hs.serverHello.SessionID,
+		ticket:               ticket,
+		cipherSuite:          hs.params.ID,
+		masterSecret:         hs.masterSecret,
+		extendedMasterSecret: hs.c.extendedMasterSecret,
+		encryptThenMAC:       hs.c.encryptThenMAC,
+		serverCertificates:   hs.serverCertificate.Certificates,
+		receivedAt:           config.now(),
+	}
+	if len(ticket) != 0 {
+		state.lifetime = time.Duration(hs.ticket.LifetimeHint) * time.Second
+	}
+	cachePut(config.ClientSessionCache, sessionKey, state, config.now())
+}
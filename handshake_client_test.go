@@ -0,0 +1,442 @@
+package tls
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/internal/handshake"
+	"github.com/piligrimm/tls/internal/prf"
+	"github.com/piligrimm/tls/internal/record"
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+type testServerBehavior uint8
+
+const (
+	testServerHonest testServerBehavior = iota
+	testServerSkipsCertificate
+	testServerStopsAfterServerHello
+	testServerBadSignature
+	testServerSHA1Signature
+	testServerBadFinished
+	testServerEncryptThenMACWithAEAD
+	testServerECDSASuiteWithRSACertificate
+	testServerOldVersion
+)
+
+type testServer struct {
+	t           *testing.T
+	conn        net.Conn
+	behavior    testServerBehavior
+	key         *rsa.PrivateKey
+	certificate *x509.Certificate
+}
+
+// run plays the server side of an ECDHE_RSA handshake and echoes one
+// line of application data. It stops at the first error and returns it, after
+// a misbehavior that is the alert the client answered with.
+func (s *testServer) run() error {
+	defer s.conn.Close()
+
+	records := record.NewReader(s.conn)
+	writer := record.NewWriter(s.conn, spec.Tls12ProtocolVersion())
+	messages := handshake.NewReader(records)
+	transcript := handshake.NewTranscript()
+	send := func(msgType spec.HandshakeType, body []byte) error {
+		raw := handshake.MarshalHandshake(&spec.Handshake{MsgType: msgType, Body: body})
+		transcript.Write(raw)
+		return writer.WriteRecords(spec.ContentTypeHandshake, raw)
+	}
+
+	message, err := messages.ReadMessage()
+	if err != nil {
+		return err
+	}
+	transcript.Write(handshake.MarshalHandshake(message))
+	clientHello, err := handshake.UnmarshalClientHello(message.Body)
+	if err != nil {
+		return err
+	}
+
+	cipherSuite := spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256
+	if s.behavior == testServerECDSASuiteWithRSACertificate {
+		cipherSuite = spec.CipherSuiteECDHE_ECDSA_WITH_AES_128_GCM_SHA256
+	}
+	params, _ := ciphersuite.Lookup(cipherSuite)
+	_ = transcript.SetHash(params.PRFHash)
+	serverRandom := make([]byte, 32)
+	rand.Read(serverRandom)
+	serverHello := &spec.ServerHello{
+		ServerTlsVersion:  spec.Tls12ProtocolVersion(),
+		Random:            serverRandom,
+		CipherSuite:       params.ID,
+		CompressionMethod: spec.CompressionMethodNull,
+	}
+	if s.behavior == testServerEncryptThenMACWithAEAD {
+		serverHello.Extensions = []spec.Extension{{Type: spec.ExtensionTypeEncryptThenMAC}}
+	}
+	if s.behavior == testServerOldVersion {
+		serverHello.ServerTlsVersion = spec.ProtocolVersion{Major: 3, Minor: 1}
+	}
+	if err := send(spec.HandshakeTypeServerHello, handshake.MarshalServerHello(serverHello)); err != nil {
+		return err
+	}
+	if s.behavior == testServerEncryptThenMACWithAEAD || s.behavior == testServerOldVersion {
+		_, err := messages.ReadMessage()
+		return err
+	}
+
+	// the ServerHello carries no extensions, so a strict client stops here
+	if s.behavior == testServerStopsAfterServerHello {
+		_, err := messages.ReadMessage()
+		return err
+	}
+
+	if s.behavior == testServerSkipsCertificate {
+		if err := send(spec.HandshakeTypeServerHelloDone, nil); err != nil {
+			return err
+		}
+		_, err := messages.ReadMessage()
+		return err
+	}
+
+	certificateBody := utils.AppendUint24(nil, 3+len(s.certificate.Raw))
+	certificateBody = utils.AppendUint24(certificateBody, len(s.certificate.Raw))
+	certificateBody = append(certificateBody, s.certificate.Raw...)
+	if err := send(spec.HandshakeTypeCertificate, certificateBody); err != nil {
+		return err
+	}
+	if s.behavior == testServerECDSASuiteWithRSACertificate {
+		_, err := messages.ReadMessage()
+		return err
+	}
+
+	ecdheKey, _ := handshake.GenerateECDHEKey(rand.Reader, spec.SupportedGroupsSecp256r1)
+	signatureAlgorithm := spec.SignatureAlgorithmRsaPkcs1Sha256
+	if s.behavior == testServerSHA1Signature {
+		signatureAlgorithm = spec.SignatureAlgorithmRsaPkcs1Sha1
+	}
+	serverKeyExchange, err := handshake.NewServerKeyExchange(rand.Reader, s.key, signatureAlgorithm, clientHello.Random, serverRandom, &spec.ServerECDHParams{
+		CurveType:  spec.ECCurveTypeNamedCurve,
+		NamedCurve: spec.SupportedGroupsSecp256r1,
+		PublicKey:  ecdheKey.PublicKey().Bytes(),
+	})
+	if err != nil {
+		return err
+	}
+	if s.behavior == testServerBadSignature {
+		serverKeyExchange.Signature[len(serverKeyExchange.Signature)-1] ^= 0xff
+	}
+	if err := send(spec.HandshakeTypeServerKeyExchange, handshake.MarshalServerKeyExchange(serverKeyExchange)); err != nil {
+		return err
+	}
+	if s.behavior == testServerBadSignature || s.behavior == testServerSHA1Signature {
+		_, err := messages.ReadMessage()
+		return err
+	}
+	if err := send(spec.HandshakeTypeServerHelloDone, nil); err != nil {
+		return err
+	}
+
+	message, err = messages.ReadMessage()
+	if err != nil {
+		return err
+	}
+	transcript.Write(handshake.MarshalHandshake(message))
+	clientKeyExchange, err := handshake.UnmarshalClientKeyExchange(message.Body)
+	if err != nil {
+		return err
+	}
+	preMasterSecret, err := handshake.ECDHEPreMasterSecret(ecdheKey, clientKeyExchange.PublicKey)
+	if err != nil {
+		return err
+	}
+	masterSecret := prf.MasterSecret(params, preMasterSecret, clientHello.Random, serverRandom)
+	keyBlock := prf.NewKeyBlock(params, masterSecret, clientHello.Random, serverRandom)
+
+	if err := messages.ReadChangeCipherSpec(); err != nil {
+		return err
+	}
+	opener, _ := record.NewProtector(params, keyBlock.ClientKey, keyBlock.ClientIV, nil)
+	records.SetProtector(opener)
+
+	message, err = messages.ReadMessage()
+	if err != nil {
+		return err
+	}
+	clientFinished, err := handshake.UnmarshalFinished(message.Body)
+	if err != nil {
+		return err
+	}
+	if err := handshake.VerifyFinished(clientFinished, params, masterSecret, prf.ClientFinishedLabel, transcript); err != nil {
+		s.t.Errorf("client Finished did not verify: %v", err)
+		return err
+	}
+	transcript.Write(handshake.MarshalHandshake(message))
+
+	if err := handshake.WriteChangeCipherSpec(writer); err != nil {
+		return err
+	}
+	sealer, _ := record.NewProtector(params, keyBlock.ServerKey, keyBlock.ServerIV, nil)
+	writer.SetProtector(sealer)
+
+	serverFinished, _ := handshake.NewFinished(params, masterSecret, prf.ServerFinishedLabel, transcript)
+	if s.behavior == testServerBadFinished {
+		serverFinished.VerifyData[0] ^= 0xff
+	}
+	if err := send(spec.HandshakeTypeFinished, handshake.MarshalFinished(serverFinished)); err != nil {
+		return err
+	}
+
+	rec, err := records.ReadRecord()
+	if err != nil {
+		return err
+	}
+	if rec.ContentType == spec.ContentTypeAlert {
+		return alert.Received(rec.Fragment)
+	}
+	return writer.WriteRecords(spec.ContentTypeApplicationData, append([]byte("echo: "), rec.Fragment...))
+}
+
+// newTestClient connects a client to a scripted server, whose result is
+// delivered on the returned channel.
+func newTestClient(t *testing.T, behavior testServerBehavior) (*Conn, <-chan error) {
+	t.Helper()
+
+	certificate := newTestCertificate(t)
+	clientSide, serverSide := net.Pipe()
+	server := &testServer{
+		t:           t,
+		conn:        serverSide,
+		behavior:    behavior,
+		key:         certificate.PrivateKey.(*rsa.PrivateKey),
+		certificate: certificate.Chain[0],
+	}
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- server.run() }()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(certificate.Chain[0])
+	config := &Config{ServerName: "localhost", RootCAs: roots}
+	// a CBC suite makes the client offer encrypt_then_mac
+	if behavior == testServerEncryptThenMACWithAEAD {
+		config.CipherSuites = []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256, spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA256}
+	}
+	client := Client(clientSide, config)
+	t.Cleanup(func() { clientSide.Close() })
+
+	return client, serverErr
+}
+
+func TestClientHandshake_ExchangesApplicationData(t *testing.T) {
+	client, _ := newTestClient(t, testServerHonest)
+
+	if err := client.Handshake(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := client.Write([]byte("ping\n")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	reply, err := bufio.NewReader(client).ReadString('\n')
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if reply != "echo: ping\n" {
+		t.Errorf("Expected %q, got %q", "echo: ping\n", reply)
+	}
+}
+
+func TestClientHandshake_Failures(t *testing.T) {
+	testCases := []struct {
+		name     string
+		behavior testServerBehavior
+		expected string
+		alert    spec.AlertDescription
+	}{
+		{
+			name:     "unexpected message",
+			behavior: testServerSkipsCertificate,
+			expected: "unexpected ServerHelloDone message in state WaitCertificate",
+			alert:    spec.AlertDescriptionUnexpectedMessage,
+		},
+		{
+			name:     "bad ServerKeyExchange signature",
+			behavior: testServerBadSignature,
+			expected: "invalid ServerKeyExchange signature",
+			alert:    spec.AlertDescriptionDecryptError,
+		},
+		{
+			name:     "signature algorithm not offered",
+			behavior: testServerSHA1Signature,
+			expected: "server used signature algorithm 0x0201 that was not offered",
+			alert:    spec.AlertDescriptionIllegalParameter,
+		},
+		{
+			name:     "version below TLS 1.2",
+			behavior: testServerOldVersion,
+			expected: "server selected unsupported version 3.1",
+			alert:    spec.AlertDescriptionProtocolVersion,
+		},
+		{
+			name:     "encrypt_then_mac with an AEAD suite",
+			behavior: testServerEncryptThenMACWithAEAD,
+			expected: "server negotiated encrypt_then_mac for AEAD cipher suite TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			alert:    spec.AlertDescriptionIllegalParameter,
+		},
+		{
+			name:     "ECDSA suite with an RSA certificate",
+			behavior: testServerECDSASuiteWithRSACertificate,
+			expected: "server certificate key of type *rsa.PublicKey does not match cipher suite TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+			alert:    spec.AlertDescriptionUnsupportedCertificate,
+		},
+		{
+			name:     "bad server Finished",
+			behavior: testServerBadFinished,
+			expected: "verify_data of Finished does not match",
+			alert:    spec.AlertDescriptionDecryptError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, serverErr := newTestClient(t, tc.behavior)
+
+			err := client.Handshake()
+
+			if err == nil {
+				t.Fatal("Expected handshake error")
+			}
+			if !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("Expected error containing %q, got %q", tc.expected, err.Error())
+			}
+			var alertErr *AlertError
+			if err := <-serverErr; !errors.As(err, &alertErr) || alertErr.Description != tc.alert {
+				t.Errorf("Expected the server to receive a %v alert, got %v", tc.alert, err)
+			}
+		})
+	}
+}
+
+func TestClientConn_HandshakeErrorIsSticky(t *testing.T) {
+	client, _ := newTestClient(t, testServerBadFinished)
+
+	_, writeErr := client.Write([]byte("early"))
+	_, readErr := client.Read(make([]byte, 1))
+
+	if writeErr == nil {
+		t.Fatal("Expected Write to fail the handshake")
+	}
+	if readErr != writeErr {
+		t.Errorf("Expected Read to return %v, got %v", writeErr, readErr)
+	}
+	if err := client.Handshake(); err != writeErr {
+		t.Errorf("Expected Handshake to return %v, got %v", writeErr, err)
+	}
+}
+
+func TestClientHandshake_RequiresServerName(t *testing.T) {
+	clientSide, serverSide := net.Pipe()
+	defer serverSide.Close()
+	client := Client(clientSide, &Config{})
+	defer client.Close()
+
+	err := client.Handshake()
+
+	expected := "server name must be set in the client config"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+}
+
+func TestClientHandshake_OffersSessionTicket(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cache    ClientSessionCache
+		disabled bool
+		expected bool
+	}{
+		{name: "with a session cache", cache: NewLRUClientSessionCache(1, time.Hour), expected: true},
+		{name: "without a session cache"},
+		{name: "tickets disabled", cache: NewLRUClientSessionCache(1, time.Hour), disabled: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientSide, serverSide := net.Pipe()
+			client := Client(clientSide, &Config{
+				ServerName:             "localhost",
+				ClientSessionCache:     tc.cache,
+				SessionTicketsDisabled: tc.disabled,
+			})
+			defer client.Close()
+			go client.Handshake()
+
+			message, err := handshake.NewReader(record.NewReader(serverSide)).ReadMessage()
+			serverSide.Close()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			clientHello, err := handshake.UnmarshalClientHello(message.Body)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			offered := false
+			for _, extension := range clientHello.Extensions {
+				if extension.Type == spec.ExtensionTypeSessionTicket {
+					offered = true
+				}
+			}
+			if offered != tc.expected {
+				t.Errorf("Expected session_ticket offered to be %v, got %v", tc.expected, offered)
+			}
+		})
+	}
+}
+
+func TestClientHandshake_StrictPolicies(t *testing.T) {
+	testCases := []struct {
+		name     string
+		require  func(config *Config)
+		expected string
+	}{
+		{
+			name:     "secure renegotiation required",
+			require:  func(config *Config) { config.RequireSecureRenegotiation = true },
+			expected: "server does not support secure renegotiation",
+		},
+		{
+			name:     "extended master secret required",
+			require:  func(config *Config) { config.RequireExtendedMasterSecret = true },
+			expected: "server does not support the extended master secret",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, serverErr := newTestClient(t, testServerStopsAfterServerHello)
+			tc.require(client.config)
+
+			err := client.Handshake()
+
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("Expected error %q, got %v", tc.expected, err)
+			}
+			var alertErr *AlertError
+			if err := <-serverErr; !errors.As(err, &alertErr) || alertErr.Description != spec.AlertDescriptionHandshakeFailure {
+				t.Errorf("Expected the server to receive a HandshakeFailure alert, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,839 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/internal/handshake"
+	"github.com/piligrimm/tls/internal/prf"
+	"github.com/piligrimm/tls/internal/session"
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+type serverHandshakeState uint8
+
+const (
+	serverStateWaitClientHello serverHandshakeState = iota
+	serverStateWaitClientKeyExchange
+	serverStateWaitChangeCipherSpec
+	serverStateWaitFinished
+	serverStateDone
+)
+
+func (s serverHandshakeState) String() string {
+	switch s {
+	case serverStateWaitClientHello:
+		return "WaitClientHello"
+	case serverStateWaitClientKeyExchange:
+		return "WaitClientKeyExchange"
+	case serverStateWaitChangeCipherSpec:
+		return "WaitChangeCipherSpec"
+	case serverStateWaitFinished:
+		return "WaitFinished"
+	case serverStateDone:
+		return "Done"
+	default:
+		return fmt.Sprintf("serverHandshakeState(%d)", uint8(s))
+	}
+}
+
+var serverExpectedMessages = map[serverHandshakeState]spec.HandshakeType{
+	serverStateWaitClientHello:       spec.HandshakeTypeClientHello,
+	serverStateWaitClientKeyExchange: spec.HandshakeTypeClientKeyExchange,
+	serverStateWaitFinished:          spec.HandshakeTypeFinished,
+}
+
+type serverHandshake struct {
+	c          *Conn
+	state      serverHandshakeState
+	transcript *handshake.Transcript
+
+	certificate *Certificate
+	clientHello *spec.ClientHello
+	hello       *spec.ServerHello
+	params      *ciphersuite.Parameters
+	group       spec.SupportedGroup
+
+	signatureAlgorithm spec.SignatureAlgorithm
+	ecdheKey           *ecdh.PrivateKey
+	masterSecret       []byte
+	keyBlock           *prf.KeyBlock
+	clientVerifyData   []byte
+	serverVerifyData   []byte
+
+	// resumed is set for the abbreviated handshake, sendTicket when a
+	// NewSessionTicket precedes the server's ChangeCipherSpec
+	resumed    bool
+	sendTicket bool
+
+	encryptThenMACOffered bool
+}
+
+func (c *Conn) serverHandshake() error {
+	hs := &serverHandshake{
+		c:          c,
+		state:      serverStateWaitClientHello,
+		transcript: handshake.NewTranscript(),
+	}
+	return hs.run()
+}
+
+func (hs *serverHandshake) run() error {
+	for hs.state != serverStateDone {
+		if hs.state == serverStateWaitChangeCipherSpec {
+			if err := hs.processChangeCipherSpec(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		message, err := hs.c.messages.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if err := hs.handleMessage(message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (hs *serverHandshake) handleMessage(message *spec.Handshake) error {
+	expected, ok := serverExpectedMessages[hs.state]
+	if !ok || message.MsgType != expected {
+		return alert.Errorf(spec.AlertDescriptionUnexpectedMessage, "unexpected %v message in state %v", message.MsgType, hs.state)
+	}
+
+	// the client Finished is verified against the transcript without itself
+	if hs.state == serverStateWaitFinished {
+		return hs.processClientFinished(message)
+	}
+	hs.transcript.Write(handshake.MarshalHandshake(message))
+
+	switch hs.state {
+	case serverStateWaitClientHello:
+		return hs.processClientHello(message.Body)
+	default:
+		return hs.processClientKeyExchange(message.Body)
+	}
+}
+
+// selectCipherSuite returns the parameters of the first suite of the server's
+// preference list that the client offered and that the certificate key can
+// authenticate. ECDHE suites are only selected when the client and the server
+// share a group and signatureErr, the reason no signature algorithm could be
+// selected for the ServerKeyExchange, is nil. RSA key exchange suites are only
+// selected when the key can decrypt.
+func selectCipherSuite(config *Config, clientCipherSuites []spec.CipherSuite, key crypto.Signer, groupShared bool, signatureErr error) (*ciphersuite.Parameters, error) {
+	publicKey := key.Public()
+	_, canDecrypt := key.(crypto.Decrypter)
+	skippedECDHE, skippedSignature, skippedKey := false, false, false
+	for _, cipherSuite := range config.cipherSuites() {
+		if !slices.Contains(clientCipherSuites, cipherSuite) {
+			continue
+		}
+		params, err := ciphersuite.Lookup(cipherSuite)
+		if err != nil {
+			return nil, err
+		}
+		if !params.UsableWith(publicKey) {
+			skippedKey = true
+			continue
+		}
+		if params.KeyExchange == ciphersuite.KeyExchangeRSA && !canDecrypt {
+			skippedKey = true
+			continue
+		}
+		if params.KeyExchange == ciphersuite.KeyExchangeECDHE && !groupShared {
+			skippedECDHE = true
+			continue
+		}
+		if params.KeyExchange == ciphersuite.KeyExchangeECDHE && signatureErr != nil {
+			skippedSignature = true
+			continue
+		}
+		return params, nil
+	}
+
+	if skippedECDHE {
+		return nil, alert.New(spec.AlertDescriptionHandshakeFailure, "no group shared with the client for an ECDHE cipher suite")
+	}
+	if skippedSignature {
+		return nil, signatureErr
+	}
+	if skippedKey {
+		return nil, alert.Errorf(spec.AlertDescriptionHandshakeFailure, "no cipher suite shared with the client for a key of type %T", publicKey)
+	}
+	return nil, alert.New(spec.AlertDescriptionHandshakeFailure, "no cipher suite shared with the client")
+}
+
+// selectVersion returns the highest version of the server's that is not above
+// clientVersion, the highest version the client supports (RFC 5246, appendix E.1).
+func selectVersion(config *Config, clientVersion spec.ProtocolVersion) (spec.ProtocolVersion, error) {
+	for _, version := range config.supportedVersions() {
+		if versionNumber(version) <= versionNumber(clientVersion) {
+			return version, nil
+		}
+	}
+
+	return spec.ProtocolVersion{}, alert.Errorf(spec.AlertDescriptionProtocolVersion, "client version %d.%d is lower than any supported version", clientVersion.Major, clientVersion.Minor)
+}
+
+// selectGroup returns the first group of the server's preference list that the
+// client supports. A client without supported_groups is free to be sent any
+// group (RFC 8422, section 4), so the most preferred one is used.
+func selectGroup(config *Config, clientHello *spec.ClientHello) (spec.SupportedGroup, bool, error) {
+	extension, ok := utils.FindExtension(clientHello.Extensions, spec.ExtensionTypeSupportedGroups)
+	if !ok {
+		return config.supportedGroups()[0], true, nil
+	}
+
+	clientGroups, err := handshake.UnmarshalSupportedGroups(extension.Opaque)
+	if err != nil {
+		return 0, false, alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+	for _, group := range config.supportedGroups() {
+		if slices.Contains(clientGroups.Groups, group) {
+			return group, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// checkPointFormats rejects a client whose ec_point_formats lacks the
+// uncompressed format, the only one this package sends or accepts.
+func checkPointFormats(clientHello *spec.ClientHello) error {
+	extension, ok := utils.FindExtension(clientHello.Extensions, spec.ExtensionTypeECPointFormats)
+	if !ok {
+		return nil
+	}
+
+	pointFormats, err := handshake.UnmarshalECPointFormats(extension.Opaque)
+	if err != nil {
+		return alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+	if !slices.Contains(pointFormats.Formats, spec.ECPointFormatUncompressed) {
+		return alert.New(spec.AlertDescriptionIllegalParameter, "client did not offer the uncompressed point format")
+	}
+
+	return nil
+}
+
+// selectSignatureAlgorithm returns the first algorithm of Config.SignatureAlgorithms
+// that the client offered and that can be used with the server key, the server's
+// order wins over the client's. A client that sent no signature_algorithms gets
+// the SHA-1 default unless it is disabled.
+func selectSignatureAlgorithm(config *Config, clientHello *spec.ClientHello, key crypto.Signer) (spec.SignatureAlgorithm, error) {
+	extension, ok := utils.FindExtension(clientHello.Extensions, spec.ExtensionTypeSignatureAlgorithms)
+	if !ok {
+		if config.DisableSHA1Fallback {
+			return 0, alert.New(spec.AlertDescriptionHandshakeFailure, "client sent no signature_algorithms and the SHA-1 fallback is disabled")
+		}
+		return handshake.DefaultSignatureAlgorithm(key.Public())
+	}
+
+	clientSignatureAlgorithms, err := handshake.UnmarshalSignatureAlgorithms(extension.Opaque)
+	if err != nil {
+		return 0, alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+
+	return handshake.SelectSignatureAlgorithm(config.signatureAlgorithms(), clientSignatureAlgorithms.Algorithms, key.Public())
+}
+
+// processRenegotiationInfo records whether the client supports secure
+// renegotiation. Its renegotiation_info must carry the client verify_data of the
+// previous handshake, which is empty on the first one.
+func (hs *serverHandshake) processRenegotiationInfo(clientHello *spec.ClientHello) error {
+	extension, ok := utils.FindExtension(clientHello.Extensions, spec.ExtensionTypeRenegotiationInfo)
+	if ok {
+		renegotiationInfo, err := handshake.UnmarshalRenegotiationInfo(extension.Opaque)
+		if err != nil {
+			return alert.Wrap(spec.AlertDescriptionDecodeError, err)
+		}
+		if subtle.ConstantTimeCompare(renegotiationInfo.RenegotiatedConnection, hs.c.clientVerifyData) != 1 {
+			return alert.New(spec.AlertDescriptionHandshakeFailure, "renegotiation_info does not match the previous handshake")
+		}
+	}
+
+	secureRenegotiation := ok || slices.Contains(clientHello.CipherSuites, spec.CipherSuiteEMPTY_RENEGOTIATION_INFO_SCSV)
+	if !secureRenegotiation && hs.c.config.RequireSecureRenegotiation {
+		return alert.New(spec.AlertDescriptionHandshakeFailure, "client does not support secure renegotiation")
+	}
+
+	hs.c.secureRenegotiation = secureRenegotiation
+	return nil
+}
+
+func (hs *serverHandshake) processExtendedMasterSecret(clientHello *spec.ClientHello) error {
+	extension, ok := utils.FindExtension(clientHello.Extensions, spec.ExtensionTypeExtendedMasterSecret)
+	if ok && len(extension.Opaque) != 0 {
+		return alert.New(spec.AlertDescriptionDecodeError, "extended_master_secret must be empty")
+	}
+	if !ok && hs.c.config.RequireExtendedMasterSecret {
+		return alert.New(spec.AlertDescriptionHandshakeFailure, "client does not support the extended master secret")
+	}
+
+	hs.c.extendedMasterSecret = ok
+	return nil
+}
+
+func (hs *serverHandshake) processEncryptThenMAC(clientHello *spec.ClientHello) error {
+	extension, ok := utils.FindExtension(clientHello.Extensions, spec.ExtensionTypeEncryptThenMAC)
+	if ok && len(extension.Opaque) != 0 {
+		return alert.New(spec.AlertDescriptionDecodeError, "encrypt_then_mac must be empty")
+	}
+
+	hs.encryptThenMACOffered = ok
+	return nil
+}
+
+// certificateForName returns the first certificate valid for the name the client
+// asked for, or the first certificate when the client sent no name.
+func certificateForName(config *Config, serverName string) (*Certificate, error) {
+	if serverName == "" {
+		return &config.Certificates[0], nil
+	}
+	for i := range config.Certificates {
+		if config.Certificates[i].Chain[0].VerifyHostname(serverName) == nil {
+			return &config.Certificates[i], nil
+		}
+	}
+
+	return nil, alert.Errorf(spec.AlertDescriptionUnrecognizedName, "no certificate matches server name %q", serverName)
+}
+
+func (hs *serverHandshake) processClientHello(body []byte) error {
+	clientHello, err := handshake.UnmarshalClientHello(body)
+	if err != nil {
+		return alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+	hs.clientHello = clientHello
+
+	hs.c.version, err = selectVersion(hs.c.config, clientHello.ClientTlsVersion)
+	if err != nil {
+		return err
+	}
+
+	// null compression is required in TLS 1.2, it is the only method ever selected
+	if !slices.Contains(clientHello.CompressionMethods, spec.CompressionMethodNull) {
+		return alert.New(spec.AlertDescriptionIllegalParameter, "client did not offer the null compression method")
+	}
+
+	serverName, err := handshake.ServerNameFromClientHello(clientHello)
+	if err != nil {
+		return alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+	hs.certificate, err = certificateForName(hs.c.config, serverName)
+	if err != nil {
+		return err
+	}
+	hs.c.serverName = serverName
+
+	if err := hs.processRenegotiationInfo(clientHello); err != nil {
+		return err
+	}
+
+	if err := hs.processExtendedMasterSecret(clientHello); err != nil {
+		return err
+	}
+
+	if err := hs.processEncryptThenMAC(clientHello); err != nil {
+		return err
+	}
+
+	if err := checkPointFormats(clientHello); err != nil {
+		return err
+	}
+
+	random := make([]byte, 32)
+	if _, err := io.ReadFull(hs.c.config.random(), random); err != nil {
+		return fmt.Errorf("failed to generate server random: %w", err)
+	}
+
+	state, err := hs.ticketSession(clientHello)
+	if err != nil {
+		return err
+	}
+	// RFC 5077, section 3.1: a resumed ticket is renewed, so that a client
+	// reconnecting within the lifetime of every ticket keeps resuming
+	hs.sendTicket = state != nil
+	if state == nil {
+		state, err = hs.cachedSession(clientHello)
+		if err != nil {
+			return err
+		}
+	}
+	if state != nil {
+		return hs.resumeSession(random, state)
+	}
+
+	group, groupShared, err := selectGroup(hs.c.config, clientHello)
+	if err != nil {
+		return err
+	}
+	// a malformed signature_algorithms fails the handshake, finding no usable
+	// scheme only rules out the ECDHE suites
+	signatureAlgorithm, signatureErr := selectSignatureAlgorithm(hs.c.config, clientHello, hs.certificate.PrivateKey)
+	if signatureErr != nil && alert.DescriptionOf(signatureErr) != spec.AlertDescriptionHandshakeFailure {
+		return signatureErr
+	}
+	hs.params, err = selectCipherSuite(hs.c.config, clientHello.CipherSuites, hs.certificate.PrivateKey, groupShared, signatureErr)
+	if err != nil {
+		return err
+	}
+	hs.group = group
+	// the RSA key exchange has no ServerKeyExchange to sign
+	if hs.params.KeyExchange == ciphersuite.KeyExchangeECDHE {
+		hs.signatureAlgorithm = signatureAlgorithm
+	}
+	hs.c.cipherSuite = hs.params.ID
+	// RFC 7366, section 2: AEAD suites have no use for it and must not echo it
+	hs.c.encryptThenMAC = hs.encryptThenMACOffered && !hs.params.AEAD()
+	if err := hs.transcript.SetHash(hs.params.PRFHash); err != nil {
+		return err
+	}
+
+	// a ticket is issued whenever the client supports them, also in place of one
+	// that could not be used
+	_, ticketOffered := utils.FindExtension(clientHello.Extensions, spec.ExtensionTypeSessionTicket)
+	hs.sendTicket = ticketOffered && !hs.c.config.SessionTicketsDisabled
+
+	extensions, err := hs.serverHelloExtensions()
+	if err != nil {
+		return err
+	}
+	// an empty server_name tells the client its name was used to pick the certificate
+	if serverName != "" {
+		extensions = append(extensions, spec.Extension{Type: spec.ExtensionTypeServerName})
+	}
+	if hs.sendTicket {
+		extensions = append(extensions, spec.Extension{Type: spec.ExtensionTypeSessionTicket})
+	}
+
+	var sessionID []byte
+	if hs.c.config.SessionCache != nil {
+		sessionID = make([]byte, 32)
+		if _, err := io.ReadFull(hs.c.config.random(), sessionID); err != nil {
+			return fmt.Errorf("failed to generate session ID: %w", err)
+		}
+	}
+
+	hs.hello, err = newServerHello(hs.c.config, hs.c.version, random, sessionID, hs.params.ID, extensions)
+	if err != nil {
+		return err
+	}
+
+	return hs.sendServerFlight()
+}
+
+// serverHelloExtensions returns the extensions answering the client's in both
+// the full and the abbreviated handshake.
+func (hs *serverHandshake) serverHelloExtensions() ([]spec.Extension, error) {
+	var extensions []spec.Extension
+	if _, ok := utils.FindExtension(hs.clientHello.Extensions, spec.ExtensionTypeECPointFormats); ok {
+		pointFormats, err := handshake.MarshalECPointFormats(&spec.ECPointFormatList{Formats: supportedPointFormats})
+		if err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, spec.Extension{Type: spec.ExtensionTypeECPointFormats, Opaque: pointFormats})
+	}
+
+	if hs.c.secureRenegotiation {
+		renegotiationInfo, err := handshake.MarshalRenegotiationInfo(&spec.RenegotiationInfo{
+			RenegotiatedConnection: append(slices.Clone(hs.c.clientVerifyData), hs.c.serverVerifyData...),
+		})
+		if err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, spec.Extension{Type: spec.ExtensionTypeRenegotiationInfo, Opaque: renegotiationInfo})
+	}
+
+	if hs.c.extendedMasterSecret {
+		extensions = append(extensions, spec.Extension{Type: spec.ExtensionTypeExtendedMasterSecret})
+	}
+
+	if hs.c.encryptThenMAC {
+		extensions = append(extensions, spec.Extension{Type: spec.ExtensionTypeEncryptThenMAC})
+	}
+
+	return extensions, nil
+}
+
+// ticketSession returns the session sealed in the client's ticket when it can
+// be resumed, or nil when a full handshake has to run instead.
+func (hs *serverHandshake) ticketSession(clientHello *spec.ClientHello) (*session.State, error) {
+	config := hs.c.config
+	if config.SessionTicketsDisabled {
+		return nil, nil
+	}
+	extension, ok := utils.FindExtension(clientHello.Extensions, spec.ExtensionTypeSessionTicket)
+	if !ok || len(extension.Opaque) == 0 {
+		return nil, nil
+	}
+
+	ticketKey, err := config.sessionTicketKey()
+	if err != nil {
+		return nil, err
+	}
+	// tickets sealed with another key or tampered with are ignored, the client
+	// gets a fresh one at the end of the full handshake
+	state, err := ticketKey.Open(extension.Opaque)
+	if err != nil {
+		return nil, nil
+	}
+
+	// servers sharing a ticket key may disagree slightly on the time, only the
+	// lifetime is enforced
+	if config.now().Sub(state.CreatedAt) > sessionTicketLifetime {
+		return nil, nil
+	}
+
+	return hs.resumableSession(clientHello, state)
+}
+
+// cachedSession returns the session the client's session ID names in the
+// SessionCache when it can be resumed, or nil.
+func (hs *serverHandshake) cachedSession(clientHello *spec.ClientHello) (*session.State, error) {
+	cache := hs.c.config.SessionCache
+	if cache == nil || len(clientHello.SessionID) == 0 {
+		return nil, nil
+	}
+	encoded, ok := cacheGet(cache, string(clientHello.SessionID), hs.c.config.now())
+	if !ok {
+		return nil, nil
+	}
+	// a cache entry this server cannot read only costs a full handshake
+	state, err := session.UnmarshalState(encoded)
+	if err != nil {
+		return nil, nil
+	}
+	// the cache may keep sessions longer than a ticket would be accepted
+	if hs.c.config.now().Sub(state.CreatedAt) > sessionTicketLifetime {
+		return nil, nil
+	}
+
+	return hs.resumableSession(clientHello, state)
+}
+
+// resumableSession returns state when the ClientHello allows resuming it.
+func (hs *serverHandshake) resumableSession(clientHello *spec.ClientHello, state *session.State) (*session.State, error) {
+	config := hs.c.config
+	if state.Version != hs.c.version {
+		return nil, nil
+	}
+	if !slices.Contains(clientHello.CipherSuites, state.CipherSuite) || !slices.Contains(config.cipherSuites(), state.CipherSuite) {
+		return nil, nil
+	}
+	// a session belongs to the name it was established for, resuming it under
+	// another name would skip that name's certificate (RFC 6066, section 3)
+	if state.ServerName != hs.c.serverName {
+		return nil, nil
+	}
+	params, err := ciphersuite.Lookup(state.CipherSuite)
+	if err != nil || !params.UsableWith(hs.certificate.PrivateKey.Public()) {
+		return nil, nil
+	}
+
+	// RFC 7627, section 5.3
+	if state.ExtendedMasterSecret && !hs.c.extendedMasterSecret {
+		return nil, alert.New(spec.AlertDescriptionHandshakeFailure, "client resumed an extended master secret session without the extension")
+	}
+	if !state.ExtendedMasterSecret && hs.c.extendedMasterSecret {
+		return nil, nil
+	}
+	// encrypt_then_mac holds for the whole session, a client that no longer
+	// asks for it cannot resume a session that used it
+	if state.EncryptThenMAC && !hs.encryptThenMACOffered {
+		return nil, nil
+	}
+
+	return state, nil
+}
+
+// resumeSession runs the server side of the abbreviated handshake: ServerHello
+// echoes the client's session ID, then ChangeCipherSpec and Finished follow
+// right away.
+func (hs *serverHandshake) resumeSession(random []byte, state *session.State) error {
+	var err error
+	hs.params, err = ciphersuite.Lookup(state.CipherSuite)
+	if err != nil {
+		return err
+	}
+	hs.c.cipherSuite = hs.params.ID
+	hs.c.encryptThenMAC = state.EncryptThenMAC
+	if err := hs.transcript.SetHash(hs.params.PRFHash); err != nil {
+		return err
+	}
+
+	extensions, err := hs.serverHelloExtensions()
+	if err != nil {
+		return err
+	}
+	if hs.sendTicket {
+		extensions = append(extensions, spec.Extension{Type: spec.ExtensionTypeSessionTicket})
+	}
+	hs.hello, err = newServerHello(hs.c.config, hs.c.version, random, hs.clientHello.SessionID, hs.params.ID, extensions)
+	if err != nil {
+		return err
+	}
+	err = hs.c.writeHandshake(hs.transcript, &spec.Handshake{
+		MsgType: spec.HandshakeTypeServerHello,
+		Body:    handshake.MarshalServerHello(hs.hello),
+	})
+	if err != nil {
+		return err
+	}
+
+	hs.resumed = true
+	hs.c.didResume = true
+	hs.masterSecret = state.MasterSecret
+	hs.keyBlock = prf.NewKeyBlock(hs.params, hs.masterSecret, hs.clientHello.Random, hs.hello.Random)
+	if err := hs.sendFinished(); err != nil {
+		return err
+	}
+
+	hs.state = serverStateWaitChangeCipherSpec
+	return nil
+}
+
+func (hs *serverHandshake) sendServerFlight() error {
+	err := hs.c.writeHandshake(hs.transcript, &spec.Handshake{
+		MsgType: spec.HandshakeTypeServerHello,
+		Body:    handshake.MarshalServerHello(hs.hello),
+	})
+	if err != nil {
+		return err
+	}
+
+	certificateBody, err := handshake.MarshalServerCertificate(&spec.ServerCertificate{Certificates: hs.certificate.Chain})
+	if err != nil {
+		return err
+	}
+	err = hs.c.writeHandshake(hs.transcript, &spec.Handshake{
+		MsgType: spec.HandshakeTypeCertificate,
+		Body:    certificateBody,
+	})
+	if err != nil {
+		return err
+	}
+
+	if hs.params.KeyExchange == ciphersuite.KeyExchangeECDHE {
+		if err := hs.sendServerKeyExchange(); err != nil {
+			return err
+		}
+	}
+
+	err = hs.c.writeHandshake(hs.transcript, &spec.Handshake{
+		MsgType: spec.HandshakeTypeServerHelloDone,
+		Body:    handshake.MarshalServerHelloDone(&spec.ServerHelloDone{}),
+	})
+	if err != nil {
+		return err
+	}
+
+	hs.state = serverStateWaitClientKeyExchange
+	return nil
+}
+
+func (hs *serverHandshake) sendServerKeyExchange() error {
+	var err error
+	hs.ecdheKey, err = handshake.GenerateECDHEKey(hs.c.config.random(), hs.group)
+	if err != nil {
+		return err
+	}
+	serverKeyExchange, err := handshake.NewServerKeyExchange(
+		hs.c.config.random(),
+		hs.certificate.PrivateKey,
+		hs.signatureAlgorithm,
+		hs.clientHello.Random,
+		hs.hello.Random,
+		&spec.ServerECDHParams{
+			CurveType:  spec.ECCurveTypeNamedCurve,
+			NamedCurve: hs.group,
+			PublicKey:  hs.ecdheKey.PublicKey().Bytes(),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return hs.c.writeHandshake(hs.transcript, &spec.Handshake{
+		MsgType: spec.HandshakeTypeServerKeyExchange,
+		Body:    handshake.MarshalServerKeyExchange(serverKeyExchange),
+	})
+}
+
+func (hs *serverHandshake) processClientKeyExchange(body []byte) error {
+	preMasterSecret, err := hs.preMasterSecret(body)
+	if err != nil {
+		return err
+	}
+
+	hs.masterSecret, err = handshake.MasterSecret(hs.params, preMasterSecret, hs.clientHello.Random, hs.hello.Random, hs.transcript, hs.c.extendedMasterSecret)
+	if err != nil {
+		return err
+	}
+	hs.keyBlock = prf.NewKeyBlock(hs.params, hs.masterSecret, hs.clientHello.Random, hs.hello.Random)
+
+	hs.state = serverStateWaitChangeCipherSpec
+	return nil
+}
+
+// preMasterSecret reads the client's share of the key exchange of the
+// negotiated suite.
+func (hs *serverHandshake) preMasterSecret(body []byte) ([]byte, error) {
+	if hs.params.KeyExchange == ciphersuite.KeyExchangeRSA {
+		clientKeyExchange, err := handshake.UnmarshalEncryptedPreMasterSecret(body)
+		if err != nil {
+			return nil, alert.Wrap(spec.AlertDescriptionDecodeError, err)
+		}
+		// selectCipherSuite only picks the RSA key exchange for a Decrypter
+		decrypter := hs.certificate.PrivateKey.(crypto.Decrypter)
+		return handshake.RSAPreMasterSecret(hs.c.config.random(), decrypter, hs.clientHello.ClientTlsVersion, clientKeyExchange.EncryptedPreMasterSecret)
+	}
+
+	clientKeyExchange, err := handshake.UnmarshalClientKeyExchange(body)
+	if err != nil {
+		return nil, alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+	return handshake.ECDHEPreMasterSecret(hs.ecdheKey, clientKeyExchange.PublicKey)
+}
+
+func (hs *serverHandshake) processChangeCipherSpec() error {
+	if err := hs.c.messages.ReadChangeCipherSpec(); err != nil {
+		return err
+	}
+
+	protector, err := hs.c.newProtector(hs.params, hs.keyBlock.ClientKey, hs.keyBlock.ClientIV, hs.keyBlock.ClientMACKey)
+	if err != nil {
+		return err
+	}
+	hs.c.records.SetProtector(protector)
+
+	hs.state = serverStateWaitFinished
+	return nil
+}
+
+func (hs *serverHandshake) processClientFinished(message *spec.Handshake) error {
+	finished, err := handshake.UnmarshalFinished(message.Body)
+	if err != nil {
+		return alert.Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+
+	if err := handshake.VerifyFinished(finished, hs.params, hs.masterSecret, prf.ClientFinishedLabel, hs.transcript); err != nil {
+		return err
+	}
+	hs.transcript.Write(handshake.MarshalHandshake(message))
+	hs.clientVerifyData = finished.VerifyData
+
+	// in the abbreviated handshake the server has already sent its Finished
+	if !hs.resumed {
+		if err := hs.sendFinished(); err != nil {
+			return err
+		}
+		if err := hs.cacheSession(); err != nil {
+			return err
+		}
+	}
+	hs.c.clientVerifyData = hs.clientVerifyData
+	hs.c.serverVerifyData = hs.serverVerifyData
+
+	hs.state = serverStateDone
+	return nil
+}
+
+// sendFinished sends the server's ChangeCipherSpec and Finished, preceded by a
+// NewSessionTicket when one was promised in the ServerHello.
+func (hs *serverHandshake) sendFinished() error {
+	if hs.sendTicket {
+		if err := hs.sendNewSessionTicket(); err != nil {
+			return err
+		}
+	}
+
+	if err := handshake.WriteChangeCipherSpec(hs.c.writer); err != nil {
+		return err
+	}
+	protector, err := hs.c.newProtector(hs.params, hs.keyBlock.ServerKey, hs.keyBlock.ServerIV, hs.keyBlock.ServerMACKey)
+	if err != nil {
+		return err
+	}
+	hs.c.writer.SetProtector(protector)
+
+	finished, err := handshake.NewFinished(hs.params, hs.masterSecret, prf.ServerFinishedLabel, hs.transcript)
+	if err != nil {
+		return err
+	}
+	err = hs.c.writeHandshake(hs.transcript, &spec.Handshake{
+		MsgType: spec.HandshakeTypeFinished,
+		Body:    handshake.MarshalFinished(finished),
+	})
+	if err != nil {
+		return err
+	}
+	hs.serverVerifyData = finished.VerifyData
+
+	return nil
+}
+
+// sessionState returns the state of the session being established.
+func (hs *serverHandshake) sessionState() *session.State {
+	return &session.State{
+		Version:              hs.c.version,
+		CipherSuite:          hs.params.ID,
+		MasterSecret:         hs.masterSecret,
+		ExtendedMasterSecret: hs.c.extendedMasterSecret,
+		EncryptThenMAC:       hs.c.encryptThenMAC,
+		CreatedAt:            hs.c.config.now(),
+		ServerName:           hs.c.serverName,
+	}
+}
+
+// cacheSession stores a completed full handshake under the session ID sent in
+// the ServerHello.
+func (hs *serverHandshake) cacheSession() error {
+	if hs.c.config.SessionCache == nil || len(hs.hello.SessionID) == 0 {
+		return nil
+	}
+	encoded, err := session.MarshalState(hs.sessionState())
+	if err != nil {
+		return err
+	}
+	cachePut(hs.c.config.SessionCache, string(hs.hello.SessionID), encoded, hs.c.config.now())
+
+	return nil
+}
+
+func (hs *serverHandshake) sendNewSessionTicket() error {
+	ticketKey, err := hs.c.config.sessionTicketKey()
+	if err != nil {
+		return err
+	}
+	ticket, err := ticketKey.Seal(hs.c.config.random(), hs.sessionState())
+	if err != nil {
+		return err
+	}
+
+	body, err := handshake.MarshalNewSessionTicket(&spec.NewSessionTicket{
+		LifetimeHint: uint32(sessionTicketLifetime / time.Second),
+		Ticket:       ticket,
+	})
+	if err != nil {
+		return err
+	}
+
+	return hs.c.writeHandshake(hs.transcript, &spec.Handshake{
+		MsgType: spec.HandshakeTypeNewSessionTicket,
+		Body:    body,
+	})
+}
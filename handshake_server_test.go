@@ -0,0 +1,784 @@
+package tls
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/internal/handshake"
+	"github.com/piligrimm/tls/internal/prf"
+	"github.com/piligrimm/tls/internal/record"
+	"github.com/piligrimm/tls/internal/session"
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+type testClientBehavior uint8
+
+const (
+	testClientHonest testClientBehavior = iota
+	testClientNoSharedSuite
+	testClientSkipsClientHello
+	testClientTruncatedClientHello
+	testClientNoNullCompression
+	testClientNoSharedGroup
+	testClientCompressedPointsOnly
+	testClientBadFinished
+	testClientStaticRSA
+	testClientRSABadPadding
+	testClientRSAWrongVersion
+	testClientRSAWrongSecret
+	testClientOldVersion
+	testClientNewerVersion
+	testClientDuplicateExtension
+)
+
+// rsaClientKeyExchange encrypts a pre-master secret to key the way behavior
+// asks for and returns the body to send with the secret the client goes on with.
+func rsaClientKeyExchange(behavior testClientBehavior, key *rsa.PublicKey, clientVersion spec.ProtocolVersion) ([]byte, []byte, error) {
+	clientKeyExchange, preMasterSecret, err := handshake.NewRSAClientKeyExchange(rand.Reader, key, clientVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch behavior {
+	case testClientRSABadPadding:
+		// block type 1 is for signatures, a decrypting server must reject it
+		block := make([]byte, key.Size())
+		block[1] = 0x01
+		rand.Read(block[2:])
+		encrypted := new(big.Int).Exp(new(big.Int).SetBytes(block), big.NewInt(int64(key.E)), key.N)
+		clientKeyExchange.EncryptedPreMasterSecret = encrypted.FillBytes(make([]byte, key.Size()))
+	case testClientRSAWrongVersion:
+		preMasterSecret[1]--
+		clientKeyExchange.EncryptedPreMasterSecret, err = rsa.EncryptPKCS1v15(rand.Reader, key, preMasterSecret)
+		if err != nil {
+			return nil, nil, err
+		}
+	case testClientRSAWrongSecret:
+		preMasterSecret = append([]byte(nil), preMasterSecret...)
+		preMasterSecret[47] ^= 0xff
+	}
+
+	body, err := handshake.MarshalEncryptedPreMasterSecret(clientKeyExchange)
+	return body, preMasterSecret, err
+}
+
+func staticRSABehavior(behavior testClientBehavior) bool {
+	return behavior >= testClientStaticRSA && behavior <= testClientRSAWrongSecret
+}
+
+// runTestClient plays the client side of a handshake and sends one record of
+// application data, returning the server's reply. After a misbehavior it
+// returns the alert the server answered with.
+func runTestClient(conn net.Conn, behavior testClientBehavior) (string, error) {
+	defer conn.Close()
+
+	records := record.NewReader(conn)
+	writer := record.NewWriter(conn, spec.Tls12ProtocolVersion())
+	messages := handshake.NewReader(records)
+	transcript := handshake.NewTranscript()
+	send := func(msgType spec.HandshakeType, body []byte) error {
+		raw := handshake.MarshalHandshake(&spec.Handshake{MsgType: msgType, Body: body})
+		transcript.Write(raw)
+		return writer.WriteRecords(spec.ContentTypeHandshake, raw)
+	}
+	receive := func(msgType spec.HandshakeType) ([]byte, error) {
+		message, err := messages.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		if message.MsgType != msgType {
+			return nil, errors.New("unexpected " + message.MsgType.String())
+		}
+		transcript.Write(handshake.MarshalHandshake(message))
+		return message.Body, nil
+	}
+
+	if behavior == testClientSkipsClientHello {
+		if err := send(spec.HandshakeTypeClientKeyExchange, []byte{0x01, 0x04}); err != nil {
+			return "", err
+		}
+		_, err := messages.ReadMessage()
+		return "", err
+	}
+
+	clientRandom := make([]byte, 32)
+	rand.Read(clientRandom)
+	cipherSuites := []spec.CipherSuite{spec.CipherSuiteECDHE_ECDSA_WITH_AES_128_GCM_SHA256, spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	if behavior == testClientNoSharedSuite {
+		cipherSuites = cipherSuites[:1]
+	}
+	if staticRSABehavior(behavior) {
+		cipherSuites = []spec.CipherSuite{spec.CipherSuiteRSA_WITH_AES_128_GCM_SHA256}
+	}
+	clientHello := &spec.ClientHello{
+		ClientTlsVersion:   spec.Tls12ProtocolVersion(),
+		Random:             clientRandom,
+		CipherSuites:       cipherSuites,
+		CompressionMethods: []spec.CompressionMethod{spec.CompressionMethodNull},
+		Extensions: []spec.Extension{
+			{Type: spec.ExtensionTypeECPointFormats, Opaque: []byte{0x01, 0x00}},
+		},
+	}
+	switch behavior {
+	case testClientNoNullCompression:
+		clientHello.CompressionMethods = []spec.CompressionMethod{1}
+	case testClientNoSharedGroup:
+		clientHello.Extensions = append(clientHello.Extensions, spec.Extension{
+			Type:   spec.ExtensionTypeSupportedGroups,
+			Opaque: []byte{0x00, 0x02, 0x00, 0x1e},
+		})
+	case testClientCompressedPointsOnly:
+		clientHello.Extensions[0].Opaque = []byte{0x01, byte(spec.ECPointFormatAnsiX962CompressedPrime)}
+	case testClientOldVersion:
+		clientHello.ClientTlsVersion = spec.ProtocolVersion{Major: 3, Minor: 1}
+	case testClientNewerVersion:
+		clientHello.ClientTlsVersion = spec.ProtocolVersion{Major: 3, Minor: 4}
+	case testClientDuplicateExtension:
+		clientHello.Extensions = append(clientHello.Extensions, clientHello.Extensions[0])
+	}
+	clientHelloBody := handshake.MarshalClientHello(clientHello)
+	if behavior == testClientTruncatedClientHello {
+		clientHelloBody = clientHelloBody[:45]
+	}
+	if err := send(spec.HandshakeTypeClientHello, clientHelloBody); err != nil {
+		return "", err
+	}
+
+	body, err := receive(spec.HandshakeTypeServerHello)
+	if err != nil {
+		return "", err
+	}
+	serverHello, err := handshake.UnmarshalServerHello(body)
+	if err != nil {
+		return "", err
+	}
+	params, err := ciphersuite.Lookup(serverHello.CipherSuite)
+	if err != nil {
+		return "", err
+	}
+	_ = transcript.SetHash(params.PRFHash)
+
+	body, err = receive(spec.HandshakeTypeCertificate)
+	if err != nil {
+		return "", err
+	}
+	leaf, err := x509.ParseCertificate(body[6 : 6+utils.ReadUint24(body[3:6])])
+	if err != nil {
+		return "", err
+	}
+
+	var clientKeyExchange, preMasterSecret []byte
+	if params.KeyExchange == ciphersuite.KeyExchangeRSA {
+		if _, err := receive(spec.HandshakeTypeServerHelloDone); err != nil {
+			return "", err
+		}
+		clientKeyExchange, preMasterSecret, err = rsaClientKeyExchange(behavior, leaf.PublicKey.(*rsa.PublicKey), clientHello.ClientTlsVersion)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		body, err = receive(spec.HandshakeTypeServerKeyExchange)
+		if err != nil {
+			return "", err
+		}
+		serverKeyExchange, err := handshake.UnmarshalServerKeyExchange(body)
+		if err != nil {
+			return "", err
+		}
+		serverCertificate := &spec.ServerCertificate{Certificates: []*x509.Certificate{leaf}}
+		if err := handshake.VerifyServerKeyExchange(serverKeyExchange, clientRandom, serverHello.Random, serverCertificate); err != nil {
+			return "", err
+		}
+
+		if _, err := receive(spec.HandshakeTypeServerHelloDone); err != nil {
+			return "", err
+		}
+
+		ecdheKeyExchange, ecdhePreMasterSecret, err := handshake.NewClientKeyExchange(rand.Reader, &serverKeyExchange.Params)
+		if err != nil {
+			return "", err
+		}
+		clientKeyExchange, preMasterSecret = handshake.MarshalClientKeyExchange(ecdheKeyExchange), ecdhePreMasterSecret
+	}
+	if err := send(spec.HandshakeTypeClientKeyExchange, clientKeyExchange); err != nil {
+		return "", err
+	}
+	masterSecret := prf.MasterSecret(params, preMasterSecret, clientRandom, serverHello.Random)
+	keyBlock := prf.NewKeyBlock(params, masterSecret, clientRandom, serverHello.Random)
+
+	if err := handshake.WriteChangeCipherSpec(writer); err != nil {
+		return "", err
+	}
+	sealer, _ := record.NewProtector(params, keyBlock.ClientKey, keyBlock.ClientIV, nil)
+	writer.SetProtector(sealer)
+
+	clientFinished, err := handshake.NewFinished(params, masterSecret, prf.ClientFinishedLabel, transcript)
+	if err != nil {
+		return "", err
+	}
+	if behavior == testClientBadFinished {
+		clientFinished.VerifyData[0] ^= 0xff
+	}
+	if err := send(spec.HandshakeTypeFinished, handshake.MarshalFinished(clientFinished)); err != nil {
+		return "", err
+	}
+
+	if err := messages.ReadChangeCipherSpec(); err != nil {
+		return "", err
+	}
+	opener, _ := record.NewProtector(params, keyBlock.ServerKey, keyBlock.ServerIV, nil)
+	records.SetProtector(opener)
+
+	message, err := messages.ReadMessage()
+	if err != nil {
+		return "", err
+	}
+	serverFinished, err := handshake.UnmarshalFinished(message.Body)
+	if err != nil {
+		return "", err
+	}
+	if err := handshake.VerifyFinished(serverFinished, params, masterSecret, prf.ServerFinishedLabel, transcript); err != nil {
+		return "", err
+	}
+
+	if err := writer.WriteRecords(spec.ContentTypeApplicationData, []byte("ping")); err != nil {
+		return "", err
+	}
+	rec, err := records.ReadRecord()
+	if err != nil {
+		return "", err
+	}
+	return string(rec.Fragment), nil
+}
+
+func TestServerHandshake_Failures(t *testing.T) {
+	config := &Config{Certificates: []Certificate{newTestCertificate(t)}}
+
+	testCases := []struct {
+		name     string
+		behavior testClientBehavior
+		expected string
+		alert    spec.AlertDescription
+	}{
+		{
+			name:     "no shared cipher suite",
+			behavior: testClientNoSharedSuite,
+			expected: "no cipher suite shared with the client",
+			alert:    spec.AlertDescriptionHandshakeFailure,
+		},
+		{
+			name:     "unexpected message",
+			behavior: testClientSkipsClientHello,
+			expected: "unexpected ClientKeyExchange message in state WaitClientHello",
+			alert:    spec.AlertDescriptionUnexpectedMessage,
+		},
+		{
+			name:     "truncated ClientHello",
+			behavior: testClientTruncatedClientHello,
+			expected: "truncated ClientHello",
+			alert:    spec.AlertDescriptionDecodeError,
+		},
+		{
+			name:     "client version below TLS 1.2",
+			behavior: testClientOldVersion,
+			expected: "client version 3.1 is lower than any supported version",
+			alert:    spec.AlertDescriptionProtocolVersion,
+		},
+		{
+			name:     "duplicate extension",
+			behavior: testClientDuplicateExtension,
+			expected: "duplicate extension ECPointFormats",
+			alert:    spec.AlertDescriptionDecodeError,
+		},
+		{
+			name:     "no null compression method",
+			behavior: testClientNoNullCompression,
+			expected: "client did not offer the null compression method",
+			alert:    spec.AlertDescriptionIllegalParameter,
+		},
+		{
+			name:     "no shared group",
+			behavior: testClientNoSharedGroup,
+			expected: "no group shared with the client for an ECDHE cipher suite",
+			alert:    spec.AlertDescriptionHandshakeFailure,
+		},
+		{
+			name:     "no uncompressed point format",
+			behavior: testClientCompressedPointsOnly,
+			expected: "client did not offer the uncompressed point format",
+			alert:    spec.AlertDescriptionIllegalParameter,
+		},
+		{
+			name:     "bad client Finished",
+			behavior: testClientBadFinished,
+			expected: "verify_data of Finished does not match",
+			alert:    spec.AlertDescriptionDecryptError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientSide, serverSide := net.Pipe()
+			clientErr := make(chan error, 1)
+			go func() {
+				_, err := runTestClient(clientSide, tc.behavior)
+				clientErr <- err
+			}()
+			server := Server(serverSide, config)
+			defer server.Close()
+
+			err := server.Handshake()
+
+			if err == nil {
+				t.Fatal("Expected handshake error")
+			}
+			if !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("Expected error containing %q, got %q", tc.expected, err.Error())
+			}
+			var alertErr *AlertError
+			if err := <-clientErr; !errors.As(err, &alertErr) || alertErr.Description != tc.alert {
+				t.Errorf("Expected the client to receive a %v alert, got %v", tc.alert, err)
+			}
+		})
+	}
+}
+
+func TestServerHandshake_RequiresCertificate(t *testing.T) {
+	clientSide, serverSide := net.Pipe()
+	defer clientSide.Close()
+	server := Server(serverSide, &Config{})
+	defer server.Close()
+
+	err := server.Handshake()
+
+	expected := "server config must contain at least one certificate"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+}
+
+func TestServerHandshake_ExchangesApplicationData(t *testing.T) {
+	clientSide, serverSide := net.Pipe()
+	server := Server(serverSide, &Config{Certificates: []Certificate{newTestCertificate(t)}})
+	defer server.Close()
+	go func() {
+		buf := make([]byte, 64)
+		n, err := server.Read(buf)
+		if err != nil {
+			return
+		}
+		_, _ = server.Write(append([]byte("echo: "), buf[:n]...))
+	}()
+
+	reply, err := runTestClient(clientSide, testClientHonest)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if reply != "echo: ping" {
+		t.Errorf("Expected %q, got %q", "echo: ping", reply)
+	}
+}
+
+func TestServerHandshake_NewerClientVersion(t *testing.T) {
+	clientSide, serverSide := net.Pipe()
+	server := Server(serverSide, &Config{Certificates: []Certificate{newTestCertificate(t)}})
+	defer server.Close()
+	version := make(chan spec.ProtocolVersion, 1)
+	go func() {
+		buf := make([]byte, 64)
+		n, err := server.Read(buf)
+		version <- server.ConnectionState().Version
+		if err != nil {
+			return
+		}
+		_, _ = server.Write(append([]byte("echo: "), buf[:n]...))
+	}()
+
+	_, err := runTestClient(clientSide, testClientNewerVersion)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if v := <-version; v != spec.Tls12ProtocolVersion() {
+		t.Errorf("Expected version %v, got %v", spec.Tls12ProtocolVersion(), v)
+	}
+}
+
+func TestServerHandshake_StaticRSA(t *testing.T) {
+	clientSide, serverSide := net.Pipe()
+	server := Server(serverSide, &Config{
+		Certificates: []Certificate{newTestCertificate(t)},
+		CipherSuites: []spec.CipherSuite{spec.CipherSuiteRSA_WITH_AES_128_GCM_SHA256},
+	})
+	defer server.Close()
+	go func() {
+		buf := make([]byte, 64)
+		n, err := server.Read(buf)
+		if err != nil {
+			return
+		}
+		_, _ = server.Write(append([]byte("echo: "), buf[:n]...))
+	}()
+
+	reply, err := runTestClient(clientSide, testClientStaticRSA)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if reply != "echo: ping" {
+		t.Errorf("Expected %q, got %q", "echo: ping", reply)
+	}
+}
+
+// A Bleichenbacher oracle needs the server to tell a bad padding or version
+// from a well-formed but wrong secret, all of them must fail alike.
+func TestServerHandshake_StaticRSAFailuresLookAlike(t *testing.T) {
+	config := &Config{
+		Certificates: []Certificate{newTestCertificate(t)},
+		CipherSuites: []spec.CipherSuite{spec.CipherSuiteRSA_WITH_AES_128_GCM_SHA256},
+	}
+	testCases := []struct {
+		name     string
+		behavior testClientBehavior
+	}{
+		{name: "bad padding", behavior: testClientRSABadPadding},
+		{name: "wrong version", behavior: testClientRSAWrongVersion},
+		{name: "wrong secret", behavior: testClientRSAWrongSecret},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientSide, serverSide := net.Pipe()
+			clientErr := make(chan error, 1)
+			go func() {
+				_, err := runTestClient(clientSide, tc.behavior)
+				clientErr <- err
+			}()
+			server := Server(serverSide, config)
+			defer server.Close()
+
+			err := server.Handshake()
+
+			// the client Finished is sealed with keys the server does not share
+			if err == nil || err.Error() != "bad record MAC" {
+				t.Errorf("Expected error %q, got %v", "bad record MAC", err)
+			}
+			var alertErr *AlertError
+			if err := <-clientErr; !errors.As(err, &alertErr) || alertErr.Description != spec.AlertDescriptionBadRecordMAC {
+				t.Errorf("Expected the client to receive a BadRecordMAC alert, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSelectGroup(t *testing.T) {
+	testCases := []struct {
+		name         string
+		clientGroups []byte
+		serverGroups []spec.SupportedGroup
+		expected     spec.SupportedGroup
+		shared       bool
+	}{
+		{
+			name:     "client without supported_groups",
+			expected: spec.SupportedGroupsX25519,
+			shared:   true,
+		},
+		{
+			name:         "server preference wins",
+			clientGroups: []byte{0x00, 0x04, 0x00, 0x17, 0x00, 0x18},
+			serverGroups: []spec.SupportedGroup{spec.SupportedGroupsSecp384r1, spec.SupportedGroupsSecp256r1},
+			expected:     spec.SupportedGroupsSecp384r1,
+			shared:       true,
+		},
+		{
+			name:         "unknown client groups are ignored",
+			clientGroups: []byte{0x00, 0x04, 0x00, 0x1e, 0x00, 0x19},
+			expected:     spec.SupportedGroupsSecp521r1,
+			shared:       true,
+		},
+		{
+			name:         "no shared group",
+			clientGroups: []byte{0x00, 0x02, 0x00, 0x17},
+			serverGroups: []spec.SupportedGroup{spec.SupportedGroupsX25519},
+			shared:       false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientHello := &spec.ClientHello{}
+			if tc.clientGroups != nil {
+				clientHello.Extensions = []spec.Extension{{Type: spec.ExtensionTypeSupportedGroups, Opaque: tc.clientGroups}}
+			}
+
+			group, shared, err := selectGroup(&Config{SupportedGroups: tc.serverGroups}, clientHello)
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if group != tc.expected || shared != tc.shared {
+				t.Errorf("Expected %#04x (shared %t), got %#04x (shared %t)", uint16(tc.expected), tc.shared, uint16(group), shared)
+			}
+		})
+	}
+}
+
+func TestSelectGroup_MalformedExtension(t *testing.T) {
+	clientHello := &spec.ClientHello{
+		Extensions: []spec.Extension{{Type: spec.ExtensionTypeSupportedGroups, Opaque: []byte{0x00, 0x03, 0x00, 0x17, 0x00}}},
+	}
+
+	_, _, err := selectGroup(&Config{}, clientHello)
+
+	if alert.DescriptionOf(err) != spec.AlertDescriptionDecodeError {
+		t.Errorf("Expected a decode_error, got %v", err)
+	}
+}
+
+func TestServerHandshake_StrictPolicies(t *testing.T) {
+	certificate := newTestCertificate(t)
+	testCases := []struct {
+		name     string
+		config   *Config
+		expected string
+	}{
+		{
+			name:     "SHA-1 fallback disabled",
+			config:   &Config{Certificates: []Certificate{certificate}, DisableSHA1Fallback: true},
+			expected: "client sent no signature_algorithms and the SHA-1 fallback is disabled",
+		},
+		{
+			name:     "secure renegotiation required",
+			config:   &Config{Certificates: []Certificate{certificate}, RequireSecureRenegotiation: true},
+			expected: "client does not support secure renegotiation",
+		},
+		{
+			name:     "extended master secret required",
+			config:   &Config{Certificates: []Certificate{certificate}, RequireExtendedMasterSecret: true},
+			expected: "client does not support the extended master secret",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientSide, serverSide := net.Pipe()
+			clientErr := make(chan error, 1)
+			go func() {
+				_, err := runTestClient(clientSide, testClientHonest)
+				clientErr <- err
+			}()
+			server := Server(serverSide, tc.config)
+			defer server.Close()
+
+			err := server.Handshake()
+
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("Expected error %q, got %v", tc.expected, err)
+			}
+			var alertErr *AlertError
+			if err := <-clientErr; !errors.As(err, &alertErr) || alertErr.Description != spec.AlertDescriptionHandshakeFailure {
+				t.Errorf("Expected the client to receive a HandshakeFailure alert, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSelectSignatureAlgorithm(t *testing.T) {
+	certificate := newTestCertificate(t)
+	testCases := []struct {
+		name             string
+		clientAlgorithms []byte
+		serverAlgorithms []spec.SignatureAlgorithm
+		expected         spec.SignatureAlgorithm
+		expectedErr      string
+	}{
+		{
+			name:     "no extension falls back to SHA-1",
+			expected: spec.SignatureAlgorithmRsaPkcs1Sha1,
+		},
+		{
+			name:             "server preference among shared RSA schemes",
+			clientAlgorithms: []byte{0x00, 0x06, 0x04, 0x03, 0x04, 0x01, 0x08, 0x04},
+			serverAlgorithms: []spec.SignatureAlgorithm{spec.SignatureAlgorithmRsaPssRsaeSha256, spec.SignatureAlgorithmRsaPkcs1Sha256},
+			expected:         spec.SignatureAlgorithmRsaPssRsaeSha256,
+		},
+		{
+			name:             "schemes for other key types are skipped",
+			clientAlgorithms: []byte{0x00, 0x04, 0x04, 0x03, 0x05, 0x01},
+			expected:         spec.SignatureAlgorithmRsaPkcs1Sha384,
+		},
+		{
+			name:             "nothing usable with the key",
+			clientAlgorithms: []byte{0x00, 0x04, 0x04, 0x03, 0x08, 0x07},
+			expectedErr:      "no signature algorithm shared with the peer for a key of type *rsa.PublicKey",
+		},
+		{
+			name:             "malformed extension",
+			clientAlgorithms: []byte{0x00, 0x03, 0x04, 0x01, 0x05},
+			expectedErr:      "incorrect signature algorithms length 3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientHello := &spec.ClientHello{}
+			if tc.clientAlgorithms != nil {
+				clientHello.Extensions = []spec.Extension{{Type: spec.ExtensionTypeSignatureAlgorithms, Opaque: tc.clientAlgorithms}}
+			}
+
+			signatureAlgorithm, err := selectSignatureAlgorithm(&Config{SignatureAlgorithms: tc.serverAlgorithms}, clientHello, certificate.PrivateKey)
+
+			if tc.expectedErr != "" {
+				if err == nil || err.Error() != tc.expectedErr {
+					t.Errorf("Expected error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if signatureAlgorithm != tc.expected {
+				t.Errorf("Expected %#04x, got %#04x", uint16(tc.expected), uint16(signatureAlgorithm))
+			}
+		})
+	}
+}
+
+func TestServerHandshake_TicketSession(t *testing.T) {
+	now := time.Now()
+	config := &Config{SessionTicketKey: [32]byte{0x42}, Time: func() time.Time { return now }}
+	ticketKey, err := config.sessionTicketKey()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	otherKey, err := session.NewTicketKey([32]byte{0x43})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	rsaCertificate := newTestCertificate(t)
+	ecdsaCertificate := newTestECDSACertificate(t, elliptic.P256())
+	suite := spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256
+	newState := func(extendedMasterSecret bool, createdAt time.Time) *session.State {
+		return &session.State{
+			Version:              spec.Tls12ProtocolVersion(),
+			CipherSuite:          suite,
+			MasterSecret:         make([]byte, prf.MasterSecretLength),
+			ExtendedMasterSecret: extendedMasterSecret,
+			EncryptThenMAC:       true,
+			CreatedAt:            createdAt,
+			ServerName:           "localhost",
+		}
+	}
+
+	testCases := []struct {
+		name                 string
+		key                  *session.TicketKey
+		state                *session.State
+		clientSuites         []spec.CipherSuite
+		extendedMasterSecret bool
+		noEncryptThenMAC     bool
+		serverName           string
+		ecdsaCertificate     bool
+		resumed              bool
+		fails                bool
+	}{
+		{name: "valid ticket", key: ticketKey, state: newState(true, now), extendedMasterSecret: true, resumed: true},
+		{name: "valid ticket without the extended master secret", key: ticketKey, state: newState(false, now), resumed: true},
+		{name: "other ticket key", key: otherKey, state: newState(true, now), extendedMasterSecret: true},
+		{name: "expired ticket", key: ticketKey, state: newState(true, now.Add(-sessionTicketLifetime-time.Second)), extendedMasterSecret: true},
+		{name: "ticket from a server ahead in time", key: ticketKey, state: newState(true, now.Add(time.Minute)), extendedMasterSecret: true, resumed: true},
+		{
+			name:                 "cipher suite not offered",
+			key:                  ticketKey,
+			state:                newState(true, now),
+			clientSuites:         []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384},
+			extendedMasterSecret: true,
+		},
+		{name: "client now offers the extended master secret", key: ticketKey, state: newState(false, now), extendedMasterSecret: true},
+		{
+			name:                 "client dropped encrypt_then_mac",
+			key:                  ticketKey,
+			state:                newState(true, now),
+			extendedMasterSecret: true,
+			noEncryptThenMAC:     true,
+		},
+		{
+			name:                 "other server name",
+			key:                  ticketKey,
+			state:                newState(true, now),
+			extendedMasterSecret: true,
+			serverName:           "other.example",
+		},
+		{
+			name:                 "certificate cannot authenticate the suite",
+			key:                  ticketKey,
+			state:                newState(true, now),
+			extendedMasterSecret: true,
+			ecdsaCertificate:     true,
+		},
+		{
+			name:  "client dropped the extended master secret",
+			key:   ticketKey,
+			state: newState(true, now),
+			fails: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ticket, err := tc.key.Seal(rand.Reader, tc.state)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			clientSuites := tc.clientSuites
+			if clientSuites == nil {
+				clientSuites = []spec.CipherSuite{suite}
+			}
+			clientHello := &spec.ClientHello{
+				CipherSuites: clientSuites,
+				Extensions:   []spec.Extension{{Type: spec.ExtensionTypeSessionTicket, Opaque: ticket}},
+			}
+			serverName := tc.serverName
+			if serverName == "" {
+				serverName = "localhost"
+			}
+			certificate := &rsaCertificate
+			if tc.ecdsaCertificate {
+				certificate = &ecdsaCertificate
+			}
+			hs := &serverHandshake{
+				c:                     &Conn{config: config, version: spec.Tls12ProtocolVersion(), extendedMasterSecret: tc.extendedMasterSecret, serverName: serverName},
+				certificate:           certificate,
+				encryptThenMACOffered: !tc.noEncryptThenMAC,
+			}
+
+			state, err := hs.ticketSession(clientHello)
+
+			if tc.fails {
+				if alert.DescriptionOf(err) != spec.AlertDescriptionHandshakeFailure {
+					t.Fatalf("Expected a HandshakeFailure alert, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if (state != nil) != tc.resumed {
+				t.Errorf("Expected resumption %t, got %t", tc.resumed, state != nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package alert
+
+import (
+	"fmt"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+const alertLength = 2
+
+func MarshalAlert(alert *spec.Alert) []byte {
+	return []byte{byte(alert.Level), byte(alert.Description)}
+}
+
+// UnmarshalAlert accepts descriptions outside the registry, a peer may use one
+// registered after this package was written.
+func UnmarshalAlert(raw []byte) (*spec.Alert, error) {
+	if len(raw) != alertLength {
+		return nil, fmt.Errorf("alert must contain %d bytes, got %d", alertLength, len(raw))
+	}
+
+	level := spec.AlertLevel(raw[0])
+	if level != spec.AlertLevelWarning && level != spec.AlertLevelFatal {
+		return nil, fmt.Errorf("unknown alert level %d", raw[0])
+	}
+
+	return &spec.Alert{Level: level, Description: spec.AlertDescription(raw[1])}, nil
+}
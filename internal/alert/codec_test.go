@@ -0,0 +1,90 @@
+package alert
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestMarshalAlert(t *testing.T) {
+	raw := MarshalAlert(&spec.Alert{Level: spec.AlertLevelFatal, Description: spec.AlertDescriptionDecodeError})
+
+	if !bytes.Equal(raw, []byte{0x02, 0x32}) {
+		t.Errorf("Expected 0232, got %x", raw)
+	}
+}
+
+func TestUnmarshalAlert(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected *spec.Alert
+	}{
+		{
+			name:     "close_notify",
+			raw:      []byte{0x01, 0x00},
+			expected: &spec.Alert{Level: spec.AlertLevelWarning, Description: spec.AlertDescriptionCloseNotify},
+		},
+		{
+			name:     "illegal_parameter",
+			raw:      []byte{0x02, 0x2f},
+			expected: &spec.Alert{Level: spec.AlertLevelFatal, Description: spec.AlertDescriptionIllegalParameter},
+		},
+		{
+			name:     "unregistered description",
+			raw:      []byte{0x02, 0xfe},
+			expected: &spec.Alert{Level: spec.AlertLevelFatal, Description: spec.AlertDescription(0xfe)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			alert, err := UnmarshalAlert(tc.raw)
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if *alert != *tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, alert)
+			}
+		})
+	}
+}
+
+func TestUnmarshalAlert_InvalidInput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "too short",
+			raw:      []byte{0x02},
+			expected: "alert must contain 2 bytes, got 1",
+		},
+		{
+			name:     "too long",
+			raw:      []byte{0x02, 0x28, 0x00},
+			expected: "alert must contain 2 bytes, got 3",
+		},
+		{
+			name:     "unknown level",
+			raw:      []byte{0x03, 0x28},
+			expected: "unknown alert level 3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := UnmarshalAlert(tc.raw)
+
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if err.Error() != tc.expected {
+				t.Errorf("Expected error message %q, got %q", tc.expected, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+// Error is a local failure that has to be reported to the peer with a fatal
+// alert carrying Description.
+type Error struct {
+	Description spec.AlertDescription
+	Err         error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(description spec.AlertDescription, text string) error {
+	return &Error{Description: description, Err: errors.New(text)}
+}
+
+func Errorf(description spec.AlertDescription, format string, args ...any) error {
+	return &Error{Description: description, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap attaches description to err, a nil err stays nil.
+func Wrap(description spec.AlertDescription, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Description: description, Err: err}
+}
+
+// DescriptionOf returns the alert to send for err, internal_error when no
+// description was attached to it.
+func DescriptionOf(err error) spec.AlertDescription {
+	var alertErr *Error
+	if errors.As(err, &alertErr) {
+		return alertErr.Description
+	}
+	return spec.AlertDescriptionInternalError
+}
+
+// RemoteError is an alert received from the peer. It is returned for fatal
+// alerts and for close_notify while a handshake is in progress.
+type RemoteError struct {
+	Level       spec.AlertLevel
+	Description spec.AlertDescription
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("received %v alert from peer: %v", e.Level, e.Description)
+}
+
+// Received interprets the fragment of an alert record sent by the peer. It
+// returns nil for a warning that can be ignored and a *RemoteError for a fatal
+// alert or close_notify.
+func Received(fragment []byte) error {
+	received, err := UnmarshalAlert(fragment)
+	if err != nil {
+		return Wrap(spec.AlertDescriptionDecodeError, err)
+	}
+
+	if received.Level == spec.AlertLevelWarning && received.Description != spec.AlertDescriptionCloseNotify {
+		return nil
+	}
+	return &RemoteError{Level: received.Level, Description: received.Description}
+}
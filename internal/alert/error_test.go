@@ -0,0 +1,105 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestDescriptionOf(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected spec.AlertDescription
+	}{
+		{
+			name:     "tagged error",
+			err:      New(spec.AlertDescriptionIllegalParameter, "bad value"),
+			expected: spec.AlertDescriptionIllegalParameter,
+		},
+		{
+			name:     "wrapped tagged error",
+			err:      fmt.Errorf("context: %w", Wrap(spec.AlertDescriptionDecodeError, io.ErrUnexpectedEOF)),
+			expected: spec.AlertDescriptionDecodeError,
+		},
+		{
+			name:     "untagged error",
+			err:      errors.New("something broke"),
+			expected: spec.AlertDescriptionInternalError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if description := DescriptionOf(tc.err); description != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, description)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if Wrap(spec.AlertDescriptionDecodeError, nil) != nil {
+		t.Error("Expected a nil error to stay nil")
+	}
+
+	err := Wrap(spec.AlertDescriptionDecodeError, io.ErrUnexpectedEOF)
+
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Error("Expected the wrapped error to be preserved")
+	}
+	if err.Error() != io.ErrUnexpectedEOF.Error() {
+		t.Errorf("Expected message %q, got %q", io.ErrUnexpectedEOF.Error(), err.Error())
+	}
+}
+
+func TestReceived(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fragment []byte
+		expected error
+	}{
+		{
+			name:     "ignorable warning",
+			fragment: []byte{0x01, 0x5a},
+		},
+		{
+			name:     "close_notify",
+			fragment: []byte{0x01, 0x00},
+			expected: &RemoteError{Level: spec.AlertLevelWarning, Description: spec.AlertDescriptionCloseNotify},
+		},
+		{
+			name:     "fatal alert",
+			fragment: []byte{0x02, 0x70},
+			expected: &RemoteError{Level: spec.AlertLevelFatal, Description: spec.AlertDescriptionUnrecognizedName},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Received(tc.fragment)
+
+			if tc.expected == nil {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expected.Error() {
+				t.Errorf("Expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestRemoteError(t *testing.T) {
+	err := &RemoteError{Level: spec.AlertLevelFatal, Description: spec.AlertDescriptionHandshakeFailure}
+
+	expected := "received Fatal alert from peer: HandshakeFailure"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+}
@@ -0,0 +1,111 @@
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// KeySize is the size of the AEAD key.
+	KeySize = chacha20KeySize
+	// NonceSize is the size of the AEAD nonce.
+	NonceSize = chacha20NonceSize
+	// Overhead is the size of the Poly1305 tag appended to every ciphertext.
+	Overhead = poly1305TagSize
+)
+
+var errOpen = errors.New("message authentication failed")
+
+type aead struct {
+	key [KeySize]byte
+}
+
+// New returns the ChaCha20-Poly1305 AEAD of RFC 8439, section 2.8.
+func New(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must contain %d bytes, got %d", KeySize, len(key))
+	}
+
+	a := &aead{}
+	copy(a.key[:], key)
+	return a, nil
+}
+
+func (a *aead) NonceSize() int {
+	return NonceSize
+}
+
+func (a *aead) Overhead() int {
+	return Overhead
+}
+
+func (a *aead) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != NonceSize {
+		panic("bad nonce length passed to Seal")
+	}
+
+	ret, out := sliceForAppend(dst, len(plaintext)+Overhead)
+	ciphertext := out[:len(plaintext)]
+	chacha20XOR(ciphertext, plaintext, &a.key, nonce, 1)
+
+	tag := a.tag(nonce, additionalData, ciphertext)
+	copy(out[len(plaintext):], tag[:])
+	return ret
+}
+
+func (a *aead) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != NonceSize {
+		panic("bad nonce length passed to Open")
+	}
+	if len(ciphertext) < Overhead {
+		return nil, errOpen
+	}
+
+	tag := ciphertext[len(ciphertext)-Overhead:]
+	ciphertext = ciphertext[:len(ciphertext)-Overhead]
+	expected := a.tag(nonce, additionalData, ciphertext)
+	if subtle.ConstantTimeCompare(expected[:], tag) != 1 {
+		return nil, errOpen
+	}
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	chacha20XOR(out, ciphertext, &a.key, nonce, 1)
+	return ret, nil
+}
+
+// tag computes the Poly1305 tag over the padded additional data and
+// ciphertext with the one-time key from block 0 of the key stream.
+func (a *aead) tag(nonce, additionalData, ciphertext []byte) [poly1305TagSize]byte {
+	var oneTimeKey [poly1305KeySize]byte
+	chacha20XOR(oneTimeKey[:], oneTimeKey[:], &a.key, nonce, 0)
+
+	mac := newPoly1305(&oneTimeKey)
+	var padding [poly1305TagSize]byte
+	mac.write(additionalData)
+	mac.write(padding[:(poly1305TagSize-len(additionalData)%poly1305TagSize)%poly1305TagSize])
+	mac.write(ciphertext)
+	mac.write(padding[:(poly1305TagSize-len(ciphertext)%poly1305TagSize)%poly1305TagSize])
+
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[0:8], uint64(len(additionalData)))
+	binary.LittleEndian.PutUint64(lengths[8:16], uint64(len(ciphertext)))
+	mac.write(lengths[:])
+
+	return mac.sum()
+}
+
+// sliceForAppend extends in by n bytes, reallocating when needed, and returns
+// the whole slice and the extension.
+func sliceForAppend(in []byte, n int) (whole, tail []byte) {
+	total := len(in) + n
+	if cap(in) >= total {
+		whole = in[:total]
+	} else {
+		whole = make([]byte, total)
+		copy(whole, in)
+	}
+	return whole, whole[len(in):]
+}
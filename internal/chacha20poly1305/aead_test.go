@@ -0,0 +1,95 @@
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+// RFC 8439, section 2.8.2
+func TestAEAD_Seal(t *testing.T) {
+	aead, err := New(sequentialKey(0x80)[:])
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	plaintext := []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+	additionalData := mustDecodeHex(t, "50515253c0c1c2c3c4c5c6c7")
+	nonce := mustDecodeHex(t, "070000004041424344454647")
+	expected := mustDecodeHex(t, "d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d6"+
+		"3dbea45e8ca9671282fafb69da92728b1a71de0a9e060b2905d6a5b67ecd3b36"+
+		"92ddbd7f2d778b8c9803aee328091b58fab324e4fad675945585808b4831d7bc"+
+		"3ff4def08e4b7a9de576d26586cec64b6116"+
+		"1ae10b594f09e26a7e902ecbd0600691")
+
+	sealed := aead.Seal(nil, nonce, plaintext, additionalData)
+
+	if !bytes.Equal(sealed, expected) {
+		t.Errorf("Expected %x, got %x", expected, sealed)
+	}
+}
+
+// RFC 8439, appendix A.5
+func TestAEAD_Open(t *testing.T) {
+	key := mustDecodeHex(t, "1c9240a5eb55d38af333888604f6b5f0473917c1402b80099dca5cbc207075c0")
+	ciphertext := mustDecodeHex(t, "64a0861575861af460f062c79be643bd5e805cfd345cf389f108670ac76c8cb2"+
+		"4c6cfc18755d43eea09ee94e382d26b0bdb7b73c321b0100d4f03b7f355894cf"+
+		"332f830e710b97ce98c8a84abd0b948114ad176e008d33bd60f982b1ff37c855"+
+		"9797a06ef4f0ef61c186324e2b3506383606907b6a7c02b0f9f6157b53c867e4"+
+		"b9166c767b804d46a59b5216cde7a4e99040c5a40433225ee282a1b0a06c523e"+
+		"af4534d7f83fa1155b0047718cbc546a0d072b04b3564eea1b422273f548271a"+
+		"0bb2316053fa76991955ebd63159434ecebb4e466dae5a1073a6727627097a10"+
+		"49e617d91d361094fa68f0ff77987130305beaba2eda04df997b714d6c6f2c29"+
+		"a6ad5cb4022b02709b"+
+		"eead9d67890cbb22392336fea1851f38")
+	additionalData := mustDecodeHex(t, "f33388860000000000004e91")
+	nonce := mustDecodeHex(t, "000000000102030405060708")
+	aead, err := New(key)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.HasPrefix(plaintext, []byte("Internet-Drafts are draft documents valid for a maximum of six months")) {
+		t.Errorf("Expected the Internet-Drafts boilerplate, got %q", plaintext)
+	}
+	if len(plaintext) != 265 {
+		t.Errorf("Expected 265 bytes, got %d", len(plaintext))
+	}
+}
+
+func TestAEAD_OpenRejectsTampering(t *testing.T) {
+	aead, err := New(sequentialKey(0x80)[:])
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	nonce := mustDecodeHex(t, "070000004041424344454647")
+	sealed := aead.Seal(nil, nonce, []byte("hello"), []byte("header"))
+
+	testCases := []struct {
+		name           string
+		ciphertext     []byte
+		additionalData []byte
+	}{
+		{name: "flipped ciphertext bit", ciphertext: append([]byte{sealed[0] ^ 1}, sealed[1:]...), additionalData: []byte("header")},
+		{name: "flipped tag bit", ciphertext: append(append([]byte(nil), sealed[:len(sealed)-1]...), sealed[len(sealed)-1]^1), additionalData: []byte("header")},
+		{name: "other additional data", ciphertext: sealed, additionalData: []byte("footer")},
+		{name: "shorter than the tag", ciphertext: sealed[:Overhead-1], additionalData: []byte("header")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := aead.Open(nil, nonce, tc.ciphertext, tc.additionalData); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestNew_InvalidKeyLength(t *testing.T) {
+	if _, err := New(make([]byte, 16)); err == nil {
+		t.Error("Expected an error, got nil")
+	}
+}
@@ -0,0 +1,78 @@
+// Package chacha20poly1305 implements the ChaCha20-Poly1305 AEAD of RFC 8439,
+// which the standard library does not export.
+package chacha20poly1305
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	chacha20KeySize   = 32
+	chacha20NonceSize = 12
+	chacha20BlockSize = 64
+)
+
+// chacha20Block computes the 64-byte key stream block for the given counter,
+// RFC 8439, section 2.3.
+func chacha20Block(out *[chacha20BlockSize]byte, key *[8]uint32, counter uint32, nonce *[3]uint32) {
+	// "expand 32-byte k"
+	initial := [16]uint32{
+		0x61707865, 0x3320646e, 0x79622d32, 0x6b206574,
+		key[0], key[1], key[2], key[3],
+		key[4], key[5], key[6], key[7],
+		counter, nonce[0], nonce[1], nonce[2],
+	}
+
+	x := initial
+	for range 10 {
+		quarterRound(&x, 0, 4, 8, 12)
+		quarterRound(&x, 1, 5, 9, 13)
+		quarterRound(&x, 2, 6, 10, 14)
+		quarterRound(&x, 3, 7, 11, 15)
+		quarterRound(&x, 0, 5, 10, 15)
+		quarterRound(&x, 1, 6, 11, 12)
+		quarterRound(&x, 2, 7, 8, 13)
+		quarterRound(&x, 3, 4, 9, 14)
+	}
+
+	for i := range x {
+		binary.LittleEndian.PutUint32(out[4*i:], x[i]+initial[i])
+	}
+}
+
+func quarterRound(x *[16]uint32, a, b, c, d int) {
+	x[a] += x[b]
+	x[d] = bits.RotateLeft32(x[d]^x[a], 16)
+	x[c] += x[d]
+	x[b] = bits.RotateLeft32(x[b]^x[c], 12)
+	x[a] += x[b]
+	x[d] = bits.RotateLeft32(x[d]^x[a], 8)
+	x[c] += x[d]
+	x[b] = bits.RotateLeft32(x[b]^x[c], 7)
+}
+
+// chacha20XOR XORs src with the key stream starting at block counter into
+// dst, RFC 8439, section 2.4. dst and src may overlap entirely.
+func chacha20XOR(dst, src []byte, key *[chacha20KeySize]byte, nonce []byte, counter uint32) {
+	var keyWords [8]uint32
+	for i := range keyWords {
+		keyWords[i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	var nonceWords [3]uint32
+	for i := range nonceWords {
+		nonceWords[i] = binary.LittleEndian.Uint32(nonce[4*i:])
+	}
+
+	var stream [chacha20BlockSize]byte
+	for len(src) > 0 {
+		chacha20Block(&stream, &keyWords, counter, &nonceWords)
+		counter++
+
+		n := min(len(src), chacha20BlockSize)
+		for i := range n {
+			dst[i] = src[i] ^ stream[i]
+		}
+		dst, src = dst[n:], src[n:]
+	}
+}
@@ -0,0 +1,69 @@
+package chacha20poly1305
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("failed to decode %q: %v", s, err)
+	}
+	return decoded
+}
+
+func sequentialKey(first byte) *[KeySize]byte {
+	var key [KeySize]byte
+	for i := range key {
+		key[i] = first + byte(i)
+	}
+	return &key
+}
+
+// RFC 8439, section 2.3.2
+func TestChaCha20Block(t *testing.T) {
+	expected := mustDecodeHex(t, "10f1e7e4d13b5915500fdd1fa32071c4c7d1f4c733c068030422aa9ac3d46c4e"+
+		"d2826446079faa0914c2d705d98b02a2b5129cd1de164eb9cbd083e8a2503c4e")
+	nonce := mustDecodeHex(t, "000000090000004a00000000")
+	block := make([]byte, chacha20BlockSize)
+
+	chacha20XOR(block, block, sequentialKey(0), nonce, 1)
+
+	if !bytes.Equal(block, expected) {
+		t.Errorf("Expected %x, got %x", expected, block)
+	}
+}
+
+// RFC 8439, section 2.4.2
+func TestChaCha20XOR(t *testing.T) {
+	plaintext := []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+	expected := mustDecodeHex(t, "6e2e359a2568f98041ba0728dd0d6981e97e7aec1d4360c20a27afccfd9fae0b"+
+		"f91b65c5524733ab8f593dabcd62b3571639d624e65152ab8f530c359f0861d8"+
+		"07ca0dbf500d6a6156a38e088a22b65e52bc514d16ccf806818ce91ab7793736"+
+		"5af90bbf74a35be6b40b8eedf2785e42874d")
+	nonce := mustDecodeHex(t, "000000000000004a00000000")
+	ciphertext := make([]byte, len(plaintext))
+
+	chacha20XOR(ciphertext, plaintext, sequentialKey(0), nonce, 1)
+
+	if !bytes.Equal(ciphertext, expected) {
+		t.Errorf("Expected %x, got %x", expected, ciphertext)
+	}
+}
+
+// RFC 8439, section 2.6.2
+func TestChaCha20_Poly1305KeyGeneration(t *testing.T) {
+	expected := mustDecodeHex(t, "8ad5a08b905f81cc815040274ab29471a833b637e3fd0da508dbb8e2fdd1a646")
+	nonce := mustDecodeHex(t, "000000000001020304050607")
+	oneTimeKey := make([]byte, poly1305KeySize)
+
+	chacha20XOR(oneTimeKey, oneTimeKey, sequentialKey(0x80), nonce, 0)
+
+	if !bytes.Equal(oneTimeKey, expected) {
+		t.Errorf("Expected %x, got %x", expected, oneTimeKey)
+	}
+}
@@ -0,0 +1,132 @@
+package chacha20poly1305
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	poly1305KeySize = 32
+	poly1305TagSize = 16
+)
+
+// poly1305 accumulates a one-time authenticator, RFC 8439, section 2.5. The
+// accumulator h is kept in three 64-bit limbs and only partially reduced
+// modulo 2^130 - 5 until sum.
+type poly1305 struct {
+	h      [3]uint64
+	r      [2]uint64
+	s      [2]uint64
+	buffer [poly1305TagSize]byte
+	offset int
+}
+
+func newPoly1305(key *[poly1305KeySize]byte) *poly1305 {
+	p := &poly1305{}
+	// clamping clears the bits r must not have
+	p.r[0] = binary.LittleEndian.Uint64(key[0:8]) & 0x0ffffffc0fffffff
+	p.r[1] = binary.LittleEndian.Uint64(key[8:16]) & 0x0ffffffc0ffffffc
+	p.s[0] = binary.LittleEndian.Uint64(key[16:24])
+	p.s[1] = binary.LittleEndian.Uint64(key[24:32])
+
+	return p
+}
+
+func (p *poly1305) write(data []byte) {
+	if p.offset > 0 {
+		n := copy(p.buffer[p.offset:], data)
+		p.offset += n
+		data = data[n:]
+		if p.offset < poly1305TagSize {
+			return
+		}
+		p.blocks(p.buffer[:], true)
+		p.offset = 0
+	}
+
+	full := len(data) - len(data)%poly1305TagSize
+	p.blocks(data[:full], true)
+	p.offset = copy(p.buffer[:], data[full:])
+}
+
+// blocks adds each 16-byte block of data, with the 2^128 bit set for full
+// blocks, to h and multiplies h by r.
+func (p *poly1305) blocks(data []byte, full bool) {
+	h0, h1, h2 := p.h[0], p.h[1], p.h[2]
+	r0, r1 := p.r[0], p.r[1]
+
+	for len(data) >= poly1305TagSize {
+		var carry uint64
+		h0, carry = bits.Add64(h0, binary.LittleEndian.Uint64(data[0:8]), 0)
+		h1, carry = bits.Add64(h1, binary.LittleEndian.Uint64(data[8:16]), carry)
+		h2 += carry
+		if full {
+			h2++
+		}
+		data = data[poly1305TagSize:]
+
+		// h * r, where h2 is at most 7 and the clamped r keeps every partial
+		// product sum below 2^128
+		h0r0Hi, h0r0Lo := bits.Mul64(h0, r0)
+		h1r0Hi, h1r0Lo := bits.Mul64(h1, r0)
+		h0r1Hi, h0r1Lo := bits.Mul64(h0, r1)
+		h1r1Hi, h1r1Lo := bits.Mul64(h1, r1)
+		h2r0 := h2 * r0
+		h2r1 := h2 * r1
+
+		m1Lo, c := bits.Add64(h1r0Lo, h0r1Lo, 0)
+		m1Hi, _ := bits.Add64(h1r0Hi, h0r1Hi, c)
+		m2Lo, c := bits.Add64(h1r1Lo, h2r0, 0)
+		m2Hi, _ := bits.Add64(h1r1Hi, 0, c)
+
+		t0 := h0r0Lo
+		t1, c := bits.Add64(m1Lo, h0r0Hi, 0)
+		t2, c := bits.Add64(m2Lo, m1Hi, c)
+		t3, _ := bits.Add64(h2r1, m2Hi, c)
+
+		// 2^130 = 5 modulo 2^130 - 5, so the bits from 2^130 up are added back
+		// once as they are and once shifted right by two, which is times 5/4
+		h0, h1, h2 = t0, t1, t2&3
+		cLo, cHi := t2&^3, t3
+		h0, c = bits.Add64(h0, cLo, 0)
+		h1, c = bits.Add64(h1, cHi, c)
+		h2 += c
+		cLo, cHi = cLo>>2|cHi<<62, cHi>>2
+		h0, c = bits.Add64(h0, cLo, 0)
+		h1, c = bits.Add64(h1, cHi, c)
+		h2 += c
+	}
+
+	p.h[0], p.h[1], p.h[2] = h0, h1, h2
+}
+
+// sum returns the tag over everything written.
+func (p *poly1305) sum() [poly1305TagSize]byte {
+	if p.offset > 0 {
+		// the last partial block is padded with a one byte and zeros
+		var last [poly1305TagSize]byte
+		copy(last[:], p.buffer[:p.offset])
+		last[p.offset] = 1
+		p.blocks(last[:], false)
+		p.offset = 0
+	}
+
+	// h is below 2 * (2^130 - 5), one conditional subtraction reduces it
+	h0, h1, h2 := p.h[0], p.h[1], p.h[2]
+	t0, borrow := bits.Sub64(h0, 0xfffffffffffffffb, 0)
+	t1, borrow := bits.Sub64(h1, 0xffffffffffffffff, borrow)
+	_, borrow = bits.Sub64(h2, 3, borrow)
+	// a borrow means h was already reduced, the mask keeps it then
+	keep := -borrow
+	h0 = h0&keep | t0&^keep
+	h1 = h1&keep | t1&^keep
+
+	var c uint64
+	h0, c = bits.Add64(h0, p.s[0], 0)
+	h1, _ = bits.Add64(h1, p.s[1], c)
+
+	var tag [poly1305TagSize]byte
+	binary.LittleEndian.PutUint64(tag[0:8], h0)
+	binary.LittleEndian.PutUint64(tag[8:16], h1)
+	return tag
+}
@@ -0,0 +1,92 @@
+package chacha20poly1305
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPoly1305(t *testing.T) {
+	testCases := []struct {
+		name     string
+		key      string
+		message  string
+		expected string
+	}{
+		{
+			// RFC 8439, section 2.5.2
+			name:     "Cryptographic Forum Research Group",
+			key:      "85d6be7857556d337f4452fe42d506a80103808afb0db2fd4abff6af4149f51b",
+			message:  "43727970746f6772617068696320466f72756d2052657365617263682047726f7570",
+			expected: "a8061dc1305136c6c22b8baf0c0127a9",
+		},
+		{
+			// RFC 8439, appendix A.3, test vector #5
+			name:     "partially reduced result",
+			key:      "02" + strings.Repeat("00", 31),
+			message:  strings.Repeat("ff", 16),
+			expected: "03" + strings.Repeat("00", 15),
+		},
+		{
+			// RFC 8439, appendix A.3, test vector #6
+			name:     "sum with s overflows",
+			key:      "02" + strings.Repeat("00", 15) + strings.Repeat("ff", 16),
+			message:  "02" + strings.Repeat("00", 15),
+			expected: "03" + strings.Repeat("00", 15),
+		},
+		{
+			// RFC 8439, appendix A.3, test vector #7
+			name:     "carry across limbs",
+			key:      "01" + strings.Repeat("00", 31),
+			message:  strings.Repeat("ff", 16) + "f0" + strings.Repeat("ff", 15) + "11" + strings.Repeat("00", 15),
+			expected: "05" + strings.Repeat("00", 15),
+		},
+		{
+			// RFC 8439, appendix A.3, test vector #8
+			name:     "result equal to the modulus",
+			key:      "01" + strings.Repeat("00", 31),
+			message:  strings.Repeat("ff", 16) + "fb" + strings.Repeat("fe", 15) + strings.Repeat("01", 16),
+			expected: strings.Repeat("00", 16),
+		},
+		{
+			// RFC 8439, appendix A.3, test vector #9
+			name:     "result just below the modulus",
+			key:      "02" + strings.Repeat("00", 31),
+			message:  "fd" + strings.Repeat("ff", 15),
+			expected: "fa" + strings.Repeat("ff", 15),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var key [poly1305KeySize]byte
+			copy(key[:], mustDecodeHex(t, tc.key))
+			expected := mustDecodeHex(t, tc.expected)
+
+			mac := newPoly1305(&key)
+			mac.write(mustDecodeHex(t, tc.message))
+			tag := mac.sum()
+
+			if !bytes.Equal(tag[:], expected) {
+				t.Errorf("Expected %x, got %x", expected, tag)
+			}
+		})
+	}
+}
+
+func TestPoly1305_SplitWrites(t *testing.T) {
+	var key [poly1305KeySize]byte
+	copy(key[:], mustDecodeHex(t, "85d6be7857556d337f4452fe42d506a80103808afb0db2fd4abff6af4149f51b"))
+	message := []byte("Cryptographic Forum Research Group")
+
+	mac := newPoly1305(&key)
+	for _, part := range [][]byte{message[:3], message[3:20], message[20:21], message[21:]} {
+		mac.write(part)
+	}
+	tag := mac.sum()
+
+	expected := mustDecodeHex(t, "a8061dc1305136c6c22b8baf0c0127a9")
+	if !bytes.Equal(tag[:], expected) {
+		t.Errorf("Expected %x, got %x", expected, tag)
+	}
+}
@@ -0,0 +1,162 @@
+package ciphersuite
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	// registers the hash functions referenced by the suite table
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"fmt"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+type KeyExchange uint8
+
+const (
+	KeyExchangeRSA KeyExchange = iota
+	KeyExchangeDHE
+	KeyExchangeECDHE
+)
+
+type Authentication uint8
+
+const (
+	AuthenticationRSA Authentication = iota
+	AuthenticationECDSA
+)
+
+type Cipher uint8
+
+const (
+	CipherAESGCM Cipher = iota
+	CipherChaCha20Poly1305
+	CipherAESCBC
+)
+
+type Parameters struct {
+	ID             spec.CipherSuite
+	KeyExchange    KeyExchange
+	Authentication Authentication
+	Cipher         Cipher
+
+	// PRFHash is SHA-256 unless the suite name ends in _SHA384
+	PRFHash crypto.Hash
+	// MACHash is zero for AEAD ciphers
+	MACHash crypto.Hash
+
+	MACLength      int
+	KeyLength      int
+	FixedIVLength  int
+	RecordIVLength int
+}
+
+func (p *Parameters) AEAD() bool {
+	return p.Cipher == CipherAESGCM || p.Cipher == CipherChaCha20Poly1305
+}
+
+// UsableWith reports whether the suite can be authenticated with a certificate
+// holding publicKey. ECDSA suites also take EdDSA keys (RFC 8422, section 2.1).
+func (p *Parameters) UsableWith(publicKey crypto.PublicKey) bool {
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		return p.Authentication == AuthenticationRSA
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+		return p.Authentication == AuthenticationECDSA
+	default:
+		return false
+	}
+}
+
+func gcm(id spec.CipherSuite, kx KeyExchange, auth Authentication, keyLength int, prfHash crypto.Hash) *Parameters {
+	return &Parameters{
+		ID:             id,
+		KeyExchange:    kx,
+		Authentication: auth,
+		Cipher:         CipherAESGCM,
+		PRFHash:        prfHash,
+		KeyLength:      keyLength,
+		FixedIVLength:  4,
+		RecordIVLength: 8,
+	}
+}
+
+func chacha20Poly1305(id spec.CipherSuite, kx KeyExchange, auth Authentication) *Parameters {
+	return &Parameters{
+		ID:             id,
+		KeyExchange:    kx,
+		Authentication: auth,
+		Cipher:         CipherChaCha20Poly1305,
+		PRFHash:        crypto.SHA256,
+		KeyLength:      32,
+		FixedIVLength:  12,
+	}
+}
+
+func cbc(id spec.CipherSuite, kx KeyExchange, auth Authentication, cipher Cipher, keyLength int, macHash crypto.Hash) *Parameters {
+	prfHash := crypto.SHA256
+	if macHash == crypto.SHA384 {
+		prfHash = crypto.SHA384
+	}
+
+	return &Parameters{
+		ID:             id,
+		KeyExchange:    kx,
+		Authentication: auth,
+		Cipher:         cipher,
+		PRFHash:        prfHash,
+		MACHash:        macHash,
+		MACLength:      macHash.Size(),
+		KeyLength:      keyLength,
+		RecordIVLength: 16,
+	}
+}
+
+var suites = []*Parameters{
+	gcm(spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256, KeyExchangeECDHE, AuthenticationRSA, 16, crypto.SHA256),
+	gcm(spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384, KeyExchangeECDHE, AuthenticationRSA, 32, crypto.SHA384),
+	gcm(spec.CipherSuiteECDHE_ECDSA_WITH_AES_128_GCM_SHA256, KeyExchangeECDHE, AuthenticationECDSA, 16, crypto.SHA256),
+	gcm(spec.CipherSuiteECDHE_ECDSA_WITH_AES_256_GCM_SHA384, KeyExchangeECDHE, AuthenticationECDSA, 32, crypto.SHA384),
+	gcm(spec.CipherSuiteDHE_RSA_WITH_AES_128_GCM_SHA256, KeyExchangeDHE, AuthenticationRSA, 16, crypto.SHA256),
+	gcm(spec.CipherSuiteDHE_RSA_WITH_AES_256_GCM_SHA384, KeyExchangeDHE, AuthenticationRSA, 32, crypto.SHA384),
+	gcm(spec.CipherSuiteRSA_WITH_AES_128_GCM_SHA256, KeyExchangeRSA, AuthenticationRSA, 16, crypto.SHA256),
+	gcm(spec.CipherSuiteRSA_WITH_AES_256_GCM_SHA384, KeyExchangeRSA, AuthenticationRSA, 32, crypto.SHA384),
+
+	chacha20Poly1305(spec.CipherSuiteECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256, KeyExchangeECDHE, AuthenticationRSA),
+	chacha20Poly1305(spec.CipherSuiteECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256, KeyExchangeECDHE, AuthenticationECDSA),
+	chacha20Poly1305(spec.CipherSuiteDHE_RSA_WITH_CHACHA20_POLY1305_SHA256, KeyExchangeDHE, AuthenticationRSA),
+
+	cbc(spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA, KeyExchangeECDHE, AuthenticationRSA, CipherAESCBC, 16, crypto.SHA1),
+	cbc(spec.CipherSuiteECDHE_RSA_WITH_AES_256_CBC_SHA, KeyExchangeECDHE, AuthenticationRSA, CipherAESCBC, 32, crypto.SHA1),
+	cbc(spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA256, KeyExchangeECDHE, AuthenticationRSA, CipherAESCBC, 16, crypto.SHA256),
+	cbc(spec.CipherSuiteECDHE_RSA_WITH_AES_256_CBC_SHA384, KeyExchangeECDHE, AuthenticationRSA, CipherAESCBC, 32, crypto.SHA384),
+	cbc(spec.CipherSuiteECDHE_ECDSA_WITH_AES_128_CBC_SHA, KeyExchangeECDHE, AuthenticationECDSA, CipherAESCBC, 16, crypto.SHA1),
+	cbc(spec.CipherSuiteECDHE_ECDSA_WITH_AES_256_CBC_SHA, KeyExchangeECDHE, AuthenticationECDSA, CipherAESCBC, 32, crypto.SHA1),
+	cbc(spec.CipherSuiteECDHE_ECDSA_WITH_AES_128_CBC_SHA256, KeyExchangeECDHE, AuthenticationECDSA, CipherAESCBC, 16, crypto.SHA256),
+	cbc(spec.CipherSuiteECDHE_ECDSA_WITH_AES_256_CBC_SHA384, KeyExchangeECDHE, AuthenticationECDSA, CipherAESCBC, 32, crypto.SHA384),
+	cbc(spec.CipherSuiteDHE_RSA_WITH_AES_128_CBC_SHA, KeyExchangeDHE, AuthenticationRSA, CipherAESCBC, 16, crypto.SHA1),
+	cbc(spec.CipherSuiteDHE_RSA_WITH_AES_256_CBC_SHA, KeyExchangeDHE, AuthenticationRSA, CipherAESCBC, 32, crypto.SHA1),
+	cbc(spec.CipherSuiteDHE_RSA_WITH_AES_128_CBC_SHA256, KeyExchangeDHE, AuthenticationRSA, CipherAESCBC, 16, crypto.SHA256),
+	cbc(spec.CipherSuiteDHE_RSA_WITH_AES_256_CBC_SHA256, KeyExchangeDHE, AuthenticationRSA, CipherAESCBC, 32, crypto.SHA256),
+	cbc(spec.CipherSuiteRSA_WITH_AES_128_CBC_SHA, KeyExchangeRSA, AuthenticationRSA, CipherAESCBC, 16, crypto.SHA1),
+	cbc(spec.CipherSuiteRSA_WITH_AES_256_CBC_SHA, KeyExchangeRSA, AuthenticationRSA, CipherAESCBC, 32, crypto.SHA1),
+	cbc(spec.CipherSuiteRSA_WITH_AES_128_CBC_SHA256, KeyExchangeRSA, AuthenticationRSA, CipherAESCBC, 16, crypto.SHA256),
+	cbc(spec.CipherSuiteRSA_WITH_AES_256_CBC_SHA256, KeyExchangeRSA, AuthenticationRSA, CipherAESCBC, 32, crypto.SHA256),
+
+	// The CAMELLIA CBC suites are left out until a Camellia block cipher is
+	// available, the standard library has none. Lookup fails for them like for
+	// any other suite this package does not implement.
+}
+
+func Lookup(id spec.CipherSuite) (*Parameters, error) {
+	for _, suite := range suites {
+		if suite.ID == id {
+			return suite, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown cipher suite: %v", id)
+}
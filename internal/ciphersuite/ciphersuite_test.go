@@ -0,0 +1,87 @@
+package ciphersuite
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestLookup_KnownSuite(t *testing.T) {
+	params, err := Lookup(spec.CipherSuiteECDHE_ECDSA_WITH_AES_256_CBC_SHA384)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if params.PRFHash != crypto.SHA384 {
+		t.Errorf("Expected SHA-384 PRF, got %v", params.PRFHash)
+	}
+	if params.MACLength != 48 {
+		t.Errorf("Expected 48-byte MAC, got %d", params.MACLength)
+	}
+	if params.KeyLength != 32 {
+		t.Errorf("Expected 32-byte key, got %d", params.KeyLength)
+	}
+	if params.AEAD() {
+		t.Error("Expected CBC suite not to be AEAD")
+	}
+	if params.Authentication != AuthenticationECDSA {
+		t.Errorf("Expected ECDSA authentication, got %v", params.Authentication)
+	}
+}
+
+func TestLookup_UnknownSuite(t *testing.T) {
+	_, err := Lookup(spec.CipherSuiteRSA_WITH_RC4_128_MD5)
+
+	if err == nil {
+		t.Fatal("Expected error for unknown suite")
+	}
+	if err.Error() != "unknown cipher suite: CipherSuite(0x0004)" {
+		t.Errorf("Unexpected error message %q", err.Error())
+	}
+}
+
+func TestLookup_DeferredCamelliaSuite(t *testing.T) {
+	_, err := Lookup(spec.CipherSuiteRSA_WITH_CAMELLIA_128_CBC_SHA)
+
+	if err == nil {
+		t.Fatal("Expected error for a Camellia suite")
+	}
+}
+
+func TestParameters_UsableWith(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	ecdsaKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ed25519Key, _, _ := ed25519.GenerateKey(rand.Reader)
+	testCases := []struct {
+		name        string
+		cipherSuite spec.CipherSuite
+		publicKey   crypto.PublicKey
+		expected    bool
+	}{
+		{"RSA suite with RSA key", spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256, &rsaKey.PublicKey, true},
+		{"RSA suite with ECDSA key", spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256, &ecdsaKey.PublicKey, false},
+		{"ECDSA suite with ECDSA key", spec.CipherSuiteECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256, &ecdsaKey.PublicKey, true},
+		{"ECDSA suite with Ed25519 key", spec.CipherSuiteECDHE_ECDSA_WITH_AES_128_CBC_SHA, ed25519Key, true},
+		{"ECDSA suite with RSA key", spec.CipherSuiteECDHE_ECDSA_WITH_AES_256_GCM_SHA384, &rsaKey.PublicKey, false},
+		{"unknown key type", spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256, "key", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params, err := Lookup(tc.cipherSuite)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if usable := params.UsableWith(tc.publicKey); usable != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, usable)
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package handshake
 
 import (
 	"encoding/binary"
@@ -8,7 +8,7 @@ import (
 	"github.com/piligrimm/tls/spec"
 )
 
-func marshalClientHello(clientHello *spec.ClientHello) []byte {
+func MarshalClientHello(clientHello *spec.ClientHello) []byte {
 	payload := []byte{}
 	payload = append(payload, clientHello.ClientTlsVersion.Major, clientHello.ClientTlsVersion.Minor)
 
@@ -43,7 +43,7 @@ func marshalClientHello(clientHello *spec.ClientHello) []byte {
 	return payload
 }
 
-func unmarshalClientHello(raw []byte) (*spec.ClientHello, error) {
+func UnmarshalClientHello(raw []byte) (*spec.ClientHello, error) {
 	const minLen = 41
 	if len(raw) < minLen {
 		return nil, fmt.Errorf("raw payload too short to be a valid ClientHello")
@@ -61,11 +61,8 @@ func unmarshalClientHello(raw []byte) (*spec.ClientHello, error) {
 	if err := need(2); err != nil {
 		return nil, err
 	}
-	// todo: validate that protocol version is tls 1.2
-	protocolVersion, err := utils.ParseProtocolVersionFromRawPayload(raw[off : off+2])
-	if err != nil {
-		return nil, err
-	}
+	// the version is negotiated by the server, which may pick a lower one
+	protocolVersion := spec.ProtocolVersion{Major: raw[off], Minor: raw[off+1]}
 	off += 2
 
 	// random (32)
@@ -126,17 +123,6 @@ func unmarshalClientHello(raw []byte) (*spec.ClientHello, error) {
 		compressionMethods[i] = spec.CompressionMethod(raw[off+i])
 	}
 	off += compLen
-	// null(0) compression method is required in TLS 1.2
-	hasNull := false
-	for _, m := range compressionMethods {
-		if m == spec.CompressionMethodNull {
-			hasNull = true
-			break
-		}
-	}
-	if !hasNull {
-		return nil, fmt.Errorf("null compression method (0) is required")
-	}
 
 	// extensions (optional)
 	extensions := make([]spec.Extension, 0)
@@ -151,6 +137,8 @@ func unmarshalClientHello(raw []byte) (*spec.ClientHello, error) {
 		}
 		endExt := off + extLen
 
+		// RFC 5246, section 7.4.1.4: an extension type appears at most once
+		seen := make(map[spec.ExtensionType]bool)
 		for off < endExt {
 			// header: type(2) + length(2)
 			if endExt-off < 4 {
@@ -165,13 +153,16 @@ func unmarshalClientHello(raw []byte) (*spec.ClientHello, error) {
 			opaque := append([]byte(nil), raw[off:off+opaqueLen]...)
 			off += opaqueLen
 
+			if seen[extType] {
+				return nil, fmt.Errorf("duplicate extension %v", extType)
+			}
+			seen[extType] = true
 			extensions = append(extensions, spec.Extension{Type: extType, Opaque: opaque})
 		}
-		// todo: validate duplicate extensions
 	}
 
 	return &spec.ClientHello{
-		ClientTlsVersion:   *protocolVersion,
+		ClientTlsVersion:   protocolVersion,
 		Random:             random,
 		SessionID:          sessionID,
 		CipherSuites:       cipherSuites,
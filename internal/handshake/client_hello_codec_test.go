@@ -1,4 +1,4 @@
-package main
+package handshake
 
 import (
 	"bytes"
@@ -29,7 +29,7 @@ func TestUnmarshalClientHello_ValidInput(t *testing.T) {
 		0x03, 0x02, 0x01, 0x02, 0x03,
 	}
 
-	clientHello, err := unmarshalClientHello(rawPayload)
+	clientHello, err := UnmarshalClientHello(rawPayload)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -186,13 +186,16 @@ func TestMarshalClientHello_ValidInput(t *testing.T) {
 		},
 	}
 
-	clientHello, err := newClientHello(random, sessionID, cipherSuites, extensions)
-
-	if err != nil {
-		t.Fatalf("Expected no error, got %v", err)
+	clientHello := &spec.ClientHello{
+		ClientTlsVersion:   spec.Tls12ProtocolVersion(),
+		Random:             random,
+		SessionID:          sessionID,
+		CipherSuites:       cipherSuites,
+		CompressionMethods: []spec.CompressionMethod{spec.CompressionMethodNull},
+		Extensions:         utils.CopyExtensions(extensions),
 	}
 
-	rawClientHello := marshalClientHello(clientHello)
+	rawClientHello := MarshalClientHello(clientHello)
 
 	expectedRawClientHello := []byte{
 		0x03, 0x03, 0x6f, 0x98, 0x03, 0x8c, 0x08, 0x3e, 0xa1, 0x51, 0x38, 0x1e,
@@ -213,3 +216,42 @@ func TestMarshalClientHello_ValidInput(t *testing.T) {
 		}
 	}
 }
+
+func TestUnmarshalClientHello_OtherVersion(t *testing.T) {
+	clientHello := &spec.ClientHello{
+		ClientTlsVersion:   spec.ProtocolVersion{Major: 3, Minor: 4},
+		Random:             make([]byte, 32),
+		CipherSuites:       []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		CompressionMethods: []spec.CompressionMethod{spec.CompressionMethodNull},
+	}
+
+	decoded, err := UnmarshalClientHello(MarshalClientHello(clientHello))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if decoded.ClientTlsVersion != clientHello.ClientTlsVersion {
+		t.Errorf("Expected version %v, got %v", clientHello.ClientTlsVersion, decoded.ClientTlsVersion)
+	}
+}
+
+func TestUnmarshalClientHello_DuplicateExtension(t *testing.T) {
+	clientHello := &spec.ClientHello{
+		ClientTlsVersion:   spec.Tls12ProtocolVersion(),
+		Random:             make([]byte, 32),
+		CipherSuites:       []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		CompressionMethods: []spec.CompressionMethod{spec.CompressionMethodNull},
+		Extensions: []spec.Extension{
+			{Type: spec.ExtensionTypeExtendedMasterSecret},
+			{Type: spec.ExtensionTypeSessionTicket},
+			{Type: spec.ExtensionTypeExtendedMasterSecret},
+		},
+	}
+
+	_, err := UnmarshalClientHello(MarshalClientHello(clientHello))
+
+	expected := "duplicate extension ExtendedMasterSecret"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,73 @@
+package handshake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+func MarshalClientKeyExchange(clientKeyExchange *spec.ClientKeyExchange) []byte {
+	payload := []byte{}
+	payload = append(payload, utils.CastUint8OrPanic(len(clientKeyExchange.PublicKey)))
+	payload = append(payload, clientKeyExchange.PublicKey...)
+
+	return payload
+}
+
+func UnmarshalClientKeyExchange(raw []byte) (*spec.ClientKeyExchange, error) {
+	if len(raw) < 1 {
+		return nil, errors.New("truncated ClientKeyExchange")
+	}
+
+	pointLen := int(raw[0])
+	if pointLen == 0 {
+		return nil, errors.New("ECDH public point cannot be empty")
+	}
+	if len(raw)-1 != pointLen {
+		return nil, fmt.Errorf("ClientKeyExchange point length %d does not match body length %d", pointLen, len(raw)-1)
+	}
+
+	return &spec.ClientKeyExchange{
+		PublicKey: append([]byte(nil), raw[1:]...),
+	}, nil
+}
+
+// MarshalEncryptedPreMasterSecret encodes the ClientKeyExchange of the RSA key
+// exchange, whose body is the encrypted pre-master secret.
+func MarshalEncryptedPreMasterSecret(clientKeyExchange *spec.ClientKeyExchange) ([]byte, error) {
+	encrypted := clientKeyExchange.EncryptedPreMasterSecret
+	if len(encrypted) == 0 {
+		return nil, errors.New("encrypted pre-master secret cannot be empty")
+	}
+	if len(encrypted) > math.MaxUint16 {
+		return nil, fmt.Errorf("encrypted pre-master secret cannot be longer than %d bytes", math.MaxUint16)
+	}
+
+	payload := make([]byte, 0, 2+len(encrypted))
+	payload = binary.BigEndian.AppendUint16(payload, utils.CastUint16OrPanic(len(encrypted)))
+	payload = append(payload, encrypted...)
+
+	return payload, nil
+}
+
+func UnmarshalEncryptedPreMasterSecret(raw []byte) (*spec.ClientKeyExchange, error) {
+	if len(raw) < 2 {
+		return nil, errors.New("truncated ClientKeyExchange")
+	}
+
+	encryptedLen := int(binary.BigEndian.Uint16(raw[:2]))
+	if encryptedLen == 0 {
+		return nil, errors.New("encrypted pre-master secret cannot be empty")
+	}
+	if len(raw)-2 != encryptedLen {
+		return nil, fmt.Errorf("encrypted pre-master secret length %d does not match body length %d", encryptedLen, len(raw)-2)
+	}
+
+	return &spec.ClientKeyExchange{
+		EncryptedPreMasterSecret: append([]byte(nil), raw[2:]...),
+	}, nil
+}
@@ -0,0 +1,127 @@
+package handshake
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestMarshalClientKeyExchange_ValidInput(t *testing.T) {
+	raw := MarshalClientKeyExchange(&spec.ClientKeyExchange{PublicKey: []byte{0x04, 0xaa, 0xbb}})
+
+	expected := []byte{0x03, 0x04, 0xaa, 0xbb}
+	if !bytes.Equal(raw, expected) {
+		t.Fatalf("Expected %x, got %x", expected, raw)
+	}
+
+	parsed, err := UnmarshalClientKeyExchange(raw)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Equal(parsed.PublicKey, []byte{0x04, 0xaa, 0xbb}) {
+		t.Errorf("Unexpected public key: %x", parsed.PublicKey)
+	}
+}
+
+func TestUnmarshalClientKeyExchange_InvalidInput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "empty body",
+			raw:      []byte{},
+			expected: "truncated ClientKeyExchange",
+		},
+		{
+			name:     "empty point",
+			raw:      []byte{0x00},
+			expected: "ECDH public point cannot be empty",
+		},
+		{
+			name:     "length mismatch",
+			raw:      []byte{0x02, 0x04},
+			expected: "ClientKeyExchange point length 2 does not match body length 1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := UnmarshalClientKeyExchange(tc.raw)
+
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if err.Error() != tc.expected {
+				t.Errorf("Expected error message %q, got %q", tc.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestMarshalEncryptedPreMasterSecret_ValidInput(t *testing.T) {
+	raw, err := MarshalEncryptedPreMasterSecret(&spec.ClientKeyExchange{EncryptedPreMasterSecret: []byte{0xaa, 0xbb, 0xcc}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []byte{0x00, 0x03, 0xaa, 0xbb, 0xcc}
+	if !bytes.Equal(raw, expected) {
+		t.Fatalf("Expected %x, got %x", expected, raw)
+	}
+
+	parsed, err := UnmarshalEncryptedPreMasterSecret(raw)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Equal(parsed.EncryptedPreMasterSecret, []byte{0xaa, 0xbb, 0xcc}) {
+		t.Errorf("Unexpected encrypted pre-master secret: %x", parsed.EncryptedPreMasterSecret)
+	}
+}
+
+func TestMarshalEncryptedPreMasterSecret_Empty(t *testing.T) {
+	_, err := MarshalEncryptedPreMasterSecret(&spec.ClientKeyExchange{})
+
+	if err == nil || err.Error() != "encrypted pre-master secret cannot be empty" {
+		t.Errorf("Expected empty secret error, got %v", err)
+	}
+}
+
+func TestUnmarshalEncryptedPreMasterSecret_InvalidInput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "truncated length",
+			raw:      []byte{0x00},
+			expected: "truncated ClientKeyExchange",
+		},
+		{
+			name:     "empty secret",
+			raw:      []byte{0x00, 0x00},
+			expected: "encrypted pre-master secret cannot be empty",
+		},
+		{
+			name:     "length mismatch",
+			raw:      []byte{0x00, 0x03, 0xaa},
+			expected: "encrypted pre-master secret length 3 does not match body length 1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := UnmarshalEncryptedPreMasterSecret(tc.raw)
+
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if err.Error() != tc.expected {
+				t.Errorf("Expected error message %q, got %q", tc.expected, err.Error())
+			}
+		})
+	}
+}
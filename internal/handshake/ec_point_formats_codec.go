@@ -0,0 +1,43 @@
+package handshake
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+func MarshalECPointFormats(ecPointFormatList *spec.ECPointFormatList) ([]byte, error) {
+	if len(ecPointFormatList.Formats) == 0 {
+		return nil, errors.New("EC point formats cannot be empty")
+	}
+
+	values := make([]byte, 0, len(ecPointFormatList.Formats))
+	for _, format := range ecPointFormatList.Formats {
+		values = append(values, byte(format))
+	}
+
+	return utils.NewOpaqueVector8(values)
+}
+
+func UnmarshalECPointFormats(raw []byte) (*spec.ECPointFormatList, error) {
+	if len(raw) < 1 {
+		return nil, errors.New("truncated EC point formats")
+	}
+
+	listLen := int(raw[0])
+	if listLen == 0 {
+		return nil, errors.New("EC point formats cannot be empty")
+	}
+	if len(raw)-1 != listLen {
+		return nil, fmt.Errorf("EC point formats length %d does not match extension length %d", listLen, len(raw)-1)
+	}
+
+	formats := make([]spec.ECPointFormat, listLen)
+	for i := range formats {
+		formats[i] = spec.ECPointFormat(raw[1+i])
+	}
+
+	return &spec.ECPointFormatList{Formats: formats}, nil
+}
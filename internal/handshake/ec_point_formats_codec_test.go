@@ -0,0 +1,79 @@
+package handshake
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestMarshalECPointFormats_ValidInput(t *testing.T) {
+	ecPointFormatList := &spec.ECPointFormatList{
+		Formats: []spec.ECPointFormat{spec.ECPointFormatUncompressed},
+	}
+
+	raw, err := MarshalECPointFormats(ecPointFormatList)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []byte{0x01, 0x00}
+	if !bytes.Equal(raw, expected) {
+		t.Errorf("Expected %x, got %x", expected, raw)
+	}
+}
+
+func TestMarshalECPointFormats_Empty(t *testing.T) {
+	_, err := MarshalECPointFormats(&spec.ECPointFormatList{})
+
+	if err == nil || err.Error() != "EC point formats cannot be empty" {
+		t.Errorf("Expected empty point formats error, got %v", err)
+	}
+}
+
+func TestUnmarshalECPointFormats_ValidInput(t *testing.T) {
+	ecPointFormatList, err := UnmarshalECPointFormats([]byte{0x02, 0x01, 0x00})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []spec.ECPointFormat{spec.ECPointFormatAnsiX962CompressedPrime, spec.ECPointFormatUncompressed}
+	if !slices.Equal(ecPointFormatList.Formats, expected) {
+		t.Errorf("Expected %v, got %v", expected, ecPointFormatList.Formats)
+	}
+}
+
+func TestUnmarshalECPointFormats_InvalidInput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "missing length",
+			raw:      []byte{},
+			expected: "truncated EC point formats",
+		},
+		{
+			name:     "empty list",
+			raw:      []byte{0x00},
+			expected: "EC point formats cannot be empty",
+		},
+		{
+			name:     "length mismatch",
+			raw:      []byte{0x02, 0x00},
+			expected: "EC point formats length 2 does not match extension length 1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := UnmarshalECPointFormats(tc.raw)
+
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("Expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
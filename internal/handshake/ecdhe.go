@@ -0,0 +1,74 @@
+package handshake
+
+import (
+	"crypto/ecdh"
+	"fmt"
+	"io"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/spec"
+)
+
+func curveForGroup(group spec.SupportedGroup) (ecdh.Curve, error) {
+	switch group {
+	case spec.SupportedGroupsSecp256r1:
+		return ecdh.P256(), nil
+	case spec.SupportedGroupsSecp384r1:
+		return ecdh.P384(), nil
+	case spec.SupportedGroupsSecp521r1:
+		return ecdh.P521(), nil
+	case spec.SupportedGroupsX25519:
+		return ecdh.X25519(), nil
+	default:
+		return nil, fmt.Errorf("unsupported group %#04x", uint16(group))
+	}
+}
+
+func GenerateECDHEKey(rand io.Reader, group spec.SupportedGroup) (*ecdh.PrivateKey, error) {
+	curve, err := curveForGroup(group)
+	if err != nil {
+		return nil, err
+	}
+
+	return curve.GenerateKey(rand)
+}
+
+// ECDHEPreMasterSecret computes the shared secret with the peer's public point.
+// Only uncompressed points on the curve of privateKey are accepted; the
+// identity point, points off the curve and low-order X25519 points are rejected.
+func ECDHEPreMasterSecret(privateKey *ecdh.PrivateKey, peerPublicKey []byte) ([]byte, error) {
+	publicKey, err := privateKey.Curve().NewPublicKey(peerPublicKey)
+	if err != nil {
+		return nil, alert.Errorf(spec.AlertDescriptionIllegalParameter, "invalid ECDH public point: %w", err)
+	}
+
+	preMasterSecret, err := privateKey.ECDH(publicKey)
+	if err != nil {
+		// X25519 fails here for low-order points, which yield an all-zero secret
+		return nil, alert.Errorf(spec.AlertDescriptionIllegalParameter, "invalid ECDH public point: %w", err)
+	}
+
+	return preMasterSecret, nil
+}
+
+// NewClientKeyExchange generates the client's ephemeral key on the group chosen
+// by the server and returns the message to send together with the pre-master secret.
+func NewClientKeyExchange(rand io.Reader, serverParams *spec.ServerECDHParams) (*spec.ClientKeyExchange, []byte, error) {
+	if serverParams.CurveType != spec.ECCurveTypeNamedCurve {
+		return nil, nil, fmt.Errorf("unsupported EC curve type %d", serverParams.CurveType)
+	}
+
+	privateKey, err := GenerateECDHEKey(rand, serverParams.NamedCurve)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	preMasterSecret, err := ECDHEPreMasterSecret(privateKey, serverParams.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &spec.ClientKeyExchange{
+		PublicKey: privateKey.PublicKey().Bytes(),
+	}, preMasterSecret, nil
+}
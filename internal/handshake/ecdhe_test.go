@@ -0,0 +1,123 @@
+package handshake
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestECDHE_BothSidesAgree(t *testing.T) {
+	testCases := []struct {
+		name         string
+		group        spec.SupportedGroup
+		secretLength int
+	}{
+		{name: "secp256r1", group: spec.SupportedGroupsSecp256r1, secretLength: 32},
+		{name: "secp384r1", group: spec.SupportedGroupsSecp384r1, secretLength: 48},
+		{name: "secp521r1", group: spec.SupportedGroupsSecp521r1, secretLength: 66},
+		{name: "x25519", group: spec.SupportedGroupsX25519, secretLength: 32},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			serverKey, err := GenerateECDHEKey(rand.Reader, tc.group)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			serverParams := &spec.ServerECDHParams{
+				CurveType:  spec.ECCurveTypeNamedCurve,
+				NamedCurve: tc.group,
+				PublicKey:  serverKey.PublicKey().Bytes(),
+			}
+
+			clientKeyExchange, clientPreMasterSecret, err := NewClientKeyExchange(rand.Reader, serverParams)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			parsed, err := UnmarshalClientKeyExchange(MarshalClientKeyExchange(clientKeyExchange))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			serverPreMasterSecret, err := ECDHEPreMasterSecret(serverKey, parsed.PublicKey)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if len(clientPreMasterSecret) != tc.secretLength {
+				t.Errorf("Expected %d-byte pre-master secret, got %d", tc.secretLength, len(clientPreMasterSecret))
+			}
+			if !bytes.Equal(clientPreMasterSecret, serverPreMasterSecret) {
+				t.Error("Pre-master secrets do not match")
+			}
+		})
+	}
+}
+
+func TestECDHEPreMasterSecret_InvalidPoint(t *testing.T) {
+	serverKey, err := GenerateECDHEKey(rand.Reader, spec.SupportedGroupsSecp256r1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	offCurve := serverKey.PublicKey().Bytes()
+	offCurve[len(offCurve)-1] ^= 0x01
+
+	testCases := []struct {
+		name  string
+		point []byte
+	}{
+		{"off curve", offCurve},
+		{"identity", []byte{0x00}},
+		{"compressed", append([]byte{0x02}, offCurve[1:33]...)},
+		{"wrong length", offCurve[:40]},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ECDHEPreMasterSecret(serverKey, tc.point)
+
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if !strings.HasPrefix(err.Error(), "invalid ECDH public point") {
+				t.Errorf("Unexpected error message %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestECDHEPreMasterSecret_LowOrderX25519Point(t *testing.T) {
+	serverKey, err := GenerateECDHEKey(rand.Reader, spec.SupportedGroupsX25519)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	_, err = ECDHEPreMasterSecret(serverKey, make([]byte, 32))
+
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if !strings.HasPrefix(err.Error(), "invalid ECDH public point") {
+		t.Errorf("Unexpected error message %q", err.Error())
+	}
+}
+
+func TestNewClientKeyExchange_UnsupportedGroup(t *testing.T) {
+	serverParams := &spec.ServerECDHParams{
+		CurveType:  spec.ECCurveTypeNamedCurve,
+		NamedCurve: spec.SupportedGroup(0x001e),
+		PublicKey:  []byte{0x01},
+	}
+
+	_, _, err := NewClientKeyExchange(rand.Reader, serverParams)
+
+	if err == nil {
+		t.Fatal("Expected error for unsupported group")
+	}
+	if err.Error() != "unsupported group 0x001e" {
+		t.Errorf("Unexpected error message %q", err.Error())
+	}
+}
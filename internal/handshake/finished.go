@@ -0,0 +1,55 @@
+package handshake
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/internal/prf"
+	"github.com/piligrimm/tls/spec"
+)
+
+func MarshalFinished(finished *spec.Finished) []byte {
+	return append([]byte(nil), finished.VerifyData...)
+}
+
+func UnmarshalFinished(raw []byte) (*spec.Finished, error) {
+	if len(raw) != prf.VerifyDataLength {
+		return nil, fmt.Errorf("verify_data of Finished must contain %d bytes, got %d", prf.VerifyDataLength, len(raw))
+	}
+
+	return &spec.Finished{VerifyData: append([]byte(nil), raw...)}, nil
+}
+
+// NewFinished computes verify_data over the transcript up to, but not
+// including, the Finished message itself.
+func NewFinished(params *ciphersuite.Parameters, masterSecret []byte, label string, transcript *Transcript) (*spec.Finished, error) {
+	handshakeHash, err := transcript.Sum()
+	if err != nil {
+		return nil, err
+	}
+
+	return &spec.Finished{
+		VerifyData: prf.VerifyData(params, masterSecret, label, handshakeHash),
+	}, nil
+}
+
+func VerifyFinished(
+	finished *spec.Finished,
+	params *ciphersuite.Parameters,
+	masterSecret []byte,
+	label string,
+	transcript *Transcript,
+) error {
+	expected, err := NewFinished(params, masterSecret, label, transcript)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(finished.VerifyData, expected.VerifyData) != 1 {
+		return alert.New(spec.AlertDescriptionDecryptError, "verify_data of Finished does not match")
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package handshake
+
+import (
+	"crypto"
+	"encoding/hex"
+	"testing"
+
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/internal/prf"
+	"github.com/piligrimm/tls/spec"
+)
+
+func newTestFinishedInputs(t *testing.T) (*ciphersuite.Parameters, []byte, *Transcript) {
+	t.Helper()
+
+	params, err := ciphersuite.Lookup(spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	masterSecret, _ := hex.DecodeString("632a49039d6452a6657e0daeb9fce7388d487928caec8f01f1464ba55f43c2690f9d01d233f388b45bd4bcd3490f1786")
+
+	transcript := NewTranscript()
+	transcript.Write([]byte{0x01, 0x00, 0x00, 0x01, 0xaa})
+	transcript.Write([]byte{0x02, 0x00, 0x00, 0x01, 0xbb})
+	if err := transcript.SetHash(crypto.SHA256); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return params, masterSecret, transcript
+}
+
+func TestNewFinished_VerifyData(t *testing.T) {
+	params, masterSecret, transcript := newTestFinishedInputs(t)
+
+	testCases := []struct {
+		label    string
+		expected string
+	}{
+		{prf.ClientFinishedLabel, "9459df78a573e74633a5d76a"},
+		{prf.ServerFinishedLabel, "afdb5ee789740cea697d3f1e"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.label, func(t *testing.T) {
+			finished, err := NewFinished(params, masterSecret, tc.label, transcript)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if hex.EncodeToString(finished.VerifyData) != tc.expected {
+				t.Errorf("Expected %s, got %x", tc.expected, finished.VerifyData)
+			}
+		})
+	}
+}
+
+func TestVerifyFinished(t *testing.T) {
+	params, masterSecret, transcript := newTestFinishedInputs(t)
+	finished, err := NewFinished(params, masterSecret, prf.ClientFinishedLabel, transcript)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	parsed, err := UnmarshalFinished(MarshalFinished(finished))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := VerifyFinished(parsed, params, masterSecret, prf.ClientFinishedLabel, transcript); err != nil {
+		t.Errorf("Expected valid Finished, got %v", err)
+	}
+
+	if err := VerifyFinished(parsed, params, masterSecret, prf.ServerFinishedLabel, transcript); err == nil {
+		t.Error("Expected error for verify_data computed with the other label")
+	}
+
+	parsed.VerifyData[0] ^= 0x01
+	if err := VerifyFinished(parsed, params, masterSecret, prf.ClientFinishedLabel, transcript); err == nil {
+		t.Error("Expected error for tampered verify_data")
+	}
+}
+
+func TestUnmarshalFinished_InvalidLength(t *testing.T) {
+	_, err := UnmarshalFinished(make([]byte, 11))
+
+	if err == nil {
+		t.Fatal("Expected error for short verify_data")
+	}
+	expectedMessage := "verify_data of Finished must contain 12 bytes, got 11"
+	if err.Error() != expectedMessage {
+		t.Errorf("Expected error message %q, got %q", expectedMessage, err.Error())
+	}
+}
@@ -0,0 +1,80 @@
+package handshake
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+const (
+	HeaderLength = 4
+
+	// MaxMessageLength bounds the body of a single handshake message so that a
+	// peer cannot make us buffer up to the 16 MiB a uint24 length allows.
+	MaxMessageLength = 1 << 18
+)
+
+func MarshalHandshake(message *spec.Handshake) []byte {
+	if len(message.Body) > MaxMessageLength {
+		panic(fmt.Sprintf("handshake message body cannot be longer than %d bytes", MaxMessageLength))
+	}
+
+	payload := make([]byte, 0, HeaderLength+len(message.Body))
+	payload = append(payload, byte(message.MsgType))
+	payload = utils.AppendUint24(payload, len(message.Body))
+	payload = append(payload, message.Body...)
+
+	return payload
+}
+
+// Framer turns a stream of handshake record fragments into handshake messages.
+// A message may be split across several fragments and a fragment may hold
+// several messages.
+type Framer struct {
+	buf []byte
+}
+
+func NewFramer() *Framer {
+	return &Framer{}
+}
+
+func (f *Framer) Write(fragment []byte) {
+	f.buf = append(f.buf, fragment...)
+}
+
+// Buffered reports whether a partial message is waiting for more fragments.
+func (f *Framer) Buffered() bool {
+	return len(f.buf) > 0
+}
+
+// Next returns the next complete message, or false if more fragments are needed.
+func (f *Framer) Next() (*spec.Handshake, bool, error) {
+	if len(f.buf) < HeaderLength {
+		return nil, false, nil
+	}
+
+	msgType := spec.HandshakeType(f.buf[0])
+	if !slices.Contains(spec.HandshakeTypes(), msgType) {
+		return nil, false, alert.Errorf(spec.AlertDescriptionUnexpectedMessage, "unknown handshake message type: %v", msgType)
+	}
+
+	length := utils.ReadUint24(f.buf[1:4])
+	if length > MaxMessageLength {
+		return nil, false, alert.Errorf(spec.AlertDescriptionDecodeError, "handshake message %v of %d bytes exceeds %d", msgType, length, MaxMessageLength)
+	}
+
+	if len(f.buf) < HeaderLength+length {
+		return nil, false, nil
+	}
+
+	body := append([]byte(nil), f.buf[HeaderLength:HeaderLength+length]...)
+	f.buf = f.buf[HeaderLength+length:]
+	if len(f.buf) == 0 {
+		f.buf = nil
+	}
+
+	return &spec.Handshake{MsgType: msgType, Body: body}, true, nil
+}
@@ -0,0 +1,120 @@
+package handshake
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestMarshalHandshake_ValidInput(t *testing.T) {
+	raw := MarshalHandshake(&spec.Handshake{
+		MsgType: spec.HandshakeTypeFinished,
+		Body:    []byte{0x01, 0x02, 0x03},
+	})
+
+	expected := []byte{0x14, 0x00, 0x00, 0x03, 0x01, 0x02, 0x03}
+	if !bytes.Equal(raw, expected) {
+		t.Errorf("Expected %x, got %x", expected, raw)
+	}
+}
+
+func TestFramer_MessageSplitAcrossFragments(t *testing.T) {
+	body := bytes.Repeat([]byte{0xab}, 100)
+	raw := MarshalHandshake(&spec.Handshake{MsgType: spec.HandshakeTypeCertificate, Body: body})
+	framer := NewFramer()
+
+	for off := 0; off < len(raw); off += 7 {
+		end := min(off+7, len(raw))
+		framer.Write(raw[off:end])
+
+		message, ok, err := framer.Next()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if end < len(raw) {
+			if ok {
+				t.Fatalf("Expected incomplete message after %d bytes", end)
+			}
+			if !framer.Buffered() {
+				t.Fatalf("Expected partial message to be buffered after %d bytes", end)
+			}
+			continue
+		}
+
+		if !ok {
+			t.Fatal("Expected complete message")
+		}
+		if message.MsgType != spec.HandshakeTypeCertificate {
+			t.Errorf("Expected message type %v, got %v", spec.HandshakeTypeCertificate, message.MsgType)
+		}
+		if !bytes.Equal(message.Body, body) {
+			t.Errorf("Body mismatch")
+		}
+	}
+
+	if framer.Buffered() {
+		t.Error("Expected no buffered bytes")
+	}
+}
+
+func TestFramer_SeveralMessagesInOneFragment(t *testing.T) {
+	var fragment []byte
+	fragment = append(fragment, MarshalHandshake(&spec.Handshake{MsgType: spec.HandshakeTypeServerHello, Body: []byte{0x03, 0x03}})...)
+	fragment = append(fragment, MarshalHandshake(&spec.Handshake{MsgType: spec.HandshakeTypeServerHelloDone})...)
+	framer := NewFramer()
+	framer.Write(fragment)
+
+	expectedTypes := []spec.HandshakeType{spec.HandshakeTypeServerHello, spec.HandshakeTypeServerHelloDone}
+	for _, expectedType := range expectedTypes {
+		message, ok, err := framer.Next()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !ok {
+			t.Fatalf("Expected %v message", expectedType)
+		}
+		if message.MsgType != expectedType {
+			t.Errorf("Expected message type %v, got %v", expectedType, message.MsgType)
+		}
+	}
+
+	if _, ok, _ := framer.Next(); ok {
+		t.Error("Expected no more messages")
+	}
+}
+
+func TestFramer_InvalidHeader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "hello verify request",
+			raw:      []byte{0x03, 0x00, 0x00, 0x00},
+			expected: "unknown handshake message type: HandshakeType(3)",
+		},
+		{
+			name:     "message too long",
+			raw:      []byte{0x0b, 0x04, 0x00, 0x01},
+			expected: "handshake message Certificate of 262145 bytes exceeds 262144",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			framer := NewFramer()
+			framer.Write(tc.raw)
+
+			_, _, err := framer.Next()
+
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if err.Error() != tc.expected {
+				t.Errorf("Expected error message %q, got %q", tc.expected, err.Error())
+			}
+		})
+	}
+}
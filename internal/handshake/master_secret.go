@@ -0,0 +1,29 @@
+package handshake
+
+import (
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/internal/prf"
+)
+
+// MasterSecret derives the master secret once ClientKeyExchange has been
+// written to the transcript. With extended set it is bound to the session hash
+// (RFC 7627), otherwise to the randoms as in RFC 5246.
+func MasterSecret(
+	params *ciphersuite.Parameters,
+	preMasterSecret []byte,
+	clientRandom []byte,
+	serverRandom []byte,
+	transcript *Transcript,
+	extended bool,
+) ([]byte, error) {
+	if !extended {
+		return prf.MasterSecret(params, preMasterSecret, clientRandom, serverRandom), nil
+	}
+
+	sessionHash, err := transcript.Sum()
+	if err != nil {
+		return nil, err
+	}
+
+	return prf.ExtendedMasterSecret(params, preMasterSecret, sessionHash), nil
+}
@@ -0,0 +1,67 @@
+package handshake
+
+import (
+	"bytes"
+	"crypto"
+	"testing"
+
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/internal/prf"
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestMasterSecret(t *testing.T) {
+	params, err := ciphersuite.Lookup(spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	preMasterSecret := bytes.Repeat([]byte{0x03}, 32)
+	clientRandom := bytes.Repeat([]byte{0x01}, 32)
+	serverRandom := bytes.Repeat([]byte{0x02}, 32)
+	transcript := NewTranscript()
+	transcript.Write([]byte{0x10, 0x00, 0x00, 0x01, 0xcc})
+	if err := transcript.SetHash(crypto.SHA256); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sessionHash, _ := transcript.Sum()
+
+	testCases := []struct {
+		name     string
+		extended bool
+		expected []byte
+	}{
+		{
+			name:     "from randoms",
+			extended: false,
+			expected: prf.MasterSecret(params, preMasterSecret, clientRandom, serverRandom),
+		},
+		{
+			name:     "from session hash",
+			extended: true,
+			expected: prf.ExtendedMasterSecret(params, preMasterSecret, sessionHash),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			masterSecret, err := MasterSecret(params, preMasterSecret, clientRandom, serverRandom, transcript, tc.extended)
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !bytes.Equal(masterSecret, tc.expected) {
+				t.Errorf("Expected %x, got %x", tc.expected, masterSecret)
+			}
+		})
+	}
+}
+
+func TestMasterSecret_HashNotSet(t *testing.T) {
+	params, _ := ciphersuite.Lookup(spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256)
+
+	_, err := MasterSecret(params, nil, nil, nil, NewTranscript(), true)
+
+	if err == nil || err.Error() != "transcript hash is not set" {
+		t.Errorf("Expected transcript error, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package handshake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+func MarshalNewSessionTicket(newSessionTicket *spec.NewSessionTicket) ([]byte, error) {
+	if len(newSessionTicket.Ticket) > math.MaxUint16 {
+		return nil, fmt.Errorf("session ticket cannot be longer than %d bytes", math.MaxUint16)
+	}
+
+	payload := make([]byte, 0, 4+2+len(newSessionTicket.Ticket))
+	payload = binary.BigEndian.AppendUint32(payload, newSessionTicket.LifetimeHint)
+	payload = binary.BigEndian.AppendUint16(payload, utils.CastUint16OrPanic(len(newSessionTicket.Ticket)))
+	payload = append(payload, newSessionTicket.Ticket...)
+
+	return payload, nil
+}
+
+func UnmarshalNewSessionTicket(raw []byte) (*spec.NewSessionTicket, error) {
+	if len(raw) < 6 {
+		return nil, errors.New("truncated NewSessionTicket")
+	}
+
+	ticketLen := int(binary.BigEndian.Uint16(raw[4:6]))
+	if len(raw)-6 != ticketLen {
+		return nil, fmt.Errorf("NewSessionTicket ticket length %d does not match body length %d", ticketLen, len(raw)-6)
+	}
+
+	return &spec.NewSessionTicket{
+		LifetimeHint: binary.BigEndian.Uint32(raw[:4]),
+		Ticket:       append([]byte(nil), raw[6:]...),
+	}, nil
+}
@@ -0,0 +1,73 @@
+package handshake
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestMarshalNewSessionTicket_ValidInput(t *testing.T) {
+	newSessionTicket := &spec.NewSessionTicket{LifetimeHint: 7200, Ticket: []byte{0xaa, 0xbb, 0xcc}}
+
+	raw, err := MarshalNewSessionTicket(newSessionTicket)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []byte{0x00, 0x00, 0x1c, 0x20, 0x00, 0x03, 0xaa, 0xbb, 0xcc}
+	if !bytes.Equal(raw, expected) {
+		t.Errorf("Expected %x, got %x", expected, raw)
+	}
+}
+
+func TestMarshalNewSessionTicket_TicketTooLong(t *testing.T) {
+	_, err := MarshalNewSessionTicket(&spec.NewSessionTicket{Ticket: make([]byte, 65536)})
+
+	if err == nil || err.Error() != "session ticket cannot be longer than 65535 bytes" {
+		t.Errorf("Expected ticket length error, got %v", err)
+	}
+}
+
+func TestUnmarshalNewSessionTicket_ValidInput(t *testing.T) {
+	newSessionTicket, err := UnmarshalNewSessionTicket([]byte{0x00, 0x00, 0x00, 0x3c, 0x00, 0x02, 0x01, 0x02})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if newSessionTicket.LifetimeHint != 60 {
+		t.Errorf("Expected lifetime hint 60, got %d", newSessionTicket.LifetimeHint)
+	}
+	if !bytes.Equal(newSessionTicket.Ticket, []byte{0x01, 0x02}) {
+		t.Errorf("Expected ticket 0102, got %x", newSessionTicket.Ticket)
+	}
+}
+
+func TestUnmarshalNewSessionTicket_InvalidInput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "truncated header",
+			raw:      []byte{0x00, 0x00, 0x00, 0x3c, 0x00},
+			expected: "truncated NewSessionTicket",
+		},
+		{
+			name:     "ticket length mismatch",
+			raw:      []byte{0x00, 0x00, 0x00, 0x3c, 0x00, 0x02, 0x01},
+			expected: "NewSessionTicket ticket length 2 does not match body length 1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := UnmarshalNewSessionTicket(tc.raw)
+
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("Expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
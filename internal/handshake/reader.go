@@ -0,0 +1,89 @@
+package handshake
+
+import (
+	"bytes"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/internal/record"
+	"github.com/piligrimm/tls/spec"
+)
+
+var changeCipherSpecMessage = []byte{0x01}
+
+// Reader reads handshake messages and ChangeCipherSpec off a record stream.
+// Warning alerts are skipped, any other record type during the handshake is an
+// error.
+type Reader struct {
+	records *record.Reader
+	framer  *Framer
+}
+
+func NewReader(records *record.Reader) *Reader {
+	return &Reader{
+		records: records,
+		framer:  NewFramer(),
+	}
+}
+
+func (r *Reader) ReadMessage() (*spec.Handshake, error) {
+	for {
+		message, ok, err := r.framer.Next()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return message, nil
+		}
+
+		rec, err := r.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		if rec.ContentType != spec.ContentTypeHandshake {
+			return nil, alert.Errorf(spec.AlertDescriptionUnexpectedMessage, "unexpected %v record, expected Handshake", rec.ContentType)
+		}
+		r.framer.Write(rec.Fragment)
+	}
+}
+
+// ReadChangeCipherSpec reads a ChangeCipherSpec record, which may only arrive
+// on a handshake message boundary.
+func (r *Reader) ReadChangeCipherSpec() error {
+	if r.framer.Buffered() {
+		return alert.New(spec.AlertDescriptionUnexpectedMessage, "ChangeCipherSpec received in the middle of a handshake message")
+	}
+
+	rec, err := r.readRecord()
+	if err != nil {
+		return err
+	}
+	if rec.ContentType != spec.ContentTypeChangeCipherSpec {
+		return alert.Errorf(spec.AlertDescriptionUnexpectedMessage, "unexpected %v record, expected ChangeCipherSpec", rec.ContentType)
+	}
+	if !bytes.Equal(rec.Fragment, changeCipherSpecMessage) {
+		return alert.New(spec.AlertDescriptionDecodeError, "malformed ChangeCipherSpec message")
+	}
+
+	return nil
+}
+
+// readRecord returns the next record that is not an ignorable warning alert.
+func (r *Reader) readRecord() (*spec.TLSPlaintext, error) {
+	for {
+		rec, err := r.records.ReadRecord()
+		if err != nil {
+			return nil, err
+		}
+		if rec.ContentType != spec.ContentTypeAlert {
+			return rec, nil
+		}
+
+		if err := alert.Received(rec.Fragment); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func WriteChangeCipherSpec(writer *record.Writer) error {
+	return writer.WriteRecords(spec.ContentTypeChangeCipherSpec, changeCipherSpecMessage)
+}
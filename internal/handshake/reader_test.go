@@ -0,0 +1,136 @@
+package handshake
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/internal/record"
+	"github.com/piligrimm/tls/spec"
+)
+
+func newTestReader(t *testing.T, records ...*spec.TLSPlaintext) *Reader {
+	t.Helper()
+
+	var stream bytes.Buffer
+	for _, rec := range records {
+		stream.Write(record.MarshalRecord(rec))
+	}
+
+	return NewReader(record.NewReader(&stream))
+}
+
+func TestReader_MessagesAndChangeCipherSpec(t *testing.T) {
+	serverHelloDone := MarshalHandshake(&spec.Handshake{MsgType: spec.HandshakeTypeServerHelloDone})
+	reader := newTestReader(t,
+		&spec.TLSPlaintext{ContentType: spec.ContentTypeHandshake, Version: spec.Tls12ProtocolVersion(), Fragment: serverHelloDone[:2]},
+		&spec.TLSPlaintext{ContentType: spec.ContentTypeHandshake, Version: spec.Tls12ProtocolVersion(), Fragment: serverHelloDone[2:]},
+		&spec.TLSPlaintext{ContentType: spec.ContentTypeChangeCipherSpec, Version: spec.Tls12ProtocolVersion(), Fragment: []byte{0x01}},
+	)
+
+	message, err := reader.ReadMessage()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if message.MsgType != spec.HandshakeTypeServerHelloDone {
+		t.Errorf("Expected %v, got %v", spec.HandshakeTypeServerHelloDone, message.MsgType)
+	}
+
+	if err := reader.ReadChangeCipherSpec(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestReader_UnexpectedRecords(t *testing.T) {
+	version := spec.Tls12ProtocolVersion()
+
+	t.Run("application data during handshake", func(t *testing.T) {
+		reader := newTestReader(t, &spec.TLSPlaintext{ContentType: spec.ContentTypeApplicationData, Version: version, Fragment: []byte{0x01}})
+
+		_, err := reader.ReadMessage()
+
+		if err == nil || err.Error() != "unexpected ApplicationData record, expected Handshake" {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("change cipher spec inside a message", func(t *testing.T) {
+		reader := newTestReader(t,
+			&spec.TLSPlaintext{ContentType: spec.ContentTypeHandshake, Version: version, Fragment: []byte{0x14, 0x00}},
+			&spec.TLSPlaintext{ContentType: spec.ContentTypeChangeCipherSpec, Version: version, Fragment: []byte{0x01}},
+		)
+		if _, err := reader.ReadMessage(); err == nil {
+			t.Fatal("Expected error for interleaved ChangeCipherSpec")
+		}
+
+		err := reader.ReadChangeCipherSpec()
+
+		if err == nil || err.Error() != "ChangeCipherSpec received in the middle of a handshake message" {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("malformed change cipher spec", func(t *testing.T) {
+		reader := newTestReader(t, &spec.TLSPlaintext{ContentType: spec.ContentTypeChangeCipherSpec, Version: version, Fragment: []byte{0x02}})
+
+		err := reader.ReadChangeCipherSpec()
+
+		if err == nil || err.Error() != "malformed ChangeCipherSpec message" {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestReader_Alerts(t *testing.T) {
+	version := spec.Tls12ProtocolVersion()
+	serverHelloDone := MarshalHandshake(&spec.Handshake{MsgType: spec.HandshakeTypeServerHelloDone})
+
+	t.Run("warning is skipped", func(t *testing.T) {
+		reader := newTestReader(t,
+			&spec.TLSPlaintext{ContentType: spec.ContentTypeAlert, Version: version, Fragment: []byte{0x01, 0x5a}},
+			&spec.TLSPlaintext{ContentType: spec.ContentTypeHandshake, Version: version, Fragment: serverHelloDone},
+		)
+
+		message, err := reader.ReadMessage()
+
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if message.MsgType != spec.HandshakeTypeServerHelloDone {
+			t.Errorf("Expected %v, got %v", spec.HandshakeTypeServerHelloDone, message.MsgType)
+		}
+	})
+
+	t.Run("fatal alert ends the handshake", func(t *testing.T) {
+		reader := newTestReader(t, &spec.TLSPlaintext{ContentType: spec.ContentTypeAlert, Version: version, Fragment: []byte{0x02, 0x28}})
+
+		err := reader.ReadChangeCipherSpec()
+
+		var remoteErr *alert.RemoteError
+		if !errors.As(err, &remoteErr) || remoteErr.Description != spec.AlertDescriptionHandshakeFailure {
+			t.Errorf("Expected a HandshakeFailure alert, got %v", err)
+		}
+	})
+
+	t.Run("close_notify ends the handshake", func(t *testing.T) {
+		reader := newTestReader(t, &spec.TLSPlaintext{ContentType: spec.ContentTypeAlert, Version: version, Fragment: []byte{0x01, 0x00}})
+
+		_, err := reader.ReadMessage()
+
+		var remoteErr *alert.RemoteError
+		if !errors.As(err, &remoteErr) || remoteErr.Description != spec.AlertDescriptionCloseNotify {
+			t.Errorf("Expected a CloseNotify alert, got %v", err)
+		}
+	})
+
+	t.Run("malformed alert", func(t *testing.T) {
+		reader := newTestReader(t, &spec.TLSPlaintext{ContentType: spec.ContentTypeAlert, Version: version, Fragment: []byte{0x02}})
+
+		_, err := reader.ReadMessage()
+
+		if alert.DescriptionOf(err) != spec.AlertDescriptionDecodeError {
+			t.Errorf("Expected a decode error, got %v", err)
+		}
+	})
+}
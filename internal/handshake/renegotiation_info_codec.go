@@ -0,0 +1,38 @@
+package handshake
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+// MarshalRenegotiationInfo encodes the extension, an empty
+// renegotiated_connection still carries its length byte.
+func MarshalRenegotiationInfo(renegotiationInfo *spec.RenegotiationInfo) ([]byte, error) {
+	if len(renegotiationInfo.RenegotiatedConnection) > math.MaxUint8 {
+		return nil, fmt.Errorf("renegotiated_connection cannot be longer than %d bytes", math.MaxUint8)
+	}
+
+	payload := make([]byte, 0, 1+len(renegotiationInfo.RenegotiatedConnection))
+	payload = append(payload, byte(len(renegotiationInfo.RenegotiatedConnection)))
+	payload = append(payload, renegotiationInfo.RenegotiatedConnection...)
+
+	return payload, nil
+}
+
+func UnmarshalRenegotiationInfo(raw []byte) (*spec.RenegotiationInfo, error) {
+	if len(raw) < 1 {
+		return nil, errors.New("truncated renegotiation_info")
+	}
+
+	connectionLen := int(raw[0])
+	if len(raw)-1 != connectionLen {
+		return nil, fmt.Errorf("renegotiated_connection length %d does not match extension length %d", connectionLen, len(raw)-1)
+	}
+
+	return &spec.RenegotiationInfo{
+		RenegotiatedConnection: append([]byte{}, raw[1:]...),
+	}, nil
+}
@@ -0,0 +1,87 @@
+package handshake
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestMarshalRenegotiationInfo(t *testing.T) {
+	testCases := []struct {
+		name       string
+		connection []byte
+		expected   []byte
+	}{
+		{
+			name:     "initial handshake",
+			expected: []byte{0x00},
+		},
+		{
+			name:       "renegotiation",
+			connection: []byte{0x01, 0x02, 0x03},
+			expected:   []byte{0x03, 0x01, 0x02, 0x03},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := MarshalRenegotiationInfo(&spec.RenegotiationInfo{RenegotiatedConnection: tc.connection})
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !bytes.Equal(raw, tc.expected) {
+				t.Errorf("Expected %x, got %x", tc.expected, raw)
+			}
+		})
+	}
+}
+
+func TestMarshalRenegotiationInfo_TooLong(t *testing.T) {
+	_, err := MarshalRenegotiationInfo(&spec.RenegotiationInfo{RenegotiatedConnection: make([]byte, 256)})
+
+	if err == nil || err.Error() != "renegotiated_connection cannot be longer than 255 bytes" {
+		t.Errorf("Expected length error, got %v", err)
+	}
+}
+
+func TestUnmarshalRenegotiationInfo_ValidInput(t *testing.T) {
+	renegotiationInfo, err := UnmarshalRenegotiationInfo([]byte{0x02, 0xaa, 0xbb})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Equal(renegotiationInfo.RenegotiatedConnection, []byte{0xaa, 0xbb}) {
+		t.Errorf("Expected aabb, got %x", renegotiationInfo.RenegotiatedConnection)
+	}
+}
+
+func TestUnmarshalRenegotiationInfo_InvalidInput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "empty extension",
+			raw:      []byte{},
+			expected: "truncated renegotiation_info",
+		},
+		{
+			name:     "length mismatch",
+			raw:      []byte{0x02, 0xaa},
+			expected: "renegotiated_connection length 2 does not match extension length 1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := UnmarshalRenegotiationInfo(tc.raw)
+
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("Expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package handshake
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/subtle"
+	"fmt"
+	"io"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/spec"
+)
+
+// rsaPreMasterSecretLength is the length of client_version followed by 46
+// random bytes (RFC 5246, section 7.4.7.1).
+const rsaPreMasterSecretLength = 48
+
+// NewRSAClientKeyExchange generates a pre-master secret starting with
+// clientVersion, the version offered in the ClientHello, and encrypts it to the
+// server key. It returns the message to send together with the pre-master secret.
+func NewRSAClientKeyExchange(rand io.Reader, publicKey crypto.PublicKey, clientVersion spec.ProtocolVersion) (*spec.ClientKeyExchange, []byte, error) {
+	rsaKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("RSA key exchange requires an RSA key, got %T", publicKey)
+	}
+
+	preMasterSecret := make([]byte, rsaPreMasterSecretLength)
+	preMasterSecret[0], preMasterSecret[1] = clientVersion.Major, clientVersion.Minor
+	if _, err := io.ReadFull(rand, preMasterSecret[2:]); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate pre-master secret: %w", err)
+	}
+
+	encrypted, err := rsa.EncryptPKCS1v15(rand, rsaKey, preMasterSecret)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt pre-master secret: %w", err)
+	}
+
+	return &spec.ClientKeyExchange{EncryptedPreMasterSecret: encrypted}, preMasterSecret, nil
+}
+
+// RSAPreMasterSecret decrypts the pre-master secret the client encrypted to key.
+// A bad PKCS #1 v1.5 padding or a version other than clientVersion yields a
+// random secret instead, chosen in constant time and without an error, so the
+// handshake fails at the Finished message like for any wrong secret. Anything
+// else would be a Bleichenbacher oracle (RFC 5246, section 7.4.7.1). The same
+// holds for a Decrypter that ignores SessionKeyLen, such as a hardware key
+// reporting the bad padding as an error, though its timing is up to it.
+func RSAPreMasterSecret(rand io.Reader, key crypto.Decrypter, clientVersion spec.ProtocolVersion, encrypted []byte) ([]byte, error) {
+	rsaKey, ok := key.Public().(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("RSA key exchange requires an RSA key, got %T", key.Public())
+	}
+	// the length is public, rejecting it tells nothing about the plaintext
+	if len(encrypted) != rsaKey.Size() {
+		return nil, alert.Errorf(spec.AlertDescriptionDecodeError, "encrypted pre-master secret of %d bytes for a %d-byte RSA key", len(encrypted), rsaKey.Size())
+	}
+
+	fallback := make([]byte, rsaPreMasterSecretLength)
+	if _, err := io.ReadFull(rand, fallback); err != nil {
+		return nil, fmt.Errorf("failed to generate pre-master secret: %w", err)
+	}
+
+	// with SessionKeyLen set a bad padding already yields random bytes, a
+	// Decrypter that ignores it fails or returns another length instead
+	preMasterSecret, err := key.Decrypt(rand, encrypted, &rsa.PKCS1v15DecryptOptions{SessionKeyLen: rsaPreMasterSecretLength})
+	if err != nil || len(preMasterSecret) != rsaPreMasterSecretLength {
+		preMasterSecret = make([]byte, rsaPreMasterSecretLength)
+		copy(preMasterSecret, fallback)
+	}
+
+	versionMatches := subtle.ConstantTimeByteEq(preMasterSecret[0], clientVersion.Major) & subtle.ConstantTimeByteEq(preMasterSecret[1], clientVersion.Minor)
+	subtle.ConstantTimeCopy(1-versionMatches, preMasterSecret, fallback)
+
+	return preMasterSecret, nil
+}
@@ -0,0 +1,164 @@
+package handshake
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/spec"
+)
+
+// rawRSAEncrypt encrypts block to key without any padding, which lets tests
+// build ciphertexts that do not decrypt to a PKCS #1 v1.5 block.
+func rawRSAEncrypt(key *rsa.PublicKey, block []byte) []byte {
+	c := new(big.Int).Exp(new(big.Int).SetBytes(block), big.NewInt(int64(key.E)), key.N)
+	return c.FillBytes(make([]byte, key.Size()))
+}
+
+func TestRSAKeyExchange_BothSidesAgree(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	clientVersion := spec.Tls12ProtocolVersion()
+
+	clientKeyExchange, clientPreMasterSecret, err := NewRSAClientKeyExchange(rand.Reader, key.Public(), clientVersion)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	serverPreMasterSecret, err := RSAPreMasterSecret(rand.Reader, key, clientVersion, clientKeyExchange.EncryptedPreMasterSecret)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Equal(clientPreMasterSecret, serverPreMasterSecret) {
+		t.Error("Expected both sides to compute the same pre-master secret")
+	}
+	if len(clientPreMasterSecret) != 48 || clientPreMasterSecret[0] != 3 || clientPreMasterSecret[1] != 3 {
+		t.Errorf("Expected 48 bytes starting with the client version, got %x", clientPreMasterSecret)
+	}
+}
+
+func TestRSAPreMasterSecret_FallsBackToRandomSecret(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	clientVersion := spec.Tls12ProtocolVersion()
+	badPadding := make([]byte, key.Size())
+	badPadding[1] = 0x01
+	rand.Read(badPadding[2:])
+	wrongVersion := make([]byte, 48)
+	wrongVersion[0], wrongVersion[1] = 3, 1
+	rand.Read(wrongVersion[2:])
+	wrongLength := make([]byte, 47)
+	wrongLength[0], wrongLength[1] = 3, 3
+	testCases := []struct {
+		name      string
+		plaintext []byte
+		encrypted []byte
+	}{
+		{
+			name:      "bad padding",
+			encrypted: rawRSAEncrypt(&key.PublicKey, badPadding),
+		},
+		{
+			name:      "wrong version",
+			plaintext: wrongVersion,
+		},
+		{
+			name:      "wrong secret length",
+			plaintext: wrongLength,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			encrypted := tc.encrypted
+			if tc.plaintext != nil {
+				encrypted, _ = rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, tc.plaintext)
+			}
+
+			first, err := RSAPreMasterSecret(rand.Reader, key, clientVersion, encrypted)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			second, err := RSAPreMasterSecret(rand.Reader, key, clientVersion, encrypted)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if len(first) != 48 || len(second) != 48 {
+				t.Fatalf("Expected 48-byte secrets, got %d and %d bytes", len(first), len(second))
+			}
+			if bytes.Equal(first, second) {
+				t.Error("Expected a fresh random secret on every attempt")
+			}
+			if tc.plaintext != nil && bytes.Equal(first, tc.plaintext) {
+				t.Error("Expected the decrypted secret to be replaced")
+			}
+		})
+	}
+}
+
+// strictDecrypter decrypts like a hardware key that ignores SessionKeyLen and
+// reports a bad padding as an error.
+type strictDecrypter struct {
+	*rsa.PrivateKey
+}
+
+func (d strictDecrypter) Decrypt(rand io.Reader, ciphertext []byte, _ crypto.DecrypterOpts) ([]byte, error) {
+	return rsa.DecryptPKCS1v15(rand, d.PrivateKey, ciphertext)
+}
+
+func TestRSAPreMasterSecret_DecrypterWithoutSessionKeyLen(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	badPadding := make([]byte, key.Size())
+	badPadding[1] = 0x01
+	rand.Read(badPadding[2:])
+	wrongLength, _ := rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, []byte{3, 3})
+	testCases := []struct {
+		name      string
+		encrypted []byte
+	}{
+		{name: "bad padding", encrypted: rawRSAEncrypt(&key.PublicKey, badPadding)},
+		{name: "wrong secret length", encrypted: wrongLength},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			preMasterSecret, err := RSAPreMasterSecret(rand.Reader, strictDecrypter{key}, spec.Tls12ProtocolVersion(), tc.encrypted)
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(preMasterSecret) != 48 {
+				t.Errorf("Expected a 48-byte secret, got %d bytes", len(preMasterSecret))
+			}
+		})
+	}
+}
+
+func TestRSAPreMasterSecret_WrongCiphertextLength(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	_, err := RSAPreMasterSecret(rand.Reader, key, spec.Tls12ProtocolVersion(), make([]byte, 128))
+
+	if err == nil || err.Error() != "encrypted pre-master secret of 128 bytes for a 256-byte RSA key" {
+		t.Errorf("Expected ciphertext length error, got %v", err)
+	}
+	if alert.DescriptionOf(err) != spec.AlertDescriptionDecodeError {
+		t.Errorf("Expected DecodeError alert, got %v", alert.DescriptionOf(err))
+	}
+}
+
+func TestRSAKeyExchange_RequiresRSAKey(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	_, _, err := NewRSAClientKeyExchange(rand.Reader, key.Public(), spec.Tls12ProtocolVersion())
+
+	if err == nil || err.Error() != "RSA key exchange requires an RSA key, got *ecdsa.PublicKey" {
+		t.Errorf("Expected key type error, got %v", err)
+	}
+}
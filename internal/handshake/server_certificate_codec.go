@@ -1,13 +1,44 @@
-package main
+package handshake
 
 import (
 	"crypto/x509"
+	"errors"
 	"fmt"
 
+	"github.com/piligrimm/tls/internal/utils"
 	"github.com/piligrimm/tls/spec"
 )
 
-func unmarshalServerCertificate(raw []byte) (*spec.ServerCertificate, error) {
+func MarshalServerCertificate(serverCertificate *spec.ServerCertificate) ([]byte, error) {
+	if len(serverCertificate.Certificates) == 0 {
+		return nil, errors.New("server certificate chain cannot be empty")
+	}
+
+	certificatesLength := 0
+	for i, certificate := range serverCertificate.Certificates {
+		if len(certificate.Raw) == 0 {
+			return nil, fmt.Errorf("certificate %d has no DER encoding", i)
+		}
+		certificatesLength += 3 + len(certificate.Raw)
+	}
+
+	// the whole message has to fit into what the peer's framer accepts,
+	// which is well below the uint24 limit of the certificate_list itself
+	if 3+certificatesLength > MaxMessageLength {
+		return nil, fmt.Errorf("certificate chain of %d bytes exceeds %d", 3+certificatesLength, MaxMessageLength)
+	}
+
+	payload := make([]byte, 0, 3+certificatesLength)
+	payload = utils.AppendUint24(payload, certificatesLength)
+	for _, certificate := range serverCertificate.Certificates {
+		payload = utils.AppendUint24(payload, len(certificate.Raw))
+		payload = append(payload, certificate.Raw...)
+	}
+
+	return payload, nil
+}
+
+func UnmarshalServerCertificate(raw []byte) (*spec.ServerCertificate, error) {
 	checkOffset := func(required int) error {
 		if required < 0 || len(raw) < required+3 {
 			return fmt.Errorf("truncated ServerCertificate at offset %d", required)
@@ -0,0 +1,189 @@
+package handshake
+
+import (
+	"bytes"
+	"crypto/x509"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/piligrimm/tls/internal/record"
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestMarshalServerCertificate_ValidInput(t *testing.T) {
+	serverCertificate := &spec.ServerCertificate{
+		Certificates: []*x509.Certificate{
+			{Raw: []byte{0x30, 0x01, 0x02}},
+			{Raw: []byte{0x30, 0x03}},
+		},
+	}
+
+	raw, err := MarshalServerCertificate(serverCertificate)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []byte{
+		0x00, 0x00, 0x0b,
+		0x00, 0x00, 0x03, 0x30, 0x01, 0x02,
+		0x00, 0x00, 0x02, 0x30, 0x03,
+	}
+	if !bytes.Equal(raw, expected) {
+		t.Errorf("Expected %x, got %x", expected, raw)
+	}
+}
+
+func TestMarshalServerCertificate_InvalidChain(t *testing.T) {
+	testCases := []struct {
+		name         string
+		certificates []*x509.Certificate
+		expected     string
+	}{
+		{
+			name:         "empty chain",
+			certificates: nil,
+			expected:     "server certificate chain cannot be empty",
+		},
+		{
+			name:         "certificate without DER",
+			certificates: []*x509.Certificate{{Raw: []byte{0x30}}, {}},
+			expected:     "certificate 1 has no DER encoding",
+		},
+		{
+			name: "chain too long",
+			certificates: []*x509.Certificate{
+				{Raw: make([]byte, MaxMessageLength/2)},
+				{Raw: make([]byte, MaxMessageLength/2)},
+			},
+			expected: "certificate chain of 262153 bytes exceeds 262144",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := MarshalServerCertificate(&spec.ServerCertificate{Certificates: tc.certificates})
+
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if err.Error() != tc.expected {
+				t.Errorf("Expected error message %q, got %q", tc.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestUnmarshalServerCertificate(t *testing.T) {
+	raw, err := os.ReadFile("server_certificate_msg.bin")
+	if err != nil {
+		t.Fatalf("failed to read testdata: %v", err)
+	}
+
+	sc, err := UnmarshalServerCertificate(raw)
+	if err != nil {
+		t.Fatalf("UnmarshalServerCertificate error: %v", err)
+	}
+
+	if len(sc.Certificates) != 3 {
+		t.Fatalf("expected 3 certificates, got %d", len(sc.Certificates))
+	}
+
+	check := func(idx int, subj, issuer string, serialNumber *big.Int, notBefore, notAfter time.Time) {
+		cert := sc.Certificates[idx]
+		if cert.Subject.String() != subj {
+			t.Errorf("cert %d subject mismatch:\n got:  %s\n want: %s", idx, cert.Subject.String(), subj)
+		}
+		if cert.Issuer.String() != issuer {
+			t.Errorf("cert %d issuer mismatch:\n got:  %s\n want: %s", idx, cert.Issuer.String(), issuer)
+		}
+		gotSerial := cert.SerialNumber
+		if gotSerial.Cmp(serialNumber) != 0 {
+			t.Errorf("cert %d serial mismatch:\n got:  %s\n want: %s", idx, gotSerial, serialNumber)
+		}
+		if !cert.NotBefore.Equal(notBefore) {
+			t.Errorf("cert %d NotBefore mismatch:\n got:  %v\n want: %v", idx, cert.NotBefore, notBefore)
+		}
+		if !cert.NotAfter.Equal(notAfter) {
+			t.Errorf("cert %d NotAfter mismatch:\n got:  %v\n want: %v", idx, cert.NotAfter, notAfter)
+		}
+	}
+
+	mustBeBigInt := func(s string) *big.Int {
+		i, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			t.Fatalf("invalid big.Int literal: %q", s)
+		}
+		return i
+	}
+
+	check(0,
+		"CN=*.google.com",
+		"CN=WR2,O=Google Trust Services,C=US",
+		mustBeBigInt("60394724544825835345752647471744394661"),
+		time.Date(2025, 9, 22, 8, 40, 36, 0, time.UTC),
+		time.Date(2025, 12, 15, 8, 40, 35, 0, time.UTC),
+	)
+
+	check(1,
+		"CN=WR2,O=Google Trust Services,C=US",
+		"CN=GTS Root R1,O=Google Trust Services LLC,C=US",
+		mustBeBigInt("170058220837755766831192027518741805976"),
+		time.Date(2023, 12, 13, 9, 0, 0, 0, time.UTC),
+		time.Date(2029, 2, 20, 14, 0, 0, 0, time.UTC),
+	)
+
+	check(2,
+		"CN=GTS Root R1,O=Google Trust Services LLC,C=US",
+		"CN=GlobalSign Root CA,OU=Root CA,O=GlobalSign nv-sa,C=BE",
+		mustBeBigInt("159159747900478145820483398898491642637"),
+		time.Date(2020, 6, 19, 0, 0, 42, 0, time.UTC),
+		time.Date(2028, 1, 28, 0, 0, 42, 0, time.UTC),
+	)
+}
+
+func TestUnmarshalServerCertificate_ReassembledFromRecords(t *testing.T) {
+	body, err := os.ReadFile("server_certificate_msg.bin")
+	if err != nil {
+		t.Fatalf("failed to read testdata: %v", err)
+	}
+
+	// split the message into records the way a server with a small record size would
+	var stream bytes.Buffer
+	writer := record.NewWriter(&stream, spec.Tls12ProtocolVersion())
+	raw := MarshalHandshake(&spec.Handshake{MsgType: spec.HandshakeTypeCertificate, Body: body})
+	for off := 0; off < len(raw); off += 1000 {
+		if err := writer.WriteRecords(spec.ContentTypeHandshake, raw[off:min(off+1000, len(raw))]); err != nil {
+			t.Fatalf("failed to write record: %v", err)
+		}
+	}
+
+	reader := record.NewReader(&stream)
+	framer := NewFramer()
+	var message *spec.Handshake
+	for records := 0; message == nil; records++ {
+		rec, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatalf("failed to read record %d: %v", records, err)
+		}
+		framer.Write(rec.Fragment)
+
+		message, _, err = framer.Next()
+		if err != nil {
+			t.Fatalf("framer error: %v", err)
+		}
+	}
+
+	if message.MsgType != spec.HandshakeTypeCertificate {
+		t.Fatalf("expected %v, got %v", spec.HandshakeTypeCertificate, message.MsgType)
+	}
+
+	sc, err := UnmarshalServerCertificate(message.Body)
+	if err != nil {
+		t.Fatalf("UnmarshalServerCertificate error: %v", err)
+	}
+	if len(sc.Certificates) != 3 {
+		t.Fatalf("expected 3 certificates, got %d", len(sc.Certificates))
+	}
+}
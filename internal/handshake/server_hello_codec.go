@@ -1,4 +1,4 @@
-package main
+package handshake
 
 import (
 	"encoding/binary"
@@ -50,10 +50,7 @@ func UnmarshalServerHello(serverHelloRaw []byte) (*spec.ServerHello, error) {
 	if err := need(2); err != nil {
 		return nil, err
 	}
-	protocolVersion, err := utils.ParseProtocolVersionFromRawPayload(serverHelloRaw[off : off+2])
-	if err != nil {
-		return nil, err
-	}
+	protocolVersion := spec.ProtocolVersion{Major: serverHelloRaw[off], Minor: serverHelloRaw[off+1]}
 	off += 2
 
 	if err := need(32); err != nil {
@@ -100,6 +97,8 @@ func UnmarshalServerHello(serverHelloRaw []byte) (*spec.ServerHello, error) {
 		extensionsLength := binary.BigEndian.Uint16(serverHelloRaw[off : off+2])
 		off += 2
 		endExt := off + int(extensionsLength)
+		// RFC 5246, section 7.4.1.4: an extension type appears at most once
+		seen := make(map[spec.ExtensionType]bool)
 		for off < endExt {
 			if err := need(2); err != nil {
 				return nil, err
@@ -120,12 +119,16 @@ func UnmarshalServerHello(serverHelloRaw []byte) (*spec.ServerHello, error) {
 			opaque := append([]byte(nil), serverHelloRaw[off:off+opaqueLength]...)
 			off += opaqueLength
 
+			if seen[extType] {
+				return nil, fmt.Errorf("duplicate extension %v", extType)
+			}
+			seen[extType] = true
 			extensions = append(extensions, spec.Extension{Type: extType, Opaque: opaque})
 		}
 	}
 
 	return &spec.ServerHello{
-		ServerTlsVersion:  *protocolVersion,
+		ServerTlsVersion:  protocolVersion,
 		Random:            random,
 		SessionID:         sessionID,
 		CipherSuite:       cipherSuite,
@@ -1,4 +1,4 @@
-package main
+package handshake
 
 import (
 	"bytes"
@@ -118,3 +118,23 @@ func TestUnmarshalServerHello_ValidInput(t *testing.T) {
 	}
 
 }
+
+func TestUnmarshalServerHello_DuplicateExtension(t *testing.T) {
+	serverHello := &spec.ServerHello{
+		ServerTlsVersion:  spec.Tls12ProtocolVersion(),
+		Random:            make([]byte, 32),
+		CipherSuite:       spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		CompressionMethod: spec.CompressionMethodNull,
+		Extensions: []spec.Extension{
+			{Type: spec.ExtensionTypeRenegotiationInfo, Opaque: []byte{0}},
+			{Type: spec.ExtensionTypeRenegotiationInfo, Opaque: []byte{0}},
+		},
+	}
+
+	_, err := UnmarshalServerHello(MarshalServerHello(serverHello))
+
+	expected := "duplicate extension RenegotiationInfo"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+}
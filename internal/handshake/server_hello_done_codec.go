@@ -0,0 +1,19 @@
+package handshake
+
+import (
+	"fmt"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func MarshalServerHelloDone(_ *spec.ServerHelloDone) []byte {
+	return []byte{}
+}
+
+func UnmarshalServerHelloDone(raw []byte) (*spec.ServerHelloDone, error) {
+	if len(raw) != 0 {
+		return nil, fmt.Errorf("ServerHelloDone must have an empty body, got %d bytes", len(raw))
+	}
+
+	return &spec.ServerHelloDone{}, nil
+}
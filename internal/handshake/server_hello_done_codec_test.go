@@ -0,0 +1,38 @@
+package handshake
+
+import (
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestMarshalServerHelloDone_EmptyBody(t *testing.T) {
+	raw := MarshalServerHelloDone(&spec.ServerHelloDone{})
+
+	if len(raw) != 0 {
+		t.Errorf("Expected empty body, got %x", raw)
+	}
+}
+
+func TestUnmarshalServerHelloDone_ValidInput(t *testing.T) {
+	serverHelloDone, err := UnmarshalServerHelloDone([]byte{})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if serverHelloDone == nil {
+		t.Fatal("Expected non-nil ServerHelloDone")
+	}
+}
+
+func TestUnmarshalServerHelloDone_NonEmptyBody(t *testing.T) {
+	_, err := UnmarshalServerHelloDone([]byte{0x00})
+
+	if err == nil {
+		t.Fatal("Expected error for non-empty body")
+	}
+	expectedMessage := "ServerHelloDone must have an empty body, got 1 bytes"
+	if err.Error() != expectedMessage {
+		t.Errorf("Expected error message %q, got %q", expectedMessage, err.Error())
+	}
+}
@@ -0,0 +1,92 @@
+package handshake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+func MarshalServerECDHParams(params *spec.ServerECDHParams) []byte {
+	payload := []byte{}
+	payload = append(payload, byte(params.CurveType))
+	payload = binary.BigEndian.AppendUint16(payload, uint16(params.NamedCurve))
+	payload = append(payload, utils.CastUint8OrPanic(len(params.PublicKey)))
+	payload = append(payload, params.PublicKey...)
+
+	return payload
+}
+
+func MarshalServerKeyExchange(serverKeyExchange *spec.ServerKeyExchange) []byte {
+	payload := MarshalServerECDHParams(&serverKeyExchange.Params)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(serverKeyExchange.SignatureAlgorithm))
+	payload = binary.BigEndian.AppendUint16(payload, utils.CastUint16OrPanic(len(serverKeyExchange.Signature)))
+	payload = append(payload, serverKeyExchange.Signature...)
+
+	return payload
+}
+
+func UnmarshalServerKeyExchange(raw []byte) (*spec.ServerKeyExchange, error) {
+	off := 0
+	need := func(n int) error {
+		if len(raw)-off < n {
+			return fmt.Errorf("truncated ServerKeyExchange at offset %d, need %d bytes", off, n)
+		}
+		return nil
+	}
+
+	// curve_type (1) + namedcurve (2)
+	if err := need(3); err != nil {
+		return nil, err
+	}
+	curveType := spec.ECCurveType(raw[off])
+	if curveType != spec.ECCurveTypeNamedCurve {
+		return nil, fmt.Errorf("unsupported EC curve type %d", curveType)
+	}
+	namedCurve := spec.SupportedGroup(binary.BigEndian.Uint16(raw[off+1 : off+3]))
+	off += 3
+
+	// public point
+	if err := need(1); err != nil {
+		return nil, err
+	}
+	pointLen := int(raw[off])
+	off++
+	if pointLen == 0 {
+		return nil, errors.New("ECDH public point cannot be empty")
+	}
+	if err := need(pointLen); err != nil {
+		return nil, err
+	}
+	publicKey := append([]byte(nil), raw[off:off+pointLen]...)
+	off += pointLen
+
+	// digitally-signed
+	if err := need(4); err != nil {
+		return nil, err
+	}
+	signatureAlgorithm := spec.SignatureAlgorithm(binary.BigEndian.Uint16(raw[off : off+2]))
+	sigLen := int(binary.BigEndian.Uint16(raw[off+2 : off+4]))
+	off += 4
+	if err := need(sigLen); err != nil {
+		return nil, err
+	}
+	signature := append([]byte(nil), raw[off:off+sigLen]...)
+	off += sigLen
+
+	if off != len(raw) {
+		return nil, fmt.Errorf("unexpected %d trailing bytes in ServerKeyExchange", len(raw)-off)
+	}
+
+	return &spec.ServerKeyExchange{
+		Params: spec.ServerECDHParams{
+			CurveType:  curveType,
+			NamedCurve: namedCurve,
+			PublicKey:  publicKey,
+		},
+		SignatureAlgorithm: signatureAlgorithm,
+		Signature:          signature,
+	}, nil
+}
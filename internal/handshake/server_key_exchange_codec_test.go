@@ -0,0 +1,94 @@
+package handshake
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestMarshalServerKeyExchange_ValidInput(t *testing.T) {
+	serverKeyExchange := &spec.ServerKeyExchange{
+		Params: spec.ServerECDHParams{
+			CurveType:  spec.ECCurveTypeNamedCurve,
+			NamedCurve: spec.SupportedGroupsSecp256r1,
+			PublicKey:  []byte{0x04, 0x01, 0x02},
+		},
+		SignatureAlgorithm: spec.SignatureAlgorithmRsaPkcs1Sha256,
+		Signature:          []byte{0xaa, 0xbb},
+	}
+
+	raw := MarshalServerKeyExchange(serverKeyExchange)
+
+	expected := []byte{
+		0x03, 0x00, 0x17, 0x03, 0x04, 0x01, 0x02,
+		0x04, 0x01, 0x00, 0x02, 0xaa, 0xbb,
+	}
+	if !bytes.Equal(raw, expected) {
+		t.Fatalf("Expected %x, got %x", expected, raw)
+	}
+
+	parsed, err := UnmarshalServerKeyExchange(raw)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if parsed.Params.NamedCurve != spec.SupportedGroupsSecp256r1 {
+		t.Errorf("Expected named curve %v, got %v", spec.SupportedGroupsSecp256r1, parsed.Params.NamedCurve)
+	}
+	if !bytes.Equal(parsed.Params.PublicKey, serverKeyExchange.Params.PublicKey) {
+		t.Errorf("Unexpected public key: %x", parsed.Params.PublicKey)
+	}
+	if parsed.SignatureAlgorithm != spec.SignatureAlgorithmRsaPkcs1Sha256 {
+		t.Errorf("Expected signature algorithm %v, got %v", spec.SignatureAlgorithmRsaPkcs1Sha256, parsed.SignatureAlgorithm)
+	}
+	if !bytes.Equal(parsed.Signature, serverKeyExchange.Signature) {
+		t.Errorf("Unexpected signature: %x", parsed.Signature)
+	}
+}
+
+func TestUnmarshalServerKeyExchange_InvalidInput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "explicit prime curve",
+			raw:      []byte{0x01, 0x00, 0x17, 0x01, 0x04, 0x04, 0x01, 0x00, 0x00},
+			expected: "unsupported EC curve type 1",
+		},
+		{
+			name:     "empty point",
+			raw:      []byte{0x03, 0x00, 0x17, 0x00, 0x04, 0x01, 0x00, 0x00},
+			expected: "ECDH public point cannot be empty",
+		},
+		{
+			name:     "truncated point",
+			raw:      []byte{0x03, 0x00, 0x17, 0x05, 0x04},
+			expected: "truncated ServerKeyExchange at offset 4, need 5 bytes",
+		},
+		{
+			name:     "truncated signature",
+			raw:      []byte{0x03, 0x00, 0x17, 0x01, 0x04, 0x04, 0x01, 0x00, 0x02, 0xaa},
+			expected: "truncated ServerKeyExchange at offset 9, need 2 bytes",
+		},
+		{
+			name:     "trailing bytes",
+			raw:      []byte{0x03, 0x00, 0x17, 0x01, 0x04, 0x04, 0x01, 0x00, 0x00, 0xff},
+			expected: "unexpected 1 trailing bytes in ServerKeyExchange",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := UnmarshalServerKeyExchange(tc.raw)
+
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if err.Error() != tc.expected {
+				t.Errorf("Expected error message %q, got %q", tc.expected, err.Error())
+			}
+		})
+	}
+}
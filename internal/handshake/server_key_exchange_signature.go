@@ -0,0 +1,78 @@
+package handshake
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+// serverKeyExchangeSignedData returns client_random + server_random + params,
+// the content covered by the ServerKeyExchange signature.
+func serverKeyExchangeSignedData(clientRandom, serverRandom []byte, params *spec.ServerECDHParams) []byte {
+	signed := make([]byte, 0, 64+4+len(params.PublicKey))
+	signed = append(signed, clientRandom...)
+	signed = append(signed, serverRandom...)
+	signed = append(signed, MarshalServerECDHParams(params)...)
+
+	return signed
+}
+
+func NewServerKeyExchange(
+	rand io.Reader,
+	signer crypto.Signer,
+	signatureAlgorithm spec.SignatureAlgorithm,
+	clientRandom []byte,
+	serverRandom []byte,
+	params *spec.ServerECDHParams,
+) (*spec.ServerKeyExchange, error) {
+	if len(clientRandom) != 32 || len(serverRandom) != 32 {
+		return nil, errors.New("random must contain 32 bytes")
+	}
+
+	if params.CurveType != spec.ECCurveTypeNamedCurve {
+		return nil, fmt.Errorf("unsupported EC curve type %d", params.CurveType)
+	}
+
+	if len(params.PublicKey) == 0 || len(params.PublicKey) > 255 {
+		return nil, errors.New("ECDH public point must contain 1 to 255 bytes")
+	}
+
+	signature, err := Sign(rand, signer, signatureAlgorithm, serverKeyExchangeSignedData(clientRandom, serverRandom, params))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign ServerKeyExchange: %w", err)
+	}
+
+	return &spec.ServerKeyExchange{
+		Params: spec.ServerECDHParams{
+			CurveType:  params.CurveType,
+			NamedCurve: params.NamedCurve,
+			PublicKey:  utils.CopySlice(params.PublicKey),
+		},
+		SignatureAlgorithm: signatureAlgorithm,
+		Signature:          signature,
+	}, nil
+}
+
+func VerifyServerKeyExchange(
+	serverKeyExchange *spec.ServerKeyExchange,
+	clientRandom []byte,
+	serverRandom []byte,
+	serverCertificate *spec.ServerCertificate,
+) error {
+	if len(serverCertificate.Certificates) == 0 {
+		return errors.New("server certificate chain is empty")
+	}
+	leaf := serverCertificate.Certificates[0]
+
+	signed := serverKeyExchangeSignedData(clientRandom, serverRandom, &serverKeyExchange.Params)
+	if err := VerifySignature(leaf.PublicKey, serverKeyExchange.SignatureAlgorithm, signed, serverKeyExchange.Signature); err != nil {
+		return alert.Wrap(spec.AlertDescriptionDecryptError, fmt.Errorf("invalid ServerKeyExchange signature: %w", err))
+	}
+
+	return nil
+}
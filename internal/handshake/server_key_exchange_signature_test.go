@@ -0,0 +1,159 @@
+package handshake
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func newTestCertificate(t *testing.T, key crypto.Signer) *x509.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+
+	return certificate
+}
+
+func testRandoms() ([]byte, []byte) {
+	clientRandom := make([]byte, 32)
+	serverRandom := make([]byte, 32)
+	for i := range 32 {
+		clientRandom[i] = byte(i)
+		serverRandom[i] = byte(0xff - i)
+	}
+	return clientRandom, serverRandom
+}
+
+func TestServerKeyExchange_SignAndVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ECDSA key: %v", err)
+	}
+
+	testCases := []struct {
+		name               string
+		key                crypto.Signer
+		signatureAlgorithm spec.SignatureAlgorithm
+	}{
+		{"rsa pkcs1 sha256", rsaKey, spec.SignatureAlgorithmRsaPkcs1Sha256},
+		{"rsa pss sha384", rsaKey, spec.SignatureAlgorithmRsaPssRsaeSha384},
+		{"ecdsa p256 sha256", ecdsaKey, spec.SignatureAlgorithmEcdsaSecp256r1Sha256},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientRandom, serverRandom := testRandoms()
+			params := &spec.ServerECDHParams{
+				CurveType:  spec.ECCurveTypeNamedCurve,
+				NamedCurve: spec.SupportedGroupsSecp256r1,
+				PublicKey:  []byte{0x04, 0x01, 0x02, 0x03},
+			}
+			serverCertificate := &spec.ServerCertificate{
+				Certificates: []*x509.Certificate{newTestCertificate(t, tc.key)},
+			}
+
+			serverKeyExchange, err := NewServerKeyExchange(rand.Reader, tc.key, tc.signatureAlgorithm, clientRandom, serverRandom, params)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			parsed, err := UnmarshalServerKeyExchange(MarshalServerKeyExchange(serverKeyExchange))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if err := VerifyServerKeyExchange(parsed, clientRandom, serverRandom, serverCertificate); err != nil {
+				t.Fatalf("Expected valid signature, got %v", err)
+			}
+
+			// the signature binds both randoms, so a replay into another handshake must fail
+			if err := VerifyServerKeyExchange(parsed, serverRandom, clientRandom, serverCertificate); err == nil {
+				t.Error("Expected error for swapped randoms")
+			}
+
+			parsed.Params.PublicKey[1] ^= 0xff
+			if err := VerifyServerKeyExchange(parsed, clientRandom, serverRandom, serverCertificate); err == nil {
+				t.Error("Expected error for tampered params")
+			}
+		})
+	}
+}
+
+func TestNewServerKeyExchange_KeyTypeMismatch(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ECDSA key: %v", err)
+	}
+	clientRandom, serverRandom := testRandoms()
+	params := &spec.ServerECDHParams{
+		CurveType:  spec.ECCurveTypeNamedCurve,
+		NamedCurve: spec.SupportedGroupsSecp256r1,
+		PublicKey:  []byte{0x04},
+	}
+
+	_, err = NewServerKeyExchange(rand.Reader, ecdsaKey, spec.SignatureAlgorithmRsaPkcs1Sha256, clientRandom, serverRandom, params)
+
+	if err == nil {
+		t.Fatal("Expected error for RSA signature with ECDSA key")
+	}
+	expectedMessage := "failed to sign ServerKeyExchange: signature algorithm does not match public key of type *ecdsa.PublicKey"
+	if err.Error() != expectedMessage {
+		t.Errorf("Expected error message %q, got %q", expectedMessage, err.Error())
+	}
+}
+
+func TestVerifyServerKeyExchange_UnsupportedAlgorithm(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ECDSA key: %v", err)
+	}
+	clientRandom, serverRandom := testRandoms()
+	serverKeyExchange := &spec.ServerKeyExchange{
+		Params: spec.ServerECDHParams{
+			CurveType:  spec.ECCurveTypeNamedCurve,
+			NamedCurve: spec.SupportedGroupsSecp256r1,
+			PublicKey:  []byte{0x04},
+		},
+		SignatureAlgorithm: spec.SignatureAlgorithmEd448,
+	}
+	serverCertificate := &spec.ServerCertificate{
+		Certificates: []*x509.Certificate{newTestCertificate(t, ecdsaKey)},
+	}
+
+	err = VerifyServerKeyExchange(serverKeyExchange, clientRandom, serverRandom, serverCertificate)
+
+	if err == nil {
+		t.Fatal("Expected error for unsupported algorithm")
+	}
+	expectedMessage := "invalid ServerKeyExchange signature: unsupported signature algorithm 0x0808"
+	if err.Error() != expectedMessage {
+		t.Errorf("Expected error message %q, got %q", expectedMessage, err.Error())
+	}
+}
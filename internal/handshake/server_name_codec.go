@@ -0,0 +1,165 @@
+package handshake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+const maxHostNameLength = 253
+
+// ValidateHostName checks that name can be sent as a host_name: a DNS name of
+// letter-digit-hyphen labels, without a trailing dot and not an IP literal.
+func ValidateHostName(name string) error {
+	if name == "" {
+		return errors.New("host name cannot be empty")
+	}
+	if len(name) > maxHostNameLength {
+		return fmt.Errorf("host name of %d bytes exceeds %d", len(name), maxHostNameLength)
+	}
+	if strings.HasSuffix(name, ".") {
+		return fmt.Errorf("host name %q cannot end with a dot", name)
+	}
+	if net.ParseIP(name) != nil {
+		return fmt.Errorf("host name %q cannot be an IP literal", name)
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if err := validateLabel(label); err != nil {
+			return fmt.Errorf("host name %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateLabel(label string) error {
+	if label == "" || len(label) > 63 {
+		return fmt.Errorf("label length %d is not between 1 and 63", len(label))
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return fmt.Errorf("label %q cannot start or end with a hyphen", label)
+	}
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		isLetter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && !isDigit && c != '-' {
+			return fmt.Errorf("label %q contains invalid character %q", label, c)
+		}
+	}
+
+	return nil
+}
+
+func MarshalServerNameList(serverNameList *spec.ServerNameList) ([]byte, error) {
+	if len(serverNameList.ServerNames) == 0 {
+		return nil, errors.New("server name list cannot be empty")
+	}
+
+	var entries []byte
+	seenNameTypes := make(map[spec.ServerNameType]bool)
+	for _, serverName := range serverNameList.ServerNames {
+		if serverName.NameType != spec.ServerNameTypeHostName {
+			return nil, fmt.Errorf("unknown server name type %v", serverName.NameType)
+		}
+		if seenNameTypes[serverName.NameType] {
+			return nil, fmt.Errorf("duplicate server name type %v", serverName.NameType)
+		}
+		seenNameTypes[serverName.NameType] = true
+
+		if err := ValidateHostName(serverName.HostName); err != nil {
+			return nil, err
+		}
+		entries = append(entries, byte(serverName.NameType))
+		entries = binary.BigEndian.AppendUint16(entries, utils.CastUint16OrPanic(len(serverName.HostName)))
+		entries = append(entries, serverName.HostName...)
+	}
+
+	if len(entries) > math.MaxUint16 {
+		return nil, fmt.Errorf("server name list of %d bytes exceeds %d", len(entries), math.MaxUint16)
+	}
+
+	return utils.NewOpaqueVector16(entries)
+}
+
+func UnmarshalServerNameList(raw []byte) (*spec.ServerNameList, error) {
+	off := 0
+	need := func(n int) error {
+		if len(raw)-off < n {
+			return fmt.Errorf("truncated server name list at offset %d, need %d bytes", off, n)
+		}
+		return nil
+	}
+
+	if err := need(2); err != nil {
+		return nil, err
+	}
+	listLen := int(binary.BigEndian.Uint16(raw[off : off+2]))
+	off += 2
+	if listLen == 0 {
+		return nil, errors.New("server name list cannot be empty")
+	}
+	if len(raw)-off != listLen {
+		return nil, fmt.Errorf("server name list length %d does not match extension length %d", listLen, len(raw)-off)
+	}
+
+	var serverNames []spec.ServerName
+	seenNameTypes := make(map[spec.ServerNameType]bool)
+	for off < len(raw) {
+		// header: name_type(1) + length(2)
+		if err := need(3); err != nil {
+			return nil, err
+		}
+		nameType := spec.ServerNameType(raw[off])
+		nameLen := int(binary.BigEndian.Uint16(raw[off+1 : off+3]))
+		off += 3
+		if err := need(nameLen); err != nil {
+			return nil, err
+		}
+		name := string(raw[off : off+nameLen])
+		off += nameLen
+
+		if nameType != spec.ServerNameTypeHostName {
+			return nil, fmt.Errorf("unknown server name type %v", nameType)
+		}
+		if seenNameTypes[nameType] {
+			return nil, fmt.Errorf("duplicate server name type %v", nameType)
+		}
+		seenNameTypes[nameType] = true
+
+		if err := ValidateHostName(name); err != nil {
+			return nil, err
+		}
+		serverNames = append(serverNames, spec.ServerName{NameType: nameType, HostName: name})
+	}
+
+	return &spec.ServerNameList{ServerNames: serverNames}, nil
+}
+
+// ServerNameFromClientHello returns the host_name the client asked for, or an
+// empty string when the ClientHello has no server_name extension.
+func ServerNameFromClientHello(clientHello *spec.ClientHello) (string, error) {
+	extension, ok := utils.FindExtension(clientHello.Extensions, spec.ExtensionTypeServerName)
+	if !ok {
+		return "", nil
+	}
+
+	serverNameList, err := UnmarshalServerNameList(extension.Opaque)
+	if err != nil {
+		return "", err
+	}
+	for _, serverName := range serverNameList.ServerNames {
+		if serverName.NameType == spec.ServerNameTypeHostName {
+			return serverName.HostName, nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,212 @@
+package handshake
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestMarshalServerNameList_ValidInput(t *testing.T) {
+	serverNameList := &spec.ServerNameList{
+		ServerNames: []spec.ServerName{{NameType: spec.ServerNameTypeHostName, HostName: "example.com"}},
+	}
+
+	raw, err := MarshalServerNameList(serverNameList)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := append([]byte{0x00, 0x0e, 0x00, 0x00, 0x0b}, "example.com"...)
+	if !bytes.Equal(raw, expected) {
+		t.Errorf("Expected %x, got %x", expected, raw)
+	}
+}
+
+func TestMarshalServerNameList_InvalidInput(t *testing.T) {
+	hostName := func(name string) spec.ServerName {
+		return spec.ServerName{NameType: spec.ServerNameTypeHostName, HostName: name}
+	}
+	testCases := []struct {
+		name        string
+		serverNames []spec.ServerName
+		expected    string
+	}{
+		{
+			name:        "empty list",
+			serverNames: nil,
+			expected:    "server name list cannot be empty",
+		},
+		{
+			name:        "unknown name type",
+			serverNames: []spec.ServerName{{NameType: 1, HostName: "example.com"}},
+			expected:    "unknown server name type ServerNameType(0x01)",
+		},
+		{
+			name:        "duplicate name type",
+			serverNames: []spec.ServerName{hostName("a.example"), hostName("b.example")},
+			expected:    "duplicate server name type HostName",
+		},
+		{
+			name:        "invalid host name",
+			serverNames: []spec.ServerName{hostName("example.com.")},
+			expected:    `host name "example.com." cannot end with a dot`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := MarshalServerNameList(&spec.ServerNameList{ServerNames: tc.serverNames})
+
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("Expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalServerNameList_ValidInput(t *testing.T) {
+	raw := append([]byte{0x00, 0x0c, 0x00, 0x00, 0x09}, "localhost"...)
+
+	serverNameList, err := UnmarshalServerNameList(raw)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(serverNameList.ServerNames) != 1 {
+		t.Fatalf("Expected 1 server name, got %d", len(serverNameList.ServerNames))
+	}
+	serverName := serverNameList.ServerNames[0]
+	if serverName.NameType != spec.ServerNameTypeHostName || serverName.HostName != "localhost" {
+		t.Errorf("Expected host_name localhost, got %v %q", serverName.NameType, serverName.HostName)
+	}
+}
+
+func TestUnmarshalServerNameList_InvalidInput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "missing list length",
+			raw:      []byte{0x00},
+			expected: "truncated server name list at offset 0, need 2 bytes",
+		},
+		{
+			name:     "empty list",
+			raw:      []byte{0x00, 0x00},
+			expected: "server name list cannot be empty",
+		},
+		{
+			name:     "list length mismatch",
+			raw:      []byte{0x00, 0x05, 0x00, 0x00, 0x01, 'a'},
+			expected: "server name list length 5 does not match extension length 4",
+		},
+		{
+			name:     "truncated entry header",
+			raw:      []byte{0x00, 0x02, 0x00, 0x00},
+			expected: "truncated server name list at offset 2, need 3 bytes",
+		},
+		{
+			name:     "truncated name",
+			raw:      []byte{0x00, 0x04, 0x00, 0x00, 0x02, 'a'},
+			expected: "truncated server name list at offset 5, need 2 bytes",
+		},
+		{
+			name:     "unknown name type",
+			raw:      []byte{0x00, 0x04, 0x01, 0x00, 0x01, 'a'},
+			expected: "unknown server name type ServerNameType(0x01)",
+		},
+		{
+			name:     "duplicate name type",
+			raw:      []byte{0x00, 0x08, 0x00, 0x00, 0x01, 'a', 0x00, 0x00, 0x01, 'b'},
+			expected: "duplicate server name type HostName",
+		},
+		{
+			name:     "IP literal",
+			raw:      append([]byte{0x00, 0x0c, 0x00, 0x00, 0x09}, "127.0.0.1"...),
+			expected: `host name "127.0.0.1" cannot be an IP literal`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := UnmarshalServerNameList(tc.raw)
+
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("Expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidateHostName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		hostName string
+		expected string
+	}{
+		{name: "single label", hostName: "localhost"},
+		{name: "mixed case with digits and hyphens", hostName: "Web-01.Example.com"},
+		{name: "empty", hostName: "", expected: "host name cannot be empty"},
+		{name: "too long", hostName: strings.Repeat("a.", 127), expected: "host name of 254 bytes exceeds 253"},
+		{name: "trailing dot", hostName: "example.com.", expected: `host name "example.com." cannot end with a dot`},
+		{name: "IPv4 literal", hostName: "192.0.2.1", expected: `host name "192.0.2.1" cannot be an IP literal`},
+		{name: "IPv6 literal", hostName: "::1", expected: `host name "::1" cannot be an IP literal`},
+		{name: "empty label", hostName: "a..b", expected: `host name "a..b": label length 0 is not between 1 and 63`},
+		{name: "long label", hostName: strings.Repeat("a", 64), expected: `host name "` + strings.Repeat("a", 64) + `": label length 64 is not between 1 and 63`},
+		{name: "leading hyphen", hostName: "-a.example", expected: `host name "-a.example": label "-a" cannot start or end with a hyphen`},
+		{name: "underscore", hostName: "a_b.example", expected: `host name "a_b.example": label "a_b" contains invalid character '_'`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateHostName(tc.hostName)
+
+			if tc.expected == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("Expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestServerNameFromClientHello(t *testing.T) {
+	serverNameList := append([]byte{0x00, 0x0c, 0x00, 0x00, 0x09}, "localhost"...)
+	testCases := []struct {
+		name       string
+		extensions []spec.Extension
+		expected   string
+	}{
+		{
+			name:       "no server_name",
+			extensions: []spec.Extension{{Type: spec.ExtensionTypeECPointFormats, Opaque: []byte{0x01, 0x00}}},
+			expected:   "",
+		},
+		{
+			name:       "host_name",
+			extensions: []spec.Extension{{Type: spec.ExtensionTypeServerName, Opaque: serverNameList}},
+			expected:   "localhost",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			serverName, err := ServerNameFromClientHello(&spec.ClientHello{Extensions: tc.extensions})
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if serverName != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, serverName)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package handshake
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/spec"
+)
+
+type signatureType uint8
+
+const (
+	signaturePKCS1v15 signatureType = iota
+	signatureRSAPSS
+	signatureECDSA
+	signatureEd25519
+)
+
+func signatureHashAndType(signatureAlgorithm spec.SignatureAlgorithm) (crypto.Hash, signatureType, error) {
+	switch signatureAlgorithm {
+	case spec.SignatureAlgorithmRsaPkcs1Sha1:
+		return crypto.SHA1, signaturePKCS1v15, nil
+	case spec.SignatureAlgorithmRsaPkcs1Sha256:
+		return crypto.SHA256, signaturePKCS1v15, nil
+	case spec.SignatureAlgorithmRsaPkcs1Sha384:
+		return crypto.SHA384, signaturePKCS1v15, nil
+	case spec.SignatureAlgorithmRsaPkcs1Sha512:
+		return crypto.SHA512, signaturePKCS1v15, nil
+	case spec.SignatureAlgorithmRsaPssRsaeSha256:
+		return crypto.SHA256, signatureRSAPSS, nil
+	case spec.SignatureAlgorithmRsaPssRsaeSha384:
+		return crypto.SHA384, signatureRSAPSS, nil
+	case spec.SignatureAlgorithmRsaPssRsaeSha512:
+		return crypto.SHA512, signatureRSAPSS, nil
+	case spec.SignatureAlgorithmEcdsaSha1:
+		return crypto.SHA1, signatureECDSA, nil
+	case spec.SignatureAlgorithmEcdsaSecp256r1Sha256:
+		return crypto.SHA256, signatureECDSA, nil
+	case spec.SignatureAlgorithmEcdsaSecp384r1Sha384:
+		return crypto.SHA384, signatureECDSA, nil
+	case spec.SignatureAlgorithmEcdsaSecp521r1Sha512:
+		return crypto.SHA512, signatureECDSA, nil
+	case spec.SignatureAlgorithmEd25519:
+		return 0, signatureEd25519, nil
+	default:
+		return 0, 0, fmt.Errorf("unsupported signature algorithm %#04x", uint16(signatureAlgorithm))
+	}
+}
+
+func Sign(rand io.Reader, signer crypto.Signer, signatureAlgorithm spec.SignatureAlgorithm, message []byte) ([]byte, error) {
+	hash, sigType, err := signatureHashAndType(signatureAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPublicKeyType(signer.Public(), sigType); err != nil {
+		return nil, err
+	}
+
+	if sigType == signatureEd25519 {
+		return signer.Sign(rand, message, crypto.Hash(0))
+	}
+
+	h := hash.New()
+	h.Write(message)
+	digest := h.Sum(nil)
+
+	var opts crypto.SignerOpts = hash
+	if sigType == signatureRSAPSS {
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hash}
+	}
+
+	return signer.Sign(rand, digest, opts)
+}
+
+func VerifySignature(publicKey crypto.PublicKey, signatureAlgorithm spec.SignatureAlgorithm, message, signature []byte) error {
+	hash, sigType, err := signatureHashAndType(signatureAlgorithm)
+	if err != nil {
+		return err
+	}
+
+	if err := checkPublicKeyType(publicKey, sigType); err != nil {
+		return err
+	}
+
+	if sigType == signatureEd25519 {
+		if !ed25519.Verify(publicKey.(ed25519.PublicKey), message, signature) {
+			return errors.New("invalid Ed25519 signature")
+		}
+		return nil
+	}
+
+	h := hash.New()
+	h.Write(message)
+	digest := h.Sum(nil)
+
+	switch sigType {
+	case signaturePKCS1v15:
+		return rsa.VerifyPKCS1v15(publicKey.(*rsa.PublicKey), hash, digest, signature)
+	case signatureRSAPSS:
+		return rsa.VerifyPSS(publicKey.(*rsa.PublicKey), hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	default:
+		if !ecdsa.VerifyASN1(publicKey.(*ecdsa.PublicKey), digest, signature) {
+			return errors.New("invalid ECDSA signature")
+		}
+		return nil
+	}
+}
+
+func checkPublicKeyType(publicKey crypto.PublicKey, sigType signatureType) error {
+	var ok bool
+	switch sigType {
+	case signaturePKCS1v15, signatureRSAPSS:
+		_, ok = publicKey.(*rsa.PublicKey)
+	case signatureECDSA:
+		_, ok = publicKey.(*ecdsa.PublicKey)
+	case signatureEd25519:
+		_, ok = publicKey.(ed25519.PublicKey)
+	}
+
+	if !ok {
+		return fmt.Errorf("signature algorithm does not match public key of type %T", publicKey)
+	}
+	return nil
+}
+
+// CheckPublicKey returns an error unless signatureAlgorithm is known and can be
+// used with publicKey.
+func CheckPublicKey(publicKey crypto.PublicKey, signatureAlgorithm spec.SignatureAlgorithm) error {
+	_, sigType, err := signatureHashAndType(signatureAlgorithm)
+	if err != nil {
+		return err
+	}
+
+	return checkPublicKeyType(publicKey, sigType)
+}
+
+// SelectSignatureAlgorithm returns the first of the preferred algorithms that
+// the peer offered and that can be used with publicKey. The order of offered
+// is ignored, the local preference wins as it does for cipher suites.
+func SelectSignatureAlgorithm(preferred, offered []spec.SignatureAlgorithm, publicKey crypto.PublicKey) (spec.SignatureAlgorithm, error) {
+	for _, signatureAlgorithm := range preferred {
+		if slices.Contains(offered, signatureAlgorithm) && CheckPublicKey(publicKey, signatureAlgorithm) == nil {
+			return signatureAlgorithm, nil
+		}
+	}
+
+	return 0, alert.Errorf(spec.AlertDescriptionHandshakeFailure, "no signature algorithm shared with the peer for a key of type %T", publicKey)
+}
+
+// DefaultSignatureAlgorithm returns the algorithm assumed for a peer that sent
+// no signature_algorithms, SHA-1 with the type of publicKey (RFC 5246,
+// section 7.4.1.4.1).
+func DefaultSignatureAlgorithm(publicKey crypto.PublicKey) (spec.SignatureAlgorithm, error) {
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		return spec.SignatureAlgorithmRsaPkcs1Sha1, nil
+	case *ecdsa.PublicKey:
+		return spec.SignatureAlgorithmEcdsaSha1, nil
+	default:
+		return 0, alert.Errorf(spec.AlertDescriptionHandshakeFailure, "no default signature algorithm for a key of type %T", publicKey)
+	}
+}
@@ -0,0 +1,44 @@
+package handshake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+func MarshalSignatureAlgorithms(signatureAlgorithmList *spec.SignatureAlgorithmList) ([]byte, error) {
+	if len(signatureAlgorithmList.Algorithms) == 0 {
+		return nil, errors.New("signature algorithms cannot be empty")
+	}
+
+	values := make([]byte, 0, 2*len(signatureAlgorithmList.Algorithms))
+	for _, signatureAlgorithm := range signatureAlgorithmList.Algorithms {
+		values = binary.BigEndian.AppendUint16(values, uint16(signatureAlgorithm))
+	}
+
+	return utils.NewOpaqueVector16(values)
+}
+
+func UnmarshalSignatureAlgorithms(raw []byte) (*spec.SignatureAlgorithmList, error) {
+	if len(raw) < 2 {
+		return nil, errors.New("truncated signature algorithms")
+	}
+
+	listLen := int(binary.BigEndian.Uint16(raw[:2]))
+	if listLen == 0 || listLen%2 != 0 {
+		return nil, fmt.Errorf("incorrect signature algorithms length %d", listLen)
+	}
+	if len(raw)-2 != listLen {
+		return nil, fmt.Errorf("signature algorithms length %d does not match extension length %d", listLen, len(raw)-2)
+	}
+
+	signatureAlgorithms := make([]spec.SignatureAlgorithm, listLen/2)
+	for i := range signatureAlgorithms {
+		signatureAlgorithms[i] = spec.SignatureAlgorithm(binary.BigEndian.Uint16(raw[2+2*i : 4+2*i]))
+	}
+
+	return &spec.SignatureAlgorithmList{Algorithms: signatureAlgorithms}, nil
+}
@@ -0,0 +1,84 @@
+package handshake
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestMarshalSignatureAlgorithms_ValidInput(t *testing.T) {
+	signatureAlgorithmList := &spec.SignatureAlgorithmList{
+		Algorithms: []spec.SignatureAlgorithm{spec.SignatureAlgorithmRsaPssRsaeSha256, spec.SignatureAlgorithmEcdsaSecp256r1Sha256},
+	}
+
+	raw, err := MarshalSignatureAlgorithms(signatureAlgorithmList)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []byte{0x00, 0x04, 0x08, 0x04, 0x04, 0x03}
+	if !bytes.Equal(raw, expected) {
+		t.Errorf("Expected %x, got %x", expected, raw)
+	}
+}
+
+func TestMarshalSignatureAlgorithms_Empty(t *testing.T) {
+	_, err := MarshalSignatureAlgorithms(&spec.SignatureAlgorithmList{})
+
+	if err == nil || err.Error() != "signature algorithms cannot be empty" {
+		t.Errorf("Expected empty signature algorithms error, got %v", err)
+	}
+}
+
+func TestUnmarshalSignatureAlgorithms_ValidInput(t *testing.T) {
+	signatureAlgorithmList, err := UnmarshalSignatureAlgorithms([]byte{0x00, 0x06, 0x04, 0x01, 0x08, 0x07, 0xfe, 0xfe})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []spec.SignatureAlgorithm{spec.SignatureAlgorithmRsaPkcs1Sha256, spec.SignatureAlgorithmEd25519, 0xfefe}
+	if !slices.Equal(signatureAlgorithmList.Algorithms, expected) {
+		t.Errorf("Expected %v, got %v", expected, signatureAlgorithmList.Algorithms)
+	}
+}
+
+func TestUnmarshalSignatureAlgorithms_InvalidInput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "missing length",
+			raw:      []byte{0x00},
+			expected: "truncated signature algorithms",
+		},
+		{
+			name:     "empty list",
+			raw:      []byte{0x00, 0x00},
+			expected: "incorrect signature algorithms length 0",
+		},
+		{
+			name:     "odd length",
+			raw:      []byte{0x00, 0x01, 0x04},
+			expected: "incorrect signature algorithms length 1",
+		},
+		{
+			name:     "length mismatch",
+			raw:      []byte{0x00, 0x04, 0x04, 0x01},
+			expected: "signature algorithms length 4 does not match extension length 2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := UnmarshalSignatureAlgorithms(tc.raw)
+
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("Expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package handshake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+func MarshalSupportedGroups(supportedGroupList *spec.SupportedGroupList) ([]byte, error) {
+	if len(supportedGroupList.Groups) == 0 {
+		return nil, errors.New("supported groups cannot be empty")
+	}
+
+	values := make([]byte, 0, 2*len(supportedGroupList.Groups))
+	for _, group := range supportedGroupList.Groups {
+		values = binary.BigEndian.AppendUint16(values, uint16(group))
+	}
+
+	return utils.NewOpaqueVector16(values)
+}
+
+func UnmarshalSupportedGroups(raw []byte) (*spec.SupportedGroupList, error) {
+	if len(raw) < 2 {
+		return nil, errors.New("truncated supported groups")
+	}
+
+	listLen := int(binary.BigEndian.Uint16(raw[:2]))
+	if listLen == 0 || listLen%2 != 0 {
+		return nil, fmt.Errorf("incorrect supported groups length %d", listLen)
+	}
+	if len(raw)-2 != listLen {
+		return nil, fmt.Errorf("supported groups length %d does not match extension length %d", listLen, len(raw)-2)
+	}
+
+	groups := make([]spec.SupportedGroup, listLen/2)
+	for i := range groups {
+		groups[i] = spec.SupportedGroup(binary.BigEndian.Uint16(raw[2+2*i : 4+2*i]))
+	}
+
+	return &spec.SupportedGroupList{Groups: groups}, nil
+}
@@ -0,0 +1,84 @@
+package handshake
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestMarshalSupportedGroups_ValidInput(t *testing.T) {
+	supportedGroupList := &spec.SupportedGroupList{
+		Groups: []spec.SupportedGroup{spec.SupportedGroupsX25519, spec.SupportedGroupsSecp256r1},
+	}
+
+	raw, err := MarshalSupportedGroups(supportedGroupList)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []byte{0x00, 0x04, 0x00, 0x1d, 0x00, 0x17}
+	if !bytes.Equal(raw, expected) {
+		t.Errorf("Expected %x, got %x", expected, raw)
+	}
+}
+
+func TestMarshalSupportedGroups_Empty(t *testing.T) {
+	_, err := MarshalSupportedGroups(&spec.SupportedGroupList{})
+
+	if err == nil || err.Error() != "supported groups cannot be empty" {
+		t.Errorf("Expected empty groups error, got %v", err)
+	}
+}
+
+func TestUnmarshalSupportedGroups_ValidInput(t *testing.T) {
+	supportedGroupList, err := UnmarshalSupportedGroups([]byte{0x00, 0x06, 0x00, 0x17, 0x00, 0x18, 0x00, 0x1e})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []spec.SupportedGroup{spec.SupportedGroupsSecp256r1, spec.SupportedGroupsSecp384r1, 0x001e}
+	if !slices.Equal(supportedGroupList.Groups, expected) {
+		t.Errorf("Expected %v, got %v", expected, supportedGroupList.Groups)
+	}
+}
+
+func TestUnmarshalSupportedGroups_InvalidInput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "missing length",
+			raw:      []byte{0x00},
+			expected: "truncated supported groups",
+		},
+		{
+			name:     "empty list",
+			raw:      []byte{0x00, 0x00},
+			expected: "incorrect supported groups length 0",
+		},
+		{
+			name:     "odd length",
+			raw:      []byte{0x00, 0x03, 0x00, 0x17, 0x00},
+			expected: "incorrect supported groups length 3",
+		},
+		{
+			name:     "length mismatch",
+			raw:      []byte{0x00, 0x04, 0x00, 0x17},
+			expected: "supported groups length 4 does not match extension length 2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := UnmarshalSupportedGroups(tc.raw)
+
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("Expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
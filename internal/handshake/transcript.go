@@ -0,0 +1,50 @@
+package handshake
+
+import (
+	"crypto"
+	"errors"
+	"hash"
+)
+
+// Transcript records the handshake messages exchanged so far. The hash is
+// only known once the cipher suite is negotiated, so messages are buffered
+// until SetHash is called and hashed incrementally afterwards.
+type Transcript struct {
+	buffer []byte
+	hash   hash.Hash
+}
+
+func NewTranscript() *Transcript {
+	return &Transcript{}
+}
+
+// Write adds a full handshake message, header included.
+func (t *Transcript) Write(message []byte) {
+	if t.hash != nil {
+		t.hash.Write(message)
+		return
+	}
+	t.buffer = append(t.buffer, message...)
+}
+
+func (t *Transcript) SetHash(h crypto.Hash) error {
+	if t.hash != nil {
+		return errors.New("transcript hash is already set")
+	}
+
+	t.hash = h.New()
+	t.hash.Write(t.buffer)
+	t.buffer = nil
+
+	return nil
+}
+
+// Sum returns the hash of all messages written so far without finalizing the
+// transcript, so it can be called at every point a handshake hash is needed.
+func (t *Transcript) Sum() ([]byte, error) {
+	if t.hash == nil {
+		return nil, errors.New("transcript hash is not set")
+	}
+
+	return t.hash.Sum(nil), nil
+}
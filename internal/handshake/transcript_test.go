@@ -0,0 +1,53 @@
+package handshake
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestTranscript_BuffersUntilHashIsKnown(t *testing.T) {
+	clientHello := []byte{0x01, 0x00, 0x00, 0x01, 0xaa}
+	serverHello := []byte{0x02, 0x00, 0x00, 0x01, 0xbb}
+	transcript := NewTranscript()
+
+	transcript.Write(clientHello)
+	if _, err := transcript.Sum(); err == nil {
+		t.Fatal("Expected error before hash is set")
+	}
+
+	if err := transcript.SetHash(crypto.SHA256); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	transcript.Write(serverHello)
+
+	sum, err := transcript.Sum()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := sha256.Sum256(append(append([]byte(nil), clientHello...), serverHello...))
+	if hex.EncodeToString(sum) != hex.EncodeToString(expected[:]) {
+		t.Errorf("Expected %x, got %x", expected, sum)
+	}
+
+	// Sum must not finalize the running hash
+	transcript.Write(clientHello)
+	again, _ := transcript.Sum()
+	if hex.EncodeToString(again) == hex.EncodeToString(sum) {
+		t.Error("Expected transcript hash to change after another message")
+	}
+}
+
+func TestTranscript_SetHashTwice(t *testing.T) {
+	transcript := NewTranscript()
+
+	if err := transcript.SetHash(crypto.SHA256); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	err := transcript.SetHash(crypto.SHA384)
+
+	if err == nil {
+		t.Fatal("Expected error when setting hash twice")
+	}
+}
@@ -0,0 +1,69 @@
+package prf
+
+import (
+	"github.com/piligrimm/tls/internal/ciphersuite"
+)
+
+const MasterSecretLength = 48
+
+func MasterSecret(params *ciphersuite.Parameters, preMasterSecret, clientRandom, serverRandom []byte) []byte {
+	seed := make([]byte, 0, len(clientRandom)+len(serverRandom))
+	seed = append(seed, clientRandom...)
+	seed = append(seed, serverRandom...)
+
+	return PRF(params.PRFHash, preMasterSecret, "master secret", seed, MasterSecretLength)
+}
+
+// ExtendedMasterSecret derives the master secret from the session hash, the
+// transcript hash up to and including ClientKeyExchange (RFC 7627, section 4).
+func ExtendedMasterSecret(params *ciphersuite.Parameters, preMasterSecret, sessionHash []byte) []byte {
+	return PRF(params.PRFHash, preMasterSecret, "extended master secret", sessionHash, MasterSecretLength)
+}
+
+type KeyBlock struct {
+	ClientMACKey []byte
+	ServerMACKey []byte
+	ClientKey    []byte
+	ServerKey    []byte
+	ClientIV     []byte
+	ServerIV     []byte
+}
+
+// NewKeyBlock expands the master secret into the write keys of both sides.
+// Note that the seed is server_random + client_random, the reverse of the
+// master secret derivation.
+func NewKeyBlock(params *ciphersuite.Parameters, masterSecret, clientRandom, serverRandom []byte) *KeyBlock {
+	seed := make([]byte, 0, len(clientRandom)+len(serverRandom))
+	seed = append(seed, serverRandom...)
+	seed = append(seed, clientRandom...)
+
+	length := 2 * (params.MACLength + params.KeyLength + params.FixedIVLength)
+	keyMaterial := PRF(params.PRFHash, masterSecret, "key expansion", seed, length)
+
+	next := func(n int) []byte {
+		part := keyMaterial[:n:n]
+		keyMaterial = keyMaterial[n:]
+		return part
+	}
+
+	keyBlock := &KeyBlock{}
+	keyBlock.ClientMACKey = next(params.MACLength)
+	keyBlock.ServerMACKey = next(params.MACLength)
+	keyBlock.ClientKey = next(params.KeyLength)
+	keyBlock.ServerKey = next(params.KeyLength)
+	keyBlock.ClientIV = next(params.FixedIVLength)
+	keyBlock.ServerIV = next(params.FixedIVLength)
+
+	return keyBlock
+}
+
+const (
+	VerifyDataLength = 12
+
+	ClientFinishedLabel = "client finished"
+	ServerFinishedLabel = "server finished"
+)
+
+func VerifyData(params *ciphersuite.Parameters, masterSecret []byte, label string, handshakeHash []byte) []byte {
+	return PRF(params.PRFHash, masterSecret, label, handshakeHash, VerifyDataLength)
+}
@@ -0,0 +1,149 @@
+package prf
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/spec"
+)
+
+const (
+	testPreMasterSecret = "0303404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f606162636465666768696a6b6c6d"
+	testClientRandom    = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	testServerRandom    = "202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f"
+)
+
+func TestMasterSecret_HashFollowsCipherSuite(t *testing.T) {
+	testCases := []struct {
+		name        string
+		cipherSuite spec.CipherSuite
+		expected    string
+	}{
+		{
+			name:        "sha256",
+			cipherSuite: spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			expected:    "632a49039d6452a6657e0daeb9fce7388d487928caec8f01f1464ba55f43c2690f9d01d233f388b45bd4bcd3490f1786",
+		},
+		{
+			name:        "sha384",
+			cipherSuite: spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			expected:    "50496bdf98d9477192620d5ee7f52e6afa468024e240491cf471a81ac75ca246a2ad8af2b53c385ac927643694135540",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params, err := ciphersuite.Lookup(tc.cipherSuite)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			masterSecret := MasterSecret(params, mustDecodeHex(t, testPreMasterSecret), mustDecodeHex(t, testClientRandom), mustDecodeHex(t, testServerRandom))
+
+			if hex.EncodeToString(masterSecret) != tc.expected {
+				t.Errorf("Expected %s, got %x", tc.expected, masterSecret)
+			}
+		})
+	}
+}
+
+func TestExtendedMasterSecret_HashFollowsCipherSuite(t *testing.T) {
+	testCases := []struct {
+		name        string
+		cipherSuite spec.CipherSuite
+		sessionHash string
+		expected    string
+	}{
+		{
+			name:        "sha256",
+			cipherSuite: spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			sessionHash: "404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f",
+			expected:    "d2a1685cb9ecb718332b5566390f9420e21be3729d2b7fb88ddbab6dc1cf1f7abad01be9f79cd4417d0ad18203d8fa16",
+		},
+		{
+			name:        "sha384",
+			cipherSuite: spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			sessionHash: "404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f606162636465666768696a6b6c6d6e6f",
+			expected:    "891887aa4472a65d891bae682efe326f4e2d0e0d1bdb3ef39d8a55420d90efc649a61d5d06ae2e81d014c4e1a86d36ed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params, err := ciphersuite.Lookup(tc.cipherSuite)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			masterSecret := ExtendedMasterSecret(params, mustDecodeHex(t, testPreMasterSecret), mustDecodeHex(t, tc.sessionHash))
+
+			if hex.EncodeToString(masterSecret) != tc.expected {
+				t.Errorf("Expected %s, got %x", tc.expected, masterSecret)
+			}
+		})
+	}
+}
+
+func TestNewKeyBlock_SizedFromCipherSuite(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		cipherSuite          spec.CipherSuite
+		masterSecret         string
+		clientMACKey         string
+		serverMACKey         string
+		clientKey, serverKey string
+		clientIV, serverIV   string
+	}{
+		{
+			name:         "aes 128 gcm",
+			cipherSuite:  spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			masterSecret: "632a49039d6452a6657e0daeb9fce7388d487928caec8f01f1464ba55f43c2690f9d01d233f388b45bd4bcd3490f1786",
+			clientKey:    "67a0dc9cefa039824353adb54e820c61",
+			serverKey:    "05365fd6a466504a16e9bbe4f1fb7502",
+			clientIV:     "e4daf521",
+			serverIV:     "0ad11462",
+		},
+		{
+			name:         "aes 256 gcm sha384",
+			cipherSuite:  spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			masterSecret: "50496bdf98d9477192620d5ee7f52e6afa468024e240491cf471a81ac75ca246a2ad8af2b53c385ac927643694135540",
+			clientKey:    "e6f48fdd0553b9e54186448ca20df13c39f08399643a7eaae1ab39a50dfbb8ef",
+			serverKey:    "7e41c7166c45d24495aab5fc34e2c1b3aabfc6d27beb2fdf4269551587e07fc9",
+			clientIV:     "88909f6a",
+			serverIV:     "7e1077a9",
+		},
+		{
+			name:         "aes 128 cbc sha",
+			cipherSuite:  spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA,
+			masterSecret: "632a49039d6452a6657e0daeb9fce7388d487928caec8f01f1464ba55f43c2690f9d01d233f388b45bd4bcd3490f1786",
+			clientMACKey: "67a0dc9cefa039824353adb54e820c6105365fd6",
+			serverMACKey: "a466504a16e9bbe4f1fb7502e4daf5210ad11462",
+			clientKey:    "4a55fc538906fc1c581a705b8cb7828b",
+			serverKey:    "72ead2bcbe0d9501d7b82d83eb9bec94",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params, err := ciphersuite.Lookup(tc.cipherSuite)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			keyBlock := NewKeyBlock(params, mustDecodeHex(t, tc.masterSecret), mustDecodeHex(t, testClientRandom), mustDecodeHex(t, testServerRandom))
+
+			check := func(name string, got []byte, expected string) {
+				if hex.EncodeToString(got) != expected {
+					t.Errorf("%s mismatch: expected %s, got %x", name, expected, got)
+				}
+			}
+			check("client MAC key", keyBlock.ClientMACKey, tc.clientMACKey)
+			check("server MAC key", keyBlock.ServerMACKey, tc.serverMACKey)
+			check("client key", keyBlock.ClientKey, tc.clientKey)
+			check("server key", keyBlock.ServerKey, tc.serverKey)
+			check("client IV", keyBlock.ClientIV, tc.clientIV)
+			check("server IV", keyBlock.ServerIV, tc.serverIV)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package prf
+
+import (
+	"crypto"
+	"crypto/hmac"
+)
+
+// PRF is the TLS 1.2 pseudorandom function, P_hash(secret, label + seed),
+// defined in RFC 5246, Section 5.
+func PRF(hash crypto.Hash, secret []byte, label string, seed []byte, length int) []byte {
+	labelAndSeed := make([]byte, 0, len(label)+len(seed))
+	labelAndSeed = append(labelAndSeed, label...)
+	labelAndSeed = append(labelAndSeed, seed...)
+
+	mac := hmac.New(hash.New, secret)
+	result := make([]byte, 0, length+hash.Size())
+
+	// A(1) = HMAC_hash(secret, seed)
+	mac.Write(labelAndSeed)
+	a := mac.Sum(nil)
+	for len(result) < length {
+		mac.Reset()
+		mac.Write(a)
+		mac.Write(labelAndSeed)
+		result = mac.Sum(result)
+
+		mac.Reset()
+		mac.Write(a)
+		a = mac.Sum(a[:0])
+	}
+
+	return result[:length]
+}
@@ -0,0 +1,70 @@
+package prf
+
+import (
+	"crypto"
+	"encoding/hex"
+	"testing"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex %q: %v", s, err)
+	}
+	return b
+}
+
+// Test vectors published on the IETF TLS mailing list for the TLS 1.2 PRF.
+func TestPRF_TestVectors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		hash     crypto.Hash
+		secret   string
+		seed     string
+		expected string
+	}{
+		{
+			name:   "sha256",
+			hash:   crypto.SHA256,
+			secret: "9bbe436ba940f017b17652849a71db35",
+			seed:   "a0ba9f936cda311827a6f796ffd5198c",
+			expected: "e3f229ba727be17b8d122620557cd453c2aab21d07c3d495329b52d4e61edb5a6b301791e90d35c9c9a46b4e14baf9af0fa022f7077def17abfd3797c0564bab" +
+				"4fbc91666e9def9b97fce34f796789baa48082d122ee42c5a72e5a5110fff70187347b66",
+		},
+		{
+			name:   "sha384",
+			hash:   crypto.SHA384,
+			secret: "b80b733d6ceefcdc71566ea48e5567df",
+			seed:   "cd665cf6a8447dd6ff8b27555edb7465",
+			expected: "7b0c18e9ced410ed1804f2cfa34a336a1c14dffb4900bb5fd7942107e81c83cde9ca0faa60be9fe34f82b1233c9146a0e534cb400fed2700884f9dc236f80edd" +
+				"8bfa961144c9e8d792eca722a7b32fc3d416d473ebc2c5fd4abfdad05d9184259b5bf8cd4d90fa0d31e2dec479e4f1a26066f2eea9a69236a3e52655c9e9aee6" +
+				"91c8f3a26854308d5eaa3be85e0990703d73e56f",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expected := mustDecodeHex(t, tc.expected)
+
+			output := PRF(tc.hash, mustDecodeHex(t, tc.secret), "test label", mustDecodeHex(t, tc.seed), len(expected))
+
+			if hex.EncodeToString(output) != tc.expected {
+				t.Errorf("Expected %s, got %x", tc.expected, output)
+			}
+		})
+	}
+}
+
+func TestPRF_ShortOutputIsPrefix(t *testing.T) {
+	secret := []byte("secret")
+	seed := []byte("seed")
+
+	long := PRF(crypto.SHA256, secret, "label", seed, 100)
+	short := PRF(crypto.SHA256, secret, "label", seed, 12)
+
+	if hex.EncodeToString(short) != hex.EncodeToString(long[:12]) {
+		t.Errorf("Expected %x to be a prefix of %x", short, long)
+	}
+}
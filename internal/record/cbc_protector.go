@@ -0,0 +1,208 @@
+package record
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"io"
+	"math/bits"
+
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/spec"
+)
+
+// maxCBCPaddingLength is the most padding a record can carry, including the
+// padding_length byte.
+const maxCBCPaddingLength = 256
+
+// cbcProtector implements the MAC-then-encrypt block cipher record protection
+// of RFC 5246, section 6.2.3.2. Every record starts with a random explicit IV.
+//
+// Open never branches on the decrypted padding or MAC: both are checked in
+// constant time and the MAC runs the same number of hash compressions
+// whatever the padding length, which is what Lucky Thirteen times. Memory
+// access patterns still depend on the padding, a local attacker watching the
+// cache may tell them apart.
+//
+// With encryptThenMAC set it implements RFC 7366 instead: the MAC covers the
+// IV and the ciphertext, follows them, and is checked before anything is
+// decrypted.
+type cbcProtector struct {
+	block          cipher.Block
+	mac            hash.Hash
+	rand           io.Reader
+	seq            sequenceNumber
+	encryptThenMAC bool
+}
+
+// NewEncryptThenMACProtector returns the protector of a CBC suite for a
+// session that negotiated encrypt_then_mac (RFC 7366).
+func NewEncryptThenMACProtector(params *ciphersuite.Parameters, key, macKey []byte) (Protector, error) {
+	if params.Cipher != ciphersuite.CipherAESCBC {
+		return nil, fmt.Errorf("%w with encrypt_then_mac: %v", errUnsupportedProtectorCipher, params.ID)
+	}
+
+	protector, err := newCBCProtector(params, key, macKey)
+	if err != nil {
+		return nil, err
+	}
+	protector.encryptThenMAC = true
+	return protector, nil
+}
+
+func newCBCProtector(params *ciphersuite.Parameters, key, macKey []byte) (*cbcProtector, error) {
+	if len(macKey) != params.MACLength {
+		return nil, fmt.Errorf("MAC key must contain %d bytes, got %d", params.MACLength, len(macKey))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cbcProtector{
+		block: block,
+		mac:   hmac.New(params.MACHash.New, macKey),
+		rand:  rand.Reader,
+	}, nil
+}
+
+// recordMAC returns the MAC of data under seq. The hash then goes on over
+// whole blocks of zeros, so that it runs as many compressions as for a MAC
+// over maxLength bytes of data.
+func (c *cbcProtector) recordMAC(seq uint64, contentType spec.ContentType, version spec.ProtocolVersion, data []byte, maxLength int) []byte {
+	header := additionalData(seq, contentType, version, len(data))
+	c.mac.Reset()
+	c.mac.Write(header)
+	c.mac.Write(data)
+	sum := c.mac.Sum(nil)
+
+	blockSize := c.mac.BlockSize()
+	extraBlocks := hashBlocks(len(header)+maxLength, blockSize) - hashBlocks(len(header)+len(data), blockSize)
+	c.mac.Write(make([]byte, extraBlocks*blockSize))
+
+	return sum
+}
+
+// hashBlocks returns the number of compressions SHA-1 or SHA-2 runs to hash n
+// bytes, the padding adds a 0x80 byte and a length field of blockSize/8 bytes.
+func hashBlocks(n, blockSize int) int {
+	return (n + 1 + blockSize/8 + blockSize - 1) / blockSize
+}
+
+func (c *cbcProtector) Seal(contentType spec.ContentType, version spec.ProtocolVersion, plaintext []byte) ([]byte, error) {
+	seq, err := c.seq.next()
+	if err != nil {
+		return nil, err
+	}
+
+	var mac []byte
+	if !c.encryptThenMAC {
+		mac = c.recordMAC(seq, contentType, version, plaintext, len(plaintext))
+	}
+	blockSize := c.block.BlockSize()
+	paddingLength := blockSize - (len(plaintext)+len(mac))%blockSize
+
+	out := make([]byte, blockSize, blockSize+len(plaintext)+len(mac)+paddingLength+c.mac.Size())
+	if _, err := io.ReadFull(c.rand, out); err != nil {
+		return nil, fmt.Errorf("failed to generate record IV: %w", err)
+	}
+	out = append(out, plaintext...)
+	out = append(out, mac...)
+	for range paddingLength {
+		out = append(out, byte(paddingLength-1))
+	}
+
+	cipher.NewCBCEncrypter(c.block, out[:blockSize]).CryptBlocks(out[blockSize:], out[blockSize:])
+	if c.encryptThenMAC {
+		out = append(out, c.recordMAC(seq, contentType, version, out, len(out))...)
+	}
+	return out, nil
+}
+
+func (c *cbcProtector) Open(contentType spec.ContentType, version spec.ProtocolVersion, ciphertext []byte) ([]byte, error) {
+	seq, err := c.seq.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.encryptThenMAC {
+		return c.openEncryptThenMAC(seq, contentType, version, ciphertext)
+	}
+
+	// the record length is public, rejecting it early reveals nothing
+	blockSize := c.block.BlockSize()
+	macSize := c.mac.Size()
+	minPayloadLength := (macSize + blockSize) / blockSize * blockSize
+	if len(ciphertext)%blockSize != 0 || len(ciphertext) < blockSize+minPayloadLength {
+		return nil, ErrBadRecordMAC
+	}
+
+	payload := make([]byte, len(ciphertext)-blockSize)
+	cipher.NewCBCDecrypter(c.block, ciphertext[:blockSize]).CryptBlocks(payload, ciphertext[blockSize:])
+
+	paddingLength, good := cbcPadding(payload)
+
+	// a padding longer than the room left for the MAC leaves no plaintext
+	plaintextLength := len(payload) - macSize - paddingLength
+	negative := int(uint(plaintextLength) >> (bits.UintSize - 1))
+	good &= negative ^ 1
+	plaintextLength = subtle.ConstantTimeSelect(negative, 0, plaintextLength)
+
+	// the longest plaintext the record can hold has a single padding byte
+	expected := c.recordMAC(seq, contentType, version, payload[:plaintextLength], len(payload)-macSize-1)
+	good &= subtle.ConstantTimeCompare(expected, payload[plaintextLength:plaintextLength+macSize])
+	if good != 1 {
+		return nil, ErrBadRecordMAC
+	}
+
+	return payload[:plaintextLength], nil
+}
+
+// openEncryptThenMAC authenticates IV || ciphertext before decrypting it, so
+// the padding of a forged record is never looked at.
+func (c *cbcProtector) openEncryptThenMAC(seq uint64, contentType spec.ContentType, version spec.ProtocolVersion, record []byte) ([]byte, error) {
+	blockSize := c.block.BlockSize()
+	macSize := c.mac.Size()
+	if len(record) < 2*blockSize+macSize || (len(record)-macSize)%blockSize != 0 {
+		return nil, ErrBadRecordMAC
+	}
+
+	ciphertext := record[:len(record)-macSize]
+	expected := c.recordMAC(seq, contentType, version, ciphertext, len(ciphertext))
+	if !hmac.Equal(expected, record[len(record)-macSize:]) {
+		return nil, ErrBadRecordMAC
+	}
+
+	payload := make([]byte, len(ciphertext)-blockSize)
+	cipher.NewCBCDecrypter(c.block, ciphertext[:blockSize]).CryptBlocks(payload, ciphertext[blockSize:])
+	paddingLength, good := cbcPadding(payload)
+	if good != 1 {
+		return nil, ErrBadRecordMAC
+	}
+
+	return payload[:len(payload)-paddingLength], nil
+}
+
+// cbcPadding returns the length of the padding at the end of payload,
+// including the padding_length byte, and 1 when every padding byte holds the
+// padding length or 0 otherwise. It reads the same bytes whatever the padding
+// and reports a malformed padding as the padding_length byte alone, as RFC
+// 5246 asks for the MAC to be checked anyway.
+func cbcPadding(payload []byte) (int, int) {
+	paddingLength := int(payload[len(payload)-1])
+	good := subtle.ConstantTimeLessOrEq(paddingLength+1, len(payload))
+
+	checked := min(len(payload), maxCBCPaddingLength)
+	for i := 1; i < checked; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, paddingLength)
+		matches := subtle.ConstantTimeByteEq(payload[len(payload)-1-i], byte(paddingLength))
+		good &= matches | (inPadding ^ 1)
+	}
+
+	return subtle.ConstantTimeSelect(good, paddingLength+1, 1), good
+}
@@ -0,0 +1,427 @@
+package record
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"errors"
+	"hash"
+	"testing"
+
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/spec"
+)
+
+func newTestCBCProtectors(t *testing.T, cipherSuite spec.CipherSuite) (*ciphersuite.Parameters, Protector, Protector) {
+	t.Helper()
+
+	params, err := ciphersuite.Lookup(cipherSuite)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	key := bytes.Repeat([]byte{0x33}, params.KeyLength)
+	macKey := bytes.Repeat([]byte{0x44}, params.MACLength)
+
+	sealer, err := NewProtector(params, key, nil, macKey)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	opener, err := NewProtector(params, key, nil, macKey)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return params, sealer, opener
+}
+
+// sealTestCBCRecord encrypts plaintext || MAC || padding for sequence number 0
+// under the keys of newTestCBCProtectors, with the padding given verbatim.
+func sealTestCBCRecord(params *ciphersuite.Parameters, plaintext, mac, padding []byte) []byte {
+	if mac == nil {
+		h := hmac.New(params.MACHash.New, bytes.Repeat([]byte{0x44}, params.MACLength))
+		h.Write(additionalData(0, spec.ContentTypeApplicationData, spec.Tls12ProtocolVersion(), len(plaintext)))
+		h.Write(plaintext)
+		mac = h.Sum(nil)
+	}
+
+	block, _ := aes.NewCipher(bytes.Repeat([]byte{0x33}, params.KeyLength))
+	record := make([]byte, aes.BlockSize)
+	record = append(record, plaintext...)
+	record = append(record, mac...)
+	record = append(record, padding...)
+	cipher.NewCBCEncrypter(block, record[:aes.BlockSize]).CryptBlocks(record[aes.BlockSize:], record[aes.BlockSize:])
+
+	return record
+}
+
+func TestCBCProtector_RoundTrip(t *testing.T) {
+	version := spec.Tls12ProtocolVersion()
+
+	for _, cipherSuite := range []spec.CipherSuite{
+		spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA,
+		spec.CipherSuiteECDHE_RSA_WITH_AES_256_CBC_SHA,
+		spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA256,
+		spec.CipherSuiteECDHE_RSA_WITH_AES_256_CBC_SHA384,
+	} {
+		t.Run(cipherSuite.String(), func(t *testing.T) {
+			params, sealer, opener := newTestCBCProtectors(t, cipherSuite)
+
+			for i, message := range []string{"first", "", "exactly sixteen!", string(bytes.Repeat([]byte{'x'}, MaxPlaintextLength))} {
+				sealed, err := sealer.Seal(spec.ContentTypeApplicationData, version, []byte(message))
+				if err != nil {
+					t.Fatalf("Expected no error sealing record %d, got %v", i, err)
+				}
+				if len(sealed)%aes.BlockSize != 0 || len(sealed) < aes.BlockSize+len(message)+params.MACLength+1 {
+					t.Errorf("Expected an IV and whole blocks holding the MAC and padding, got %d bytes for %d", len(sealed), len(message))
+				}
+
+				opened, err := opener.Open(spec.ContentTypeApplicationData, version, sealed)
+				if err != nil {
+					t.Fatalf("Expected no error opening record %d, got %v", i, err)
+				}
+				if string(opened) != message {
+					t.Errorf("Expected %q, got %q", message, opened)
+				}
+			}
+		})
+	}
+}
+
+func TestCBCProtector_FreshIVPerRecord(t *testing.T) {
+	_, sealer, _ := newTestCBCProtectors(t, spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA256)
+	version := spec.Tls12ProtocolVersion()
+
+	first, _ := sealer.Seal(spec.ContentTypeApplicationData, version, []byte("same"))
+	second, _ := sealer.Seal(spec.ContentTypeApplicationData, version, []byte("same"))
+
+	if bytes.Equal(first[:aes.BlockSize], second[:aes.BlockSize]) {
+		t.Error("Expected every record to carry a fresh explicit IV")
+	}
+}
+
+func TestCBCProtector_OpenRejectsMalformedRecords(t *testing.T) {
+	params, err := ciphersuite.Lookup(spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	plaintext := []byte("legacy embedded device")
+	version := spec.Tls12ProtocolVersion()
+
+	testCases := []struct {
+		name   string
+		record []byte
+	}{
+		{
+			name:   "valid record for reference",
+			record: sealTestCBCRecord(params, plaintext, nil, bytes.Repeat([]byte{5}, 6)),
+		},
+		{
+			name:   "padding byte not matching the length",
+			record: sealTestCBCRecord(params, plaintext, nil, []byte{5, 5, 5, 4, 5, 5}),
+		},
+		{
+			name:   "padding longer than the record",
+			record: sealTestCBCRecord(params, plaintext, nil, bytes.Repeat([]byte{0xff}, 6)),
+		},
+		{
+			name:   "bad MAC with valid padding",
+			record: sealTestCBCRecord(params, plaintext, make([]byte, params.MACLength), bytes.Repeat([]byte{5}, 6)),
+		},
+		{
+			name:   "record not a multiple of the block size",
+			record: sealTestCBCRecord(params, plaintext, nil, bytes.Repeat([]byte{5}, 6))[:60],
+		},
+		{
+			name:   "record too short for a MAC",
+			record: sealTestCBCRecord(params, nil, make([]byte, 15), []byte{0})[:32],
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, opener := newTestCBCProtectors(t, spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA)
+
+			opened, err := opener.Open(spec.ContentTypeApplicationData, version, tc.record)
+
+			if i == 0 {
+				if err != nil || !bytes.Equal(opened, plaintext) {
+					t.Fatalf("Expected %q, got %q (%v)", plaintext, opened, err)
+				}
+				return
+			}
+			// padding and MAC failures must be indistinguishable
+			if err != ErrBadRecordMAC {
+				t.Errorf("Expected ErrBadRecordMAC, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCBCProtector_ReplayedRecord(t *testing.T) {
+	_, sealer, opener := newTestCBCProtectors(t, spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA)
+	version := spec.Tls12ProtocolVersion()
+	sealed, _ := sealer.Seal(spec.ContentTypeApplicationData, version, []byte("once"))
+
+	if _, err := opener.Open(spec.ContentTypeApplicationData, version, sealed); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_, err := opener.Open(spec.ContentTypeApplicationData, version, sealed)
+
+	if !errors.Is(err, ErrBadRecordMAC) {
+		t.Errorf("Expected ErrBadRecordMAC for replayed record, got %v", err)
+	}
+}
+
+// compressionCounter wraps the HMAC of a protector and counts the
+// compressions the inner hash of a Merkle-Damgard construction runs.
+type compressionCounter struct {
+	hash.Hash
+	buffered     int
+	compressions int
+}
+
+func (c *compressionCounter) Reset() {
+	c.Hash.Reset()
+	// the HMAC inner hash starts with one block of the padded key
+	c.buffered = c.BlockSize()
+}
+
+func (c *compressionCounter) Write(p []byte) (int, error) {
+	blockSize := c.BlockSize()
+	c.compressions += (c.buffered%blockSize + len(p)) / blockSize
+	c.buffered += len(p)
+	return c.Hash.Write(p)
+}
+
+func (c *compressionCounter) Sum(b []byte) []byte {
+	// 0x80, zeros and a length field of blockSize/8 bytes
+	blockSize := c.BlockSize()
+	padded := c.buffered%blockSize + 1 + blockSize/8
+	c.compressions += (padded + blockSize - 1) / blockSize
+	return c.Hash.Sum(b)
+}
+
+// Lucky Thirteen learns the padding length from the time the MAC takes, so a
+// record of a given length must cost the same compressions whatever it holds.
+func TestCBCProtector_OpenCompressionsIndependentOfPadding(t *testing.T) {
+	for _, cipherSuite := range []spec.CipherSuite{
+		spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA,
+		spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA256,
+		spec.CipherSuiteECDHE_RSA_WITH_AES_256_CBC_SHA384,
+	} {
+		t.Run(cipherSuite.String(), func(t *testing.T) {
+			params, _, _ := newTestCBCProtectors(t, cipherSuite)
+			// plaintext, MAC and padding fill the same 320 bytes in every record
+			payloadLength := 320
+			expected := -1
+			for paddingLength := 1; paddingLength <= maxCBCPaddingLength; paddingLength++ {
+				plaintextLength := payloadLength - params.MACLength - paddingLength
+				if plaintextLength < 0 {
+					break
+				}
+				_, _, opener := newTestCBCProtectors(t, cipherSuite)
+				counter := &compressionCounter{Hash: opener.(*cbcProtector).mac}
+				opener.(*cbcProtector).mac = counter
+				padding := bytes.Repeat([]byte{byte(paddingLength - 1)}, paddingLength)
+				record := sealTestCBCRecord(params, make([]byte, plaintextLength), nil, padding)
+
+				if _, err := opener.Open(spec.ContentTypeApplicationData, spec.Tls12ProtocolVersion(), record); err != nil {
+					t.Fatalf("Expected no error with padding length %d, got %v", paddingLength, err)
+				}
+
+				if expected == -1 {
+					expected = counter.compressions
+				}
+				if counter.compressions != expected {
+					t.Fatalf("Expected %d compressions with padding length %d, got %d", expected, paddingLength, counter.compressions)
+				}
+			}
+		})
+	}
+}
+
+func TestCBCPadding(t *testing.T) {
+	testCases := []struct {
+		name           string
+		payload        []byte
+		expectedLength int
+		expectedGood   int
+	}{
+		{name: "length byte only", payload: []byte{1, 2, 3, 0}, expectedLength: 1, expectedGood: 1},
+		{name: "three padding bytes", payload: []byte{1, 2, 2, 2}, expectedLength: 3, expectedGood: 1},
+		{name: "whole payload is padding", payload: []byte{3, 3, 3, 3}, expectedLength: 4, expectedGood: 1},
+		{name: "mismatching padding byte", payload: []byte{1, 2, 1, 2}, expectedLength: 1, expectedGood: 0},
+		{name: "padding longer than the payload", payload: []byte{4, 4, 4, 4}, expectedLength: 1, expectedGood: 0},
+		{name: "maximum padding", payload: append([]byte{9}, bytes.Repeat([]byte{0xff}, 256)...), expectedLength: 256, expectedGood: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			length, good := cbcPadding(tc.payload)
+
+			if length != tc.expectedLength || good != tc.expectedGood {
+				t.Errorf("Expected length %d (good %d), got %d (good %d)", tc.expectedLength, tc.expectedGood, length, good)
+			}
+		})
+	}
+}
+
+func TestNewProtector_InvalidCBCInput(t *testing.T) {
+	params, _ := ciphersuite.Lookup(spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA256)
+
+	if _, err := NewProtector(params, make([]byte, 16), nil, make([]byte, 20)); err == nil {
+		t.Error("Expected error for wrong MAC key length")
+	}
+	if _, err := NewProtector(params, make([]byte, 15), nil, make([]byte, 32)); err == nil {
+		t.Error("Expected error for wrong key length")
+	}
+}
+
+func newTestEncryptThenMACProtectors(t *testing.T, cipherSuite spec.CipherSuite) (*ciphersuite.Parameters, Protector, Protector) {
+	t.Helper()
+
+	params, err := ciphersuite.Lookup(cipherSuite)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	key := bytes.Repeat([]byte{0x33}, params.KeyLength)
+	macKey := bytes.Repeat([]byte{0x44}, params.MACLength)
+
+	sealer, err := NewEncryptThenMACProtector(params, key, macKey)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	opener, err := NewEncryptThenMACProtector(params, key, macKey)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return params, sealer, opener
+}
+
+func TestEncryptThenMACProtector_RecordLayout(t *testing.T) {
+	params, sealer, opener := newTestEncryptThenMACProtectors(t, spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA256)
+	version := spec.Tls12ProtocolVersion()
+	plaintext := []byte("encrypt then MAC")
+
+	sealed, err := sealer.Seal(spec.ContentTypeApplicationData, version, plaintext)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// IV || ENC(content || padding) || MAC over the IV and the ciphertext
+	ciphertext := sealed[:len(sealed)-params.MACLength]
+	h := hmac.New(params.MACHash.New, bytes.Repeat([]byte{0x44}, params.MACLength))
+	h.Write(additionalData(0, spec.ContentTypeApplicationData, version, len(ciphertext)))
+	h.Write(ciphertext)
+	if !bytes.Equal(h.Sum(nil), sealed[len(ciphertext):]) {
+		t.Error("Expected the record to end with the MAC of the IV and ciphertext")
+	}
+	if len(ciphertext) != aes.BlockSize+32 {
+		t.Errorf("Expected an IV and two blocks, got %d bytes", len(ciphertext))
+	}
+
+	opened, err := opener.Open(spec.ContentTypeApplicationData, version, sealed)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("Expected %q, got %q", plaintext, opened)
+	}
+}
+
+func TestEncryptThenMACProtector_OpenRejectsModifiedRecords(t *testing.T) {
+	version := spec.Tls12ProtocolVersion()
+
+	testCases := []struct {
+		name   string
+		mutate func(sealed []byte) []byte
+	}{
+		{
+			name: "flipped IV bit",
+			mutate: func(sealed []byte) []byte {
+				sealed[0] ^= 0x01
+				return sealed
+			},
+		},
+		{
+			name: "flipped MAC bit",
+			mutate: func(sealed []byte) []byte {
+				sealed[len(sealed)-1] ^= 0x01
+				return sealed
+			},
+		},
+		{
+			name: "truncated MAC",
+			mutate: func(sealed []byte) []byte {
+				return sealed[:len(sealed)-1]
+			},
+		},
+		{
+			name: "no ciphertext block",
+			mutate: func(sealed []byte) []byte {
+				return append(sealed[:aes.BlockSize:aes.BlockSize], sealed[len(sealed)-20:]...)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, sealer, opener := newTestEncryptThenMACProtectors(t, spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA)
+			sealed, err := sealer.Seal(spec.ContentTypeApplicationData, version, []byte("some application data"))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			_, err = opener.Open(spec.ContentTypeApplicationData, version, tc.mutate(sealed))
+
+			if err != ErrBadRecordMAC {
+				t.Errorf("Expected ErrBadRecordMAC, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEncryptThenMACProtector_BadPaddingUnderValidMAC(t *testing.T) {
+	params, _, opener := newTestEncryptThenMACProtectors(t, spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA)
+	version := spec.Tls12ProtocolVersion()
+	block, _ := aes.NewCipher(bytes.Repeat([]byte{0x33}, params.KeyLength))
+	record := make([]byte, aes.BlockSize)
+	record = append(record, []byte("fifteen bytes!!")...)
+	record = append(record, 7)
+	cipher.NewCBCEncrypter(block, record[:aes.BlockSize]).CryptBlocks(record[aes.BlockSize:], record[aes.BlockSize:])
+	h := hmac.New(params.MACHash.New, bytes.Repeat([]byte{0x44}, params.MACLength))
+	h.Write(additionalData(0, spec.ContentTypeApplicationData, version, len(record)))
+	h.Write(record)
+	record = h.Sum(record)
+
+	_, err := opener.Open(spec.ContentTypeApplicationData, version, record)
+
+	if err != ErrBadRecordMAC {
+		t.Errorf("Expected ErrBadRecordMAC, got %v", err)
+	}
+}
+
+func TestEncryptThenMACProtector_ModesDoNotMix(t *testing.T) {
+	_, sealer, _ := newTestEncryptThenMACProtectors(t, spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA)
+	_, _, opener := newTestCBCProtectors(t, spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA)
+	version := spec.Tls12ProtocolVersion()
+	sealed, _ := sealer.Seal(spec.ContentTypeApplicationData, version, []byte("encrypt then MAC"))
+
+	_, err := opener.Open(spec.ContentTypeApplicationData, version, sealed)
+
+	if err != ErrBadRecordMAC {
+		t.Errorf("Expected ErrBadRecordMAC, got %v", err)
+	}
+}
+
+func TestNewEncryptThenMACProtector_AEADSuite(t *testing.T) {
+	params, _ := ciphersuite.Lookup(spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256)
+
+	_, err := NewEncryptThenMACProtector(params, make([]byte, 16), nil)
+
+	if !errors.Is(err, errUnsupportedProtectorCipher) {
+		t.Errorf("Expected errUnsupportedProtectorCipher, got %v", err)
+	}
+}
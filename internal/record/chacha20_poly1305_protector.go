@@ -0,0 +1,75 @@
+package record
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/piligrimm/tls/internal/chacha20poly1305"
+	"github.com/piligrimm/tls/spec"
+)
+
+// chacha20Poly1305Protector implements the ChaCha20-Poly1305 record protection
+// of RFC 7905. Records carry no explicit nonce: the nonce is the 12-byte IV
+// from the key block XORed with the sequence number, left-padded to 12 bytes.
+type chacha20Poly1305Protector struct {
+	aead cipher.AEAD
+	iv   []byte
+	seq  sequenceNumber
+}
+
+func newChaCha20Poly1305Protector(key, iv []byte) (*chacha20Poly1305Protector, error) {
+	if len(iv) != chacha20poly1305.NonceSize {
+		return nil, fmt.Errorf("ChaCha20-Poly1305 IV must contain %d bytes, got %d", chacha20poly1305.NonceSize, len(iv))
+	}
+
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chacha20Poly1305Protector{
+		aead: aead,
+		iv:   append([]byte(nil), iv...),
+	}, nil
+}
+
+func (c *chacha20Poly1305Protector) nonce(seq uint64) []byte {
+	nonce := append([]byte(nil), c.iv...)
+	var padded [chacha20poly1305.NonceSize]byte
+	binary.BigEndian.PutUint64(padded[4:], seq)
+	for i := range nonce {
+		nonce[i] ^= padded[i]
+	}
+	return nonce
+}
+
+func (c *chacha20Poly1305Protector) Seal(contentType spec.ContentType, version spec.ProtocolVersion, plaintext []byte) ([]byte, error) {
+	seq, err := c.seq.next()
+	if err != nil {
+		return nil, err
+	}
+
+	ad := additionalData(seq, contentType, version, len(plaintext))
+	out := make([]byte, 0, len(plaintext)+c.aead.Overhead())
+	return c.aead.Seal(out, c.nonce(seq), plaintext, ad), nil
+}
+
+func (c *chacha20Poly1305Protector) Open(contentType spec.ContentType, version spec.ProtocolVersion, ciphertext []byte) ([]byte, error) {
+	seq, err := c.seq.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < c.aead.Overhead() {
+		return nil, ErrBadRecordMAC
+	}
+
+	ad := additionalData(seq, contentType, version, len(ciphertext)-c.aead.Overhead())
+	plaintext, err := c.aead.Open(nil, c.nonce(seq), ciphertext, ad)
+	if err != nil {
+		return nil, ErrBadRecordMAC
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,135 @@
+package record
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/piligrimm/tls/internal/chacha20poly1305"
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/spec"
+)
+
+var testChaCha20IV = []byte{0x07, 0x00, 0x00, 0x00, 0x40, 0x41, 0x42, 0x43, 0x44, 0x45, 0x46, 0x47}
+
+func newTestChaCha20Poly1305Protectors(t *testing.T) (Protector, Protector) {
+	t.Helper()
+
+	params, err := ciphersuite.Lookup(spec.CipherSuiteECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	key := bytes.Repeat([]byte{0x22}, 32)
+
+	sealer, err := NewProtector(params, key, testChaCha20IV, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	opener, err := NewProtector(params, key, testChaCha20IV, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return sealer, opener
+}
+
+func TestChaCha20Poly1305Protector_NonceAndAdditionalData(t *testing.T) {
+	sealer, _ := newTestChaCha20Poly1305Protectors(t)
+	plaintext := []byte("hello")
+	version := spec.Tls12ProtocolVersion()
+
+	if _, err := sealer.Seal(spec.ContentTypeHandshake, version, []byte("first")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sealed, err := sealer.Seal(spec.ContentTypeApplicationData, version, plaintext)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// the second record XORs sequence number 1 into the last IV byte and sends no explicit nonce
+	aead, _ := chacha20poly1305.New(bytes.Repeat([]byte{0x22}, 32))
+	nonce := []byte{0x07, 0x00, 0x00, 0x00, 0x40, 0x41, 0x42, 0x43, 0x44, 0x45, 0x46, 0x46}
+	ad := []byte{0, 0, 0, 0, 0, 0, 0, 1, 0x17, 0x03, 0x03, 0x00, 0x05}
+	expected := aead.Seal(nil, nonce, plaintext, ad)
+
+	if !bytes.Equal(sealed, expected) {
+		t.Errorf("Expected %x, got %x", expected, sealed)
+	}
+}
+
+func TestChaCha20Poly1305Protector_RoundTrip(t *testing.T) {
+	sealer, opener := newTestChaCha20Poly1305Protectors(t)
+	version := spec.Tls12ProtocolVersion()
+
+	for i, message := range []string{"first", "", "third"} {
+		sealed, err := sealer.Seal(spec.ContentTypeApplicationData, version, []byte(message))
+		if err != nil {
+			t.Fatalf("Expected no error sealing record %d, got %v", i, err)
+		}
+
+		opened, err := opener.Open(spec.ContentTypeApplicationData, version, sealed)
+		if err != nil {
+			t.Fatalf("Expected no error opening record %d, got %v", i, err)
+		}
+		if string(opened) != message {
+			t.Errorf("Expected %q, got %q", message, opened)
+		}
+	}
+}
+
+func TestChaCha20Poly1305Protector_OpenRejectsModifiedRecords(t *testing.T) {
+	version := spec.Tls12ProtocolVersion()
+
+	testCases := []struct {
+		name   string
+		mutate func(sealed []byte) (spec.ContentType, []byte)
+	}{
+		{
+			name: "flipped ciphertext bit",
+			mutate: func(sealed []byte) (spec.ContentType, []byte) {
+				sealed[3] ^= 0x01
+				return spec.ContentTypeApplicationData, sealed
+			},
+		},
+		{
+			name: "different content type",
+			mutate: func(sealed []byte) (spec.ContentType, []byte) {
+				return spec.ContentTypeHandshake, sealed
+			},
+		},
+		{
+			name: "shorter than the tag",
+			mutate: func(sealed []byte) (spec.ContentType, []byte) {
+				return spec.ContentTypeApplicationData, sealed[:15]
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sealer, opener := newTestChaCha20Poly1305Protectors(t)
+			sealed, err := sealer.Seal(spec.ContentTypeApplicationData, version, []byte("some application data"))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			contentType, mutated := tc.mutate(sealed)
+			_, err = opener.Open(contentType, version, mutated)
+
+			if !errors.Is(err, ErrBadRecordMAC) {
+				t.Errorf("Expected ErrBadRecordMAC, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewProtector_InvalidChaCha20Poly1305Input(t *testing.T) {
+	params, _ := ciphersuite.Lookup(spec.CipherSuiteECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256)
+
+	if _, err := NewProtector(params, make([]byte, 32), make([]byte, 4), nil); err == nil {
+		t.Error("Expected error for wrong IV length")
+	}
+	if _, err := NewProtector(params, make([]byte, 16), make([]byte, 12), nil); err == nil {
+		t.Error("Expected error for wrong key length")
+	}
+}
@@ -0,0 +1,53 @@
+package record
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/internal/utils"
+	"github.com/piligrimm/tls/spec"
+)
+
+const (
+	HeaderLength = 5
+
+	// MaxPlaintextLength is 2^14, the largest fragment a TLSPlaintext may carry.
+	MaxPlaintextLength = 1 << 14
+)
+
+func MarshalRecord(record *spec.TLSPlaintext) []byte {
+	payload := make([]byte, 0, HeaderLength+len(record.Fragment))
+	payload = append(payload, byte(record.ContentType))
+	payload = append(payload, record.Version.Major, record.Version.Minor)
+	payload = binary.BigEndian.AppendUint16(payload, utils.CastUint16OrPanic(len(record.Fragment)))
+	payload = append(payload, record.Fragment...)
+
+	return payload
+}
+
+func unmarshalHeader(raw []byte, maxFragmentLength int) (spec.ContentType, spec.ProtocolVersion, int, error) {
+	if len(raw) < HeaderLength {
+		return 0, spec.ProtocolVersion{}, 0, fmt.Errorf("truncated record header, need %d bytes", HeaderLength)
+	}
+
+	contentType := spec.ContentType(raw[0])
+	if !slices.Contains(spec.ContentTypes(), contentType) {
+		return 0, spec.ProtocolVersion{}, 0, alert.Errorf(spec.AlertDescriptionUnexpectedMessage, "unknown record content type: %v", contentType)
+	}
+
+	// the record version of an initial ClientHello may be anything from 0x0300 up,
+	// so only the major version is checked here
+	version := spec.ProtocolVersion{Major: raw[1], Minor: raw[2]}
+	if version.Major != spec.Tls12ProtocolVersion().Major {
+		return 0, spec.ProtocolVersion{}, 0, alert.Errorf(spec.AlertDescriptionProtocolVersion, "unsupported record version %d.%d", version.Major, version.Minor)
+	}
+
+	length := int(binary.BigEndian.Uint16(raw[3:5]))
+	if length > maxFragmentLength {
+		return 0, spec.ProtocolVersion{}, 0, alert.Errorf(spec.AlertDescriptionRecordOverflow, "record fragment length %d exceeds %d", length, maxFragmentLength)
+	}
+
+	return contentType, version, length, nil
+}
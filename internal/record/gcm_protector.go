@@ -0,0 +1,86 @@
+package record
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+const (
+	gcmImplicitNonceLength = 4
+	gcmExplicitNonceLength = 8
+)
+
+// gcmProtector implements the AES-GCM record protection of RFC 5288. The nonce
+// is the 4-byte implicit salt from the key block followed by an 8-byte explicit
+// part sent in front of every record, for which the sequence number is used.
+type gcmProtector struct {
+	aead cipher.AEAD
+	salt []byte
+	seq  sequenceNumber
+}
+
+func newGCMProtector(key, salt []byte) (*gcmProtector, error) {
+	if len(salt) != gcmImplicitNonceLength {
+		return nil, fmt.Errorf("AES-GCM implicit nonce must contain %d bytes, got %d", gcmImplicitNonceLength, len(salt))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcmProtector{
+		aead: aead,
+		salt: append([]byte(nil), salt...),
+	}, nil
+}
+
+func (g *gcmProtector) nonce(explicit []byte) []byte {
+	nonce := make([]byte, 0, gcmImplicitNonceLength+gcmExplicitNonceLength)
+	nonce = append(nonce, g.salt...)
+	return append(nonce, explicit...)
+}
+
+func (g *gcmProtector) Seal(contentType spec.ContentType, version spec.ProtocolVersion, plaintext []byte) ([]byte, error) {
+	seq, err := g.seq.next()
+	if err != nil {
+		return nil, err
+	}
+
+	explicit := binary.BigEndian.AppendUint64(nil, seq)
+	ad := additionalData(seq, contentType, version, len(plaintext))
+
+	out := make([]byte, 0, gcmExplicitNonceLength+len(plaintext)+g.aead.Overhead())
+	out = append(out, explicit...)
+	return g.aead.Seal(out, g.nonce(explicit), plaintext, ad), nil
+}
+
+func (g *gcmProtector) Open(contentType spec.ContentType, version spec.ProtocolVersion, ciphertext []byte) ([]byte, error) {
+	seq, err := g.seq.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcmExplicitNonceLength+g.aead.Overhead() {
+		return nil, ErrBadRecordMAC
+	}
+
+	explicit := ciphertext[:gcmExplicitNonceLength]
+	sealed := ciphertext[gcmExplicitNonceLength:]
+	ad := additionalData(seq, contentType, version, len(sealed)-g.aead.Overhead())
+
+	plaintext, err := g.aead.Open(nil, g.nonce(explicit), sealed, ad)
+	if err != nil {
+		return nil, ErrBadRecordMAC
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,195 @@
+package record
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/spec"
+)
+
+func newTestGCMProtectors(t *testing.T) (Protector, Protector) {
+	t.Helper()
+
+	params, err := ciphersuite.Lookup(spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	key := bytes.Repeat([]byte{0x11}, 16)
+	salt := []byte{0xde, 0xad, 0xbe, 0xef}
+
+	sealer, err := NewProtector(params, key, salt, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	opener, err := NewProtector(params, key, salt, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return sealer, opener
+}
+
+func TestGCMProtector_NonceAndAdditionalData(t *testing.T) {
+	sealer, _ := newTestGCMProtectors(t)
+	plaintext := []byte("hello")
+	version := spec.Tls12ProtocolVersion()
+
+	if _, err := sealer.Seal(spec.ContentTypeHandshake, version, []byte("first")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sealed, err := sealer.Seal(spec.ContentTypeApplicationData, version, plaintext)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// the second record uses sequence number 1 for both the explicit nonce and the additional data
+	block, _ := aes.NewCipher(bytes.Repeat([]byte{0x11}, 16))
+	aead, _ := cipher.NewGCM(block)
+	explicit := []byte{0, 0, 0, 0, 0, 0, 0, 1}
+	nonce := append([]byte{0xde, 0xad, 0xbe, 0xef}, explicit...)
+	ad := []byte{0, 0, 0, 0, 0, 0, 0, 1, 0x17, 0x03, 0x03, 0x00, 0x05}
+	expected := aead.Seal(append([]byte(nil), explicit...), nonce, plaintext, ad)
+
+	if !bytes.Equal(sealed, expected) {
+		t.Errorf("Expected %x, got %x", expected, sealed)
+	}
+}
+
+func TestGCMProtector_RoundTrip(t *testing.T) {
+	sealer, opener := newTestGCMProtectors(t)
+	version := spec.Tls12ProtocolVersion()
+
+	for i, message := range []string{"first", "", "third"} {
+		sealed, err := sealer.Seal(spec.ContentTypeApplicationData, version, []byte(message))
+		if err != nil {
+			t.Fatalf("Expected no error sealing record %d, got %v", i, err)
+		}
+
+		opened, err := opener.Open(spec.ContentTypeApplicationData, version, sealed)
+		if err != nil {
+			t.Fatalf("Expected no error opening record %d, got %v", i, err)
+		}
+		if string(opened) != message {
+			t.Errorf("Expected %q, got %q", message, opened)
+		}
+	}
+}
+
+func TestGCMProtector_OpenRejectsModifiedRecords(t *testing.T) {
+	version := spec.Tls12ProtocolVersion()
+
+	testCases := []struct {
+		name   string
+		mutate func(sealed []byte) (spec.ContentType, []byte)
+	}{
+		{
+			name: "flipped ciphertext bit",
+			mutate: func(sealed []byte) (spec.ContentType, []byte) {
+				sealed[10] ^= 0x01
+				return spec.ContentTypeApplicationData, sealed
+			},
+		},
+		{
+			name: "different content type",
+			mutate: func(sealed []byte) (spec.ContentType, []byte) {
+				return spec.ContentTypeHandshake, sealed
+			},
+		},
+		{
+			name: "truncated record",
+			mutate: func(sealed []byte) (spec.ContentType, []byte) {
+				return spec.ContentTypeApplicationData, sealed[:20]
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sealer, opener := newTestGCMProtectors(t)
+			sealed, err := sealer.Seal(spec.ContentTypeApplicationData, version, []byte("some application data"))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			contentType, mutated := tc.mutate(sealed)
+			_, err = opener.Open(contentType, version, mutated)
+
+			if !errors.Is(err, ErrBadRecordMAC) {
+				t.Errorf("Expected ErrBadRecordMAC, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGCMProtector_ReplayedRecord(t *testing.T) {
+	sealer, opener := newTestGCMProtectors(t)
+	version := spec.Tls12ProtocolVersion()
+	sealed, _ := sealer.Seal(spec.ContentTypeApplicationData, version, []byte("once"))
+
+	if _, err := opener.Open(spec.ContentTypeApplicationData, version, sealed); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_, err := opener.Open(spec.ContentTypeApplicationData, version, sealed)
+
+	if !errors.Is(err, ErrBadRecordMAC) {
+		t.Errorf("Expected ErrBadRecordMAC for replayed record, got %v", err)
+	}
+}
+
+func TestGCMProtector_SequenceNumberDoesNotWrap(t *testing.T) {
+	sealer, _ := newTestGCMProtectors(t)
+	sealer.(*gcmProtector).seq.value = math.MaxUint64
+	version := spec.Tls12ProtocolVersion()
+
+	if _, err := sealer.Seal(spec.ContentTypeApplicationData, version, []byte("last")); err != nil {
+		t.Fatalf("Expected last sequence number to be usable, got %v", err)
+	}
+	_, err := sealer.Seal(spec.ContentTypeApplicationData, version, []byte("wrapped"))
+
+	if !errors.Is(err, ErrSequenceNumberExhausted) {
+		t.Errorf("Expected ErrSequenceNumberExhausted, got %v", err)
+	}
+}
+
+func TestNewProtector_InvalidInput(t *testing.T) {
+	gcmParams, _ := ciphersuite.Lookup(spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256)
+
+	if _, err := NewProtector(gcmParams, make([]byte, 16), make([]byte, 12), nil); err == nil {
+		t.Error("Expected error for wrong implicit nonce length")
+	}
+	if _, err := NewProtector(gcmParams, make([]byte, 15), make([]byte, 4), nil); err == nil {
+		t.Error("Expected error for wrong key length")
+	}
+}
+
+func TestReaderWriter_WithProtectors(t *testing.T) {
+	sealer, opener := newTestGCMProtectors(t)
+	var stream bytes.Buffer
+	writer := NewWriter(&stream, spec.Tls12ProtocolVersion())
+	writer.SetProtector(sealer)
+	payload := bytes.Repeat([]byte{0x5a}, MaxPlaintextLength+1)
+
+	if err := writer.WriteRecords(spec.ContentTypeApplicationData, payload); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	reader := NewReader(&stream)
+	reader.SetProtector(opener)
+	var joined []byte
+	for range 2 {
+		record, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		joined = append(joined, record.Fragment...)
+	}
+
+	if !bytes.Equal(joined, payload) {
+		t.Error("Decrypted payload does not match")
+	}
+}
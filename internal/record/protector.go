@@ -0,0 +1,74 @@
+package record
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/spec"
+)
+
+// MaxCiphertextLength is 2^14 + 2048, the largest fragment a protected record may carry.
+const MaxCiphertextLength = MaxPlaintextLength + 2048
+
+var (
+	ErrBadRecordMAC               = alert.New(spec.AlertDescriptionBadRecordMAC, "bad record MAC")
+	ErrSequenceNumberExhausted    = errors.New("record sequence number exhausted, connection must be closed")
+	errUnsupportedProtectorCipher = errors.New("unsupported record protection cipher")
+)
+
+// Protector seals or opens the fragments of one direction of a connection.
+// Every call consumes a sequence number.
+type Protector interface {
+	Seal(contentType spec.ContentType, version spec.ProtocolVersion, plaintext []byte) ([]byte, error)
+	Open(contentType spec.ContentType, version spec.ProtocolVersion, ciphertext []byte) ([]byte, error)
+}
+
+func NewProtector(params *ciphersuite.Parameters, key, iv, macKey []byte) (Protector, error) {
+	switch params.Cipher {
+	case ciphersuite.CipherAESGCM:
+		return newGCMProtector(key, iv)
+	case ciphersuite.CipherChaCha20Poly1305:
+		return newChaCha20Poly1305Protector(key, iv)
+	case ciphersuite.CipherAESCBC:
+		return newCBCProtector(params, key, macKey)
+	default:
+		return nil, fmt.Errorf("%w: %v", errUnsupportedProtectorCipher, params.ID)
+	}
+}
+
+type sequenceNumber struct {
+	value     uint64
+	exhausted bool
+}
+
+// next returns the current sequence number and advances it. It never wraps:
+// once 2^64-1 has been used every further call fails.
+func (s *sequenceNumber) next() (uint64, error) {
+	if s.exhausted {
+		return 0, ErrSequenceNumberExhausted
+	}
+
+	value := s.value
+	if value == math.MaxUint64 {
+		s.exhausted = true
+	} else {
+		s.value++
+	}
+
+	return value, nil
+}
+
+// additionalData returns seq_num + type + version + length as used by the
+// AEAD ciphers and the record MAC.
+func additionalData(seq uint64, contentType spec.ContentType, version spec.ProtocolVersion, length int) []byte {
+	ad := make([]byte, 0, 13)
+	ad = binary.BigEndian.AppendUint64(ad, seq)
+	ad = append(ad, byte(contentType), version.Major, version.Minor)
+	ad = binary.BigEndian.AppendUint16(ad, uint16(length))
+
+	return ad
+}
@@ -0,0 +1,100 @@
+package record
+
+import (
+	"errors"
+	"io"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/spec"
+)
+
+const readChunkLength = 4096
+
+type Reader struct {
+	r   io.Reader
+	buf []byte
+
+	maxFragmentLength int
+	protector         Protector
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:                 r,
+		maxFragmentLength: MaxPlaintextLength,
+	}
+}
+
+// SetProtector makes every following record be opened with p. It is called
+// once the peer's ChangeCipherSpec has been received.
+func (r *Reader) SetProtector(p Protector) {
+	r.protector = p
+	r.maxFragmentLength = MaxCiphertextLength
+}
+
+// ReadRecord returns the next record from the stream. Bytes read past the end
+// of the record are kept for the following call, so a single Read may carry
+// several records and a record may span several Reads.
+func (r *Reader) ReadRecord() (*spec.TLSPlaintext, error) {
+	if err := r.fill(HeaderLength); err != nil {
+		return nil, err
+	}
+
+	contentType, version, length, err := unmarshalHeader(r.buf[:HeaderLength], r.maxFragmentLength)
+	if err != nil {
+		return nil, err
+	}
+
+	total := HeaderLength + length
+	if err := r.fill(total); err != nil {
+		return nil, err
+	}
+
+	fragment := append([]byte(nil), r.buf[HeaderLength:total]...)
+	r.buf = r.buf[total:]
+
+	if r.protector != nil {
+		fragment, err = r.protector.Open(contentType, version, fragment)
+		if err != nil {
+			return nil, err
+		}
+		if len(fragment) > MaxPlaintextLength {
+			return nil, alert.Errorf(spec.AlertDescriptionRecordOverflow, "decrypted record fragment length %d exceeds %d", len(fragment), MaxPlaintextLength)
+		}
+	}
+
+	if len(fragment) == 0 && contentType != spec.ContentTypeApplicationData {
+		return nil, alert.New(spec.AlertDescriptionUnexpectedMessage, "empty record fragment is only allowed for application data")
+	}
+
+	return &spec.TLSPlaintext{
+		ContentType: contentType,
+		Version:     version,
+		Fragment:    fragment,
+	}, nil
+}
+
+func (r *Reader) fill(n int) error {
+	for len(r.buf) < n {
+		if cap(r.buf)-len(r.buf) < readChunkLength {
+			grown := make([]byte, len(r.buf), max(n, len(r.buf)+readChunkLength))
+			copy(grown, r.buf)
+			r.buf = grown
+		}
+
+		m, err := r.r.Read(r.buf[len(r.buf):cap(r.buf)])
+		r.buf = r.buf[:len(r.buf)+m]
+		if len(r.buf) >= n {
+			return nil
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(r.buf) > 0 {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package record
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+type oneByteReader struct {
+	r io.Reader
+}
+
+func (o *oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return o.r.Read(p[:1])
+}
+
+func TestReadRecord_SeveralRecordsInOneRead(t *testing.T) {
+	raw := []byte{
+		0x16, 0x03, 0x03, 0x00, 0x02, 0xaa, 0xbb,
+		0x15, 0x03, 0x03, 0x00, 0x02, 0x02, 0x28,
+	}
+	reader := NewReader(bytes.NewReader(raw))
+
+	first, err := reader.ReadRecord()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.ContentType != spec.ContentTypeHandshake {
+		t.Errorf("Expected content type %v, got %v", spec.ContentTypeHandshake, first.ContentType)
+	}
+	if !bytes.Equal(first.Fragment, []byte{0xaa, 0xbb}) {
+		t.Errorf("Unexpected fragment: %x", first.Fragment)
+	}
+
+	second, err := reader.ReadRecord()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if second.ContentType != spec.ContentTypeAlert {
+		t.Errorf("Expected content type %v, got %v", spec.ContentTypeAlert, second.ContentType)
+	}
+	if !bytes.Equal(second.Fragment, []byte{0x02, 0x28}) {
+		t.Errorf("Unexpected fragment: %x", second.Fragment)
+	}
+
+	if _, err := reader.ReadRecord(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestReadRecord_PartialReads(t *testing.T) {
+	fragment := bytes.Repeat([]byte{0x42}, 5000)
+	raw := MarshalRecord(&spec.TLSPlaintext{
+		ContentType: spec.ContentTypeApplicationData,
+		Version:     spec.Tls12ProtocolVersion(),
+		Fragment:    fragment,
+	})
+	reader := NewReader(&oneByteReader{r: bytes.NewReader(raw)})
+
+	record, err := reader.ReadRecord()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if record.Version != spec.Tls12ProtocolVersion() {
+		t.Errorf("Expected version %v, got %v", spec.Tls12ProtocolVersion(), record.Version)
+	}
+	if !bytes.Equal(record.Fragment, fragment) {
+		t.Errorf("Fragment mismatch")
+	}
+}
+
+func TestReadRecord_TruncatedRecord(t *testing.T) {
+	raw := []byte{0x16, 0x03, 0x03, 0x00, 0x04, 0x01, 0x02}
+	reader := NewReader(bytes.NewReader(raw))
+
+	_, err := reader.ReadRecord()
+
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestReadRecord_InvalidHeader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "unknown content type",
+			raw:      []byte{0x63, 0x03, 0x03, 0x00, 0x01, 0x00},
+			expected: "unknown record content type: ContentType(99)",
+		},
+		{
+			name:     "unsupported version",
+			raw:      []byte{0x16, 0x02, 0x00, 0x00, 0x01, 0x00},
+			expected: "unsupported record version 2.0",
+		},
+		{
+			name:     "fragment too long",
+			raw:      []byte{0x17, 0x03, 0x03, 0x40, 0x01},
+			expected: "record fragment length 16385 exceeds 16384",
+		},
+		{
+			name:     "empty handshake fragment",
+			raw:      []byte{0x16, 0x03, 0x03, 0x00, 0x00},
+			expected: "empty record fragment is only allowed for application data",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := NewReader(bytes.NewReader(tc.raw))
+
+			_, err := reader.ReadRecord()
+
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if err.Error() != tc.expected {
+				t.Errorf("Expected error message %q, got %q", tc.expected, err.Error())
+			}
+		})
+	}
+}
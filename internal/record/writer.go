@@ -0,0 +1,60 @@
+package record
+
+import (
+	"errors"
+	"io"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+type Writer struct {
+	w         io.Writer
+	version   spec.ProtocolVersion
+	protector Protector
+}
+
+func NewWriter(w io.Writer, version spec.ProtocolVersion) *Writer {
+	return &Writer{
+		w:       w,
+		version: version,
+	}
+}
+
+// SetProtector makes every following record be sealed with p. It is called
+// right after our ChangeCipherSpec has been written.
+func (w *Writer) SetProtector(p Protector) {
+	w.protector = p
+}
+
+// WriteRecords splits payload into records of at most MaxPlaintextLength bytes
+// and writes them with a single call to the underlying writer.
+func (w *Writer) WriteRecords(contentType spec.ContentType, payload []byte) error {
+	if len(payload) == 0 {
+		if contentType == spec.ContentTypeApplicationData {
+			return nil
+		}
+		return errors.New("empty record fragment is only allowed for application data")
+	}
+
+	raw := make([]byte, 0, len(payload)+HeaderLength*(len(payload)/MaxPlaintextLength+1))
+	for off := 0; off < len(payload); off += MaxPlaintextLength {
+		end := min(off+MaxPlaintextLength, len(payload))
+		fragment := payload[off:end]
+		if w.protector != nil {
+			var err error
+			fragment, err = w.protector.Seal(contentType, w.version, fragment)
+			if err != nil {
+				return err
+			}
+		}
+
+		raw = append(raw, MarshalRecord(&spec.TLSPlaintext{
+			ContentType: contentType,
+			Version:     w.version,
+			Fragment:    fragment,
+		})...)
+	}
+
+	_, err := w.w.Write(raw)
+	return err
+}
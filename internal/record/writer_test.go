@@ -0,0 +1,70 @@
+package record
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func TestWriteRecords_SplitsLargePayload(t *testing.T) {
+	var out bytes.Buffer
+	writer := NewWriter(&out, spec.Tls12ProtocolVersion())
+	payload := bytes.Repeat([]byte{0x01}, 2*MaxPlaintextLength+10)
+
+	if err := writer.WriteRecords(spec.ContentTypeApplicationData, payload); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	reader := NewReader(&out)
+	expectedLengths := []int{MaxPlaintextLength, MaxPlaintextLength, 10}
+	var joined []byte
+	for i, expectedLength := range expectedLengths {
+		record, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatalf("Expected no error for record %d, got %v", i, err)
+		}
+		if len(record.Fragment) != expectedLength {
+			t.Errorf("Expected record %d to be %d bytes, got %d", i, expectedLength, len(record.Fragment))
+		}
+		joined = append(joined, record.Fragment...)
+	}
+
+	if !bytes.Equal(joined, payload) {
+		t.Error("Joined fragments do not match payload")
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no trailing bytes, got %d", out.Len())
+	}
+}
+
+func TestWriteRecords_Header(t *testing.T) {
+	var out bytes.Buffer
+	writer := NewWriter(&out, spec.Tls12ProtocolVersion())
+
+	if err := writer.WriteRecords(spec.ContentTypeHandshake, []byte{0x0e, 0x00, 0x00, 0x00}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []byte{0x16, 0x03, 0x03, 0x00, 0x04, 0x0e, 0x00, 0x00, 0x00}
+	if !bytes.Equal(out.Bytes(), expected) {
+		t.Errorf("Expected %x, got %x", expected, out.Bytes())
+	}
+}
+
+func TestWriteRecords_EmptyPayload(t *testing.T) {
+	var out bytes.Buffer
+	writer := NewWriter(&out, spec.Tls12ProtocolVersion())
+
+	if err := writer.WriteRecords(spec.ContentTypeApplicationData, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing to be written, got %d bytes", out.Len())
+	}
+
+	err := writer.WriteRecords(spec.ContentTypeHandshake, nil)
+	if err == nil {
+		t.Fatal("Expected error for empty handshake payload")
+	}
+}
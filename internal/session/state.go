@@ -0,0 +1,88 @@
+// Package session holds the state needed to resume a TLS 1.2 session and
+// protects it inside session tickets.
+package session
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/piligrimm/tls/internal/prf"
+	"github.com/piligrimm/tls/spec"
+)
+
+// State is what an abbreviated handshake needs from the session it resumes.
+type State struct {
+	Version              spec.ProtocolVersion
+	CipherSuite          spec.CipherSuite
+	MasterSecret         []byte
+	ExtendedMasterSecret bool
+	EncryptThenMAC       bool
+	CreatedAt            time.Time
+	// ServerName is the server_name the session was established for, empty
+	// when the client sent none
+	ServerName string
+}
+
+// fixedStateLength is version(2) + cipher_suite(2) + flags(1) + created_at(8) +
+// master_secret(48), the server name follows with a one-byte length.
+const fixedStateLength = 2 + 2 + 1 + 8 + prf.MasterSecretLength
+
+// the flags byte holds the per-session extensions, other bits must be zero
+const (
+	flagExtendedMasterSecret = 1 << iota
+	flagEncryptThenMAC
+)
+
+func MarshalState(state *State) ([]byte, error) {
+	if len(state.MasterSecret) != prf.MasterSecretLength {
+		return nil, fmt.Errorf("master secret must contain %d bytes, got %d", prf.MasterSecretLength, len(state.MasterSecret))
+	}
+	if len(state.ServerName) > math.MaxUint8 {
+		return nil, fmt.Errorf("server name cannot be longer than %d bytes", math.MaxUint8)
+	}
+
+	payload := make([]byte, 0, fixedStateLength+1+len(state.ServerName))
+	payload = append(payload, state.Version.Major, state.Version.Minor)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(state.CipherSuite))
+	var flags byte
+	if state.ExtendedMasterSecret {
+		flags |= flagExtendedMasterSecret
+	}
+	if state.EncryptThenMAC {
+		flags |= flagEncryptThenMAC
+	}
+	payload = append(payload, flags)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(state.CreatedAt.Unix()))
+	payload = append(payload, state.MasterSecret...)
+	payload = append(payload, byte(len(state.ServerName)))
+	payload = append(payload, state.ServerName...)
+
+	return payload, nil
+}
+
+func UnmarshalState(raw []byte) (*State, error) {
+	if len(raw) < fixedStateLength+1 {
+		return nil, fmt.Errorf("session state must contain at least %d bytes, got %d", fixedStateLength+1, len(raw))
+	}
+	serverNameLen := int(raw[fixedStateLength])
+	if len(raw)-fixedStateLength-1 != serverNameLen {
+		return nil, fmt.Errorf("session state server name length %d does not match %d remaining bytes", serverNameLen, len(raw)-fixedStateLength-1)
+	}
+	flags := raw[4]
+	if flags&^(flagExtendedMasterSecret|flagEncryptThenMAC) != 0 {
+		return nil, errors.New("invalid flags in session state")
+	}
+
+	return &State{
+		Version:              spec.ProtocolVersion{Major: raw[0], Minor: raw[1]},
+		CipherSuite:          spec.CipherSuite(binary.BigEndian.Uint16(raw[2:4])),
+		ExtendedMasterSecret: flags&flagExtendedMasterSecret != 0,
+		EncryptThenMAC:       flags&flagEncryptThenMAC != 0,
+		CreatedAt:            time.Unix(int64(binary.BigEndian.Uint64(raw[5:13])), 0),
+		MasterSecret:         append([]byte(nil), raw[13:fixedStateLength]...),
+		ServerName:           string(raw[fixedStateLength+1:]),
+	}, nil
+}
@@ -0,0 +1,111 @@
+package session
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+func newTestState() *State {
+	return &State{
+		Version:              spec.Tls12ProtocolVersion(),
+		CipherSuite:          spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		MasterSecret:         bytes.Repeat([]byte{0xab}, 48),
+		ExtendedMasterSecret: true,
+		EncryptThenMAC:       true,
+		CreatedAt:            time.Unix(1700000000, 0),
+		ServerName:           "example.com",
+	}
+}
+
+func TestState_RoundTrip(t *testing.T) {
+	state := newTestState()
+
+	raw, err := MarshalState(state)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	parsed, err := UnmarshalState(raw)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if parsed.Version != state.Version || parsed.CipherSuite != state.CipherSuite {
+		t.Errorf("Expected %v %v, got %v %v", state.Version, state.CipherSuite, parsed.Version, parsed.CipherSuite)
+	}
+	if !bytes.Equal(parsed.MasterSecret, state.MasterSecret) {
+		t.Errorf("Expected master secret %x, got %x", state.MasterSecret, parsed.MasterSecret)
+	}
+	if !parsed.ExtendedMasterSecret || !parsed.EncryptThenMAC {
+		t.Error("Expected the extended master secret and encrypt_then_mac flags to be kept")
+	}
+	if !parsed.CreatedAt.Equal(state.CreatedAt) {
+		t.Errorf("Expected %v, got %v", state.CreatedAt, parsed.CreatedAt)
+	}
+	if parsed.ServerName != state.ServerName {
+		t.Errorf("Expected server name %q, got %q", state.ServerName, parsed.ServerName)
+	}
+}
+
+func TestMarshalState_InvalidMasterSecret(t *testing.T) {
+	state := newTestState()
+	state.MasterSecret = state.MasterSecret[:47]
+
+	_, err := MarshalState(state)
+
+	if err == nil || err.Error() != "master secret must contain 48 bytes, got 47" {
+		t.Errorf("Expected master secret length error, got %v", err)
+	}
+}
+
+func TestMarshalState_ServerNameTooLong(t *testing.T) {
+	state := newTestState()
+	state.ServerName = strings.Repeat("a", 256)
+
+	_, err := MarshalState(state)
+
+	if err == nil || err.Error() != "server name cannot be longer than 255 bytes" {
+		t.Errorf("Expected server name length error, got %v", err)
+	}
+}
+
+func TestUnmarshalState_InvalidInput(t *testing.T) {
+	raw, _ := MarshalState(newTestState())
+	badFlag := append([]byte(nil), raw...)
+	badFlag[4] = 4
+
+	testCases := []struct {
+		name     string
+		raw      []byte
+		expected string
+	}{
+		{
+			name:     "truncated",
+			raw:      raw[:61],
+			expected: "session state must contain at least 62 bytes, got 61",
+		},
+		{
+			name:     "truncated server name",
+			raw:      raw[:len(raw)-1],
+			expected: "session state server name length 11 does not match 10 remaining bytes",
+		},
+		{
+			name:     "invalid flag",
+			raw:      badFlag,
+			expected: "invalid flags in session state",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := UnmarshalState(tc.raw)
+
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("Expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const ticketKeyNameLength = 16
+
+var errInvalidTicket = errors.New("invalid session ticket")
+
+// TicketKey seals session states into tickets that only the server holding
+// the same key can open. A ticket is key_name || nonce || AES-256-GCM
+// ciphertext, the key name is authenticated as additional data.
+type TicketKey struct {
+	name [ticketKeyNameLength]byte
+	aead cipher.AEAD
+}
+
+// NewTicketKey derives the key name and the AES-256-GCM key from secret.
+func NewTicketKey(secret [32]byte) (*TicketKey, error) {
+	derived := sha512.Sum512(secret[:])
+
+	block, err := aes.NewCipher(derived[ticketKeyNameLength : ticketKeyNameLength+32])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &TicketKey{aead: aead}
+	copy(key.name[:], derived[:ticketKeyNameLength])
+	return key, nil
+}
+
+func (k *TicketKey) Seal(rand io.Reader, state *State) ([]byte, error) {
+	plaintext, err := MarshalState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket := make([]byte, ticketKeyNameLength+k.aead.NonceSize(), ticketKeyNameLength+k.aead.NonceSize()+len(plaintext)+k.aead.Overhead())
+	copy(ticket, k.name[:])
+	nonce := ticket[ticketKeyNameLength:]
+	if _, err := io.ReadFull(rand, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate ticket nonce: %w", err)
+	}
+
+	return k.aead.Seal(ticket, nonce, plaintext, k.name[:]), nil
+}
+
+// Open returns the session state inside ticket. Tickets sealed under another
+// key, truncated or modified ones are all reported as invalid.
+func (k *TicketKey) Open(ticket []byte) (*State, error) {
+	headerLength := ticketKeyNameLength + k.aead.NonceSize()
+	if len(ticket) < headerLength+k.aead.Overhead() {
+		return nil, errInvalidTicket
+	}
+	if subtle.ConstantTimeCompare(ticket[:ticketKeyNameLength], k.name[:]) != 1 {
+		return nil, errInvalidTicket
+	}
+
+	plaintext, err := k.aead.Open(nil, ticket[ticketKeyNameLength:headerLength], ticket[headerLength:], k.name[:])
+	if err != nil {
+		return nil, errInvalidTicket
+	}
+
+	return UnmarshalState(plaintext)
+}
@@ -0,0 +1,88 @@
+package session
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func newTestTicketKey(t *testing.T, fill byte) *TicketKey {
+	t.Helper()
+
+	var secret [32]byte
+	for i := range secret {
+		secret[i] = fill
+	}
+	key, err := NewTicketKey(secret)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return key
+}
+
+func TestTicketKey_SealAndOpen(t *testing.T) {
+	key := newTestTicketKey(t, 0x01)
+	state := newTestState()
+
+	ticket, err := key.Seal(rand.Reader, state)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	opened, err := key.Open(ticket)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !bytes.Equal(opened.MasterSecret, state.MasterSecret) || opened.CipherSuite != state.CipherSuite {
+		t.Errorf("Expected the sealed state back, got %+v", opened)
+	}
+	if bytes.Contains(ticket, state.MasterSecret) {
+		t.Error("Expected the master secret to be encrypted")
+	}
+}
+
+func TestTicketKey_FreshNoncePerTicket(t *testing.T) {
+	key := newTestTicketKey(t, 0x01)
+	state := newTestState()
+
+	first, _ := key.Seal(rand.Reader, state)
+	second, _ := key.Seal(rand.Reader, state)
+
+	if bytes.Equal(first, second) {
+		t.Error("Expected two tickets for the same state to differ")
+	}
+}
+
+func TestTicketKey_OpenRejectsInvalidTickets(t *testing.T) {
+	key := newTestTicketKey(t, 0x01)
+	ticket, err := key.Seal(rand.Reader, newTestState())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	tampered := append([]byte(nil), ticket...)
+	tampered[len(tampered)-1] ^= 0x01
+	renamed := append([]byte(nil), ticket...)
+	renamed[0] ^= 0x01
+
+	testCases := []struct {
+		name   string
+		key    *TicketKey
+		ticket []byte
+	}{
+		{name: "other key", key: newTestTicketKey(t, 0x02), ticket: ticket},
+		{name: "tampered ciphertext", key: key, ticket: tampered},
+		{name: "tampered key name", key: key, ticket: renamed},
+		{name: "truncated", key: key, ticket: ticket[:40]},
+		{name: "empty", key: key, ticket: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.key.Open(tc.ticket)
+
+			if err == nil || err.Error() != "invalid session ticket" {
+				t.Errorf("Expected invalid ticket error, got %v", err)
+			}
+		})
+	}
+}
@@ -28,3 +28,17 @@ func NewOpaqueVector8(values []byte) ([]byte, error) {
 
 	return append([]byte{byte(CastUint8OrPanic(len(values)))}, values...), nil
 }
+
+const MaxUint24 = 1<<24 - 1
+
+func AppendUint24(payload []byte, value int) []byte {
+	if value < 0 || value > MaxUint24 {
+		panic(fmt.Sprintf("cannot cast %d to uint24", value))
+	}
+
+	return append(payload, byte(value>>16), byte(value>>8), byte(value))
+}
+
+func ReadUint24(raw []byte) int {
+	return int(raw[0])<<16 | int(raw[1])<<8 | int(raw[2])
+}
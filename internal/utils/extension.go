@@ -41,3 +41,13 @@ func CopyExtensions(src []spec.Extension) []spec.Extension {
 	})
 	return dst
 }
+
+func FindExtension(extensions []spec.Extension, extensionType spec.ExtensionType) (spec.Extension, bool) {
+	for _, extension := range extensions {
+		if extension.Type == extensionType {
+			return extension, true
+		}
+	}
+
+	return spec.Extension{}, false
+}
@@ -1,4 +1,4 @@
-package main
+package tls
 
 import (
 	"errors"
@@ -10,7 +10,9 @@ import (
 	"github.com/piligrimm/tls/spec"
 )
 
-func NewServerHello(
+func newServerHello(
+	config *Config,
+	version spec.ProtocolVersion,
 	random []byte,
 	sessionID []byte,
 	cipherSuite spec.CipherSuite,
@@ -24,9 +26,11 @@ func NewServerHello(
 		return nil, errors.New("session ID cannot be longer than 32 bytes")
 	}
 
-	// todo: move this check to the client side
-	supportedCipherSuites := spec.SupportedCipherSuites()
-	if !slices.Contains(supportedCipherSuites, cipherSuite) {
+	if !slices.Contains(config.supportedVersions(), version) {
+		return nil, fmt.Errorf("unsupported version %d.%d", version.Major, version.Minor)
+	}
+
+	if !slices.Contains(config.cipherSuites(), cipherSuite) {
 		return nil, fmt.Errorf("unsupported cipher suite: %v", cipherSuite)
 	}
 
@@ -53,7 +57,7 @@ func NewServerHello(
 	}
 
 	return &spec.ServerHello{
-		ServerTlsVersion:  spec.Tls12ProtocolVersion(),
+		ServerTlsVersion:  version,
 		Random:            utils.CopySlice(random),
 		SessionID:         utils.CopySlice(sessionID),
 		CipherSuite:       cipherSuite,
@@ -1,4 +1,4 @@
-package main
+package tls
 
 import (
 	"bytes"
@@ -20,7 +20,7 @@ func TestCreateServerHello_ValidInput(t *testing.T) {
 	extensions := []spec.Extension{}
 
 	// Act
-	serverHello, err := NewServerHello(random, sessionID, cipherSuite, extensions)
+	serverHello, err := newServerHello(&Config{}, spec.Tls12ProtocolVersion(), random, sessionID, cipherSuite, extensions)
 
 	// Assert
 	if err != nil {
@@ -54,7 +54,7 @@ func TestCreateServerHello_InvalidRandomLength(t *testing.T) {
 	extensions := []spec.Extension{}
 
 	// Act
-	_, err := NewServerHello(random, sessionID, cipherSuite, extensions)
+	_, err := newServerHello(&Config{}, spec.Tls12ProtocolVersion(), random, sessionID, cipherSuite, extensions)
 
 	// Assert
 	if err == nil {
@@ -73,7 +73,7 @@ func TestCreateServerHello_UnsupportedCipherSuite(t *testing.T) {
 	extensions := []spec.Extension{}
 
 	// Act
-	_, err := NewServerHello(random, sessionID, cipherSuite, extensions)
+	_, err := newServerHello(&Config{}, spec.Tls12ProtocolVersion(), random, sessionID, cipherSuite, extensions)
 
 	// Assert
 	if err == nil {
@@ -85,6 +85,25 @@ func TestCreateServerHello_UnsupportedCipherSuite(t *testing.T) {
 	}
 }
 
+func TestCreateServerHello_CipherSuiteNotEnabled(t *testing.T) {
+	// Arrange
+	config := &Config{CipherSuites: []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384}}
+	random := make([]byte, 32)
+	cipherSuite := spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256
+
+	// Act
+	_, err := newServerHello(config, spec.Tls12ProtocolVersion(), random, nil, cipherSuite, nil)
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected error for a cipher suite that is not enabled")
+	}
+	expectedErrorMessage := "unsupported cipher suite: TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"
+	if err.Error() != expectedErrorMessage {
+		t.Errorf("Expected error message '%s', got %q", expectedErrorMessage, err.Error())
+	}
+}
+
 func TestCreateServerHello_SessionIdTooLong(t *testing.T) {
 	// Arrange
 	random := make([]byte, 32)
@@ -93,7 +112,7 @@ func TestCreateServerHello_SessionIdTooLong(t *testing.T) {
 	extensions := []spec.Extension{}
 
 	// Act
-	_, err := NewServerHello(random, sessionID, cipherSuite, extensions)
+	_, err := newServerHello(&Config{}, spec.Tls12ProtocolVersion(), random, sessionID, cipherSuite, extensions)
 
 	// Assert
 	if err == nil {
@@ -117,7 +136,7 @@ func TestCreateServerHello_DuplicateExtension(t *testing.T) {
 	}
 
 	// Act
-	_, err := NewServerHello(random, sessionID, cipherSuite, extensions)
+	_, err := newServerHello(&Config{}, spec.Tls12ProtocolVersion(), random, sessionID, cipherSuite, extensions)
 
 	// Assert
 	if err == nil {
@@ -138,7 +157,7 @@ func TestCreateServerHello_UnsupportedExtension(t *testing.T) {
 	}
 
 	// Act
-	_, err := NewServerHello(random, sessionID, cipherSuite, extensions)
+	_, err := newServerHello(&Config{}, spec.Tls12ProtocolVersion(), random, sessionID, cipherSuite, extensions)
 
 	// Assert
 	if err == nil {
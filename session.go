@@ -0,0 +1,180 @@
+package tls
+
+import (
+	"container/list"
+	"crypto/x509"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/piligrimm/tls/spec"
+)
+
+// sessionTicketLifetime is how long the server resumes a session, by ticket or
+// by session ID. It is announced to the client as the ticket lifetime hint.
+const sessionTicketLifetime = 7 * 24 * time.Hour
+
+// ClientSessionState is a session the client can resume, its content is only
+// meaningful to this package.
+type ClientSessionState struct {
+	sessionID            []byte
+	ticket               []byte
+	cipherSuite          spec.CipherSuite
+	masterSecret         []byte
+	extendedMasterSecret bool
+	encryptThenMAC       bool
+	serverCertificates   []*x509.Certificate
+	receivedAt           time.Time
+	lifetime             time.Duration
+}
+
+// expired reports whether the server announced a lifetime that has passed.
+func (s *ClientSessionState) expired(now time.Time) bool {
+	return s.lifetime > 0 && now.After(s.receivedAt.Add(s.lifetime))
+}
+
+// ClientSessionCache keeps sessions for resumption on the client, keyed by
+// server name and address. Implementations must be safe for concurrent use.
+type ClientSessionCache interface {
+	Get(sessionKey string) (*ClientSessionState, bool)
+	Put(sessionKey string, state *ClientSessionState)
+	Delete(sessionKey string)
+}
+
+// SessionCache keeps sessions for resumption by session ID on the server. The
+// session is an opaque encoding holding the master secret, it must not leave
+// trusted storage. Implementations must be safe for concurrent use.
+type SessionCache interface {
+	Get(sessionID string) ([]byte, bool)
+	Put(sessionID string, session []byte)
+	Delete(sessionID string)
+}
+
+// clientSessionKey identifies the server a client session was established
+// with, a session is only offered to the same name at the same address.
+func clientSessionKey(config *Config, remoteAddr net.Addr) string {
+	return config.ServerName + "@" + remoteAddr.String()
+}
+
+// clockedCache is implemented by the caches of this package. The handshake
+// passes them the time of its Config, so that Config.Time drives their ttl like
+// every other expiry.
+type clockedCache[V any] interface {
+	getAt(key string, now time.Time) (V, bool)
+	putAt(key string, value V, now time.Time)
+}
+
+func cacheGet[V any](cache interface{ Get(string) (V, bool) }, key string, now time.Time) (V, bool) {
+	if clocked, ok := cache.(clockedCache[V]); ok {
+		return clocked.getAt(key, now)
+	}
+	return cache.Get(key)
+}
+
+func cachePut[V any](cache interface{ Put(string, V) }, key string, value V, now time.Time) {
+	if clocked, ok := cache.(clockedCache[V]); ok {
+		clocked.putAt(key, value, now)
+		return
+	}
+	cache.Put(key, value)
+}
+
+// lruCache is a bounded cache evicting the least recently used entry first,
+// entries older than ttl are dropped when a non-zero ttl is set.
+type lruCache[V any] struct {
+	mutex    sync.Mutex
+	capacity int
+	ttl      time.Duration
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type lruEntry[V any] struct {
+	key     string
+	value   V
+	addedAt time.Time
+}
+
+func newLRUCache[V any](capacity int, ttl time.Duration) *lruCache[V] {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &lruCache[V]{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// NewLRUClientSessionCache returns a ClientSessionCache that keeps at most
+// capacity sessions for at most ttl each, evicting the least recently used one
+// first. A session ticket is also dropped once its announced lifetime passes.
+func NewLRUClientSessionCache(capacity int, ttl time.Duration) ClientSessionCache {
+	return newLRUCache[*ClientSessionState](capacity, ttl)
+}
+
+// NewLRUSessionCache returns a SessionCache that keeps at most capacity
+// sessions for at most ttl each, evicting the least recently used one first.
+func NewLRUSessionCache(capacity int, ttl time.Duration) SessionCache {
+	return newLRUCache[[]byte](capacity, ttl)
+}
+
+func (c *lruCache[V]) Get(key string) (V, bool) {
+	return c.getAt(key, time.Now())
+}
+
+func (c *lruCache[V]) getAt(key string, now time.Time) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var zero V
+	element, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	entry := element.Value.(*lruEntry[V])
+	if c.ttl > 0 && now.Sub(entry.addedAt) > c.ttl {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+
+	return entry.value, true
+}
+
+func (c *lruCache[V]) Put(key string, value V) {
+	c.putAt(key, value, time.Now())
+}
+
+func (c *lruCache[V]) putAt(key string, value V, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry[V])
+		entry.value = value
+		entry.addedAt = now
+		c.order.MoveToFront(element)
+		return
+	}
+
+	if c.order.Len() == c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[V]).key)
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value, addedAt: now})
+}
+
+func (c *lruCache[V]) Delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
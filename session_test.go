@@ -0,0 +1,122 @@
+package tls
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLRUClientSessionCache(t *testing.T) {
+	// Arrange
+	cache := NewLRUClientSessionCache(2, time.Hour)
+	first := &ClientSessionState{ticket: []byte{1}}
+	second := &ClientSessionState{ticket: []byte{2}}
+	third := &ClientSessionState{ticket: []byte{3}}
+
+	// Act
+	cache.Put("first", first)
+	cache.Put("second", second)
+	cache.Get("first")
+	cache.Put("third", third)
+
+	// Assert
+	if _, ok := cache.Get("second"); ok {
+		t.Error("Expected the least recently used session to be evicted")
+	}
+	for key, expected := range map[string]*ClientSessionState{"first": first, "third": third} {
+		if state, ok := cache.Get(key); !ok || state != expected {
+			t.Errorf("Expected session %q to be cached, got %v", key, state)
+		}
+	}
+}
+
+func TestLRUClientSessionCache_PutReplacesAndDeleteRemoves(t *testing.T) {
+	// Arrange
+	cache := NewLRUClientSessionCache(2, time.Hour)
+	replacement := &ClientSessionState{ticket: []byte{2}}
+
+	// Act
+	cache.Put("server", &ClientSessionState{ticket: []byte{1}})
+	cache.Put("server", replacement)
+	cache.Put("other", &ClientSessionState{})
+	replaced, replacedOK := cache.Get("server")
+	cache.Delete("server")
+	_, deletedOK := cache.Get("server")
+
+	// Assert
+	if !replacedOK || replaced != replacement {
+		t.Errorf("Expected the replacement session, got %v", replaced)
+	}
+	if deletedOK {
+		t.Error("Expected the deleted session to be gone")
+	}
+	if _, ok := cache.Get("other"); !ok {
+		t.Error("Expected the other session to be kept")
+	}
+}
+
+func TestClientSessionState_Expired(t *testing.T) {
+	receivedAt := time.Unix(1_700_000_000, 0)
+	testCases := []struct {
+		lifetime time.Duration
+		age      time.Duration
+		expected bool
+	}{
+		{lifetime: time.Hour, age: time.Minute, expected: false},
+		{lifetime: time.Hour, age: time.Hour + time.Second, expected: true},
+		{lifetime: 0, age: 365 * 24 * time.Hour, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("lifetime %v age %v", tc.lifetime, tc.age), func(t *testing.T) {
+			state := &ClientSessionState{receivedAt: receivedAt, lifetime: tc.lifetime}
+
+			if expired := state.expired(receivedAt.Add(tc.age)); expired != tc.expected {
+				t.Errorf("Expected expired %t, got %t", tc.expected, expired)
+			}
+		})
+	}
+}
+
+func TestLRUSessionCache_ExpiresEntries(t *testing.T) {
+	// Arrange
+	now := time.Unix(1_700_000_000, 0)
+	cache := newLRUCache[[]byte](2, time.Hour)
+	cachePut[[]byte](cache, "old", []byte{1}, now)
+	cachePut[[]byte](cache, "new", []byte{2}, now.Add(30*time.Minute))
+
+	// Act
+	now = now.Add(75 * time.Minute)
+	_, oldOK := cacheGet[[]byte](cache, "old", now)
+	_, newOK := cacheGet[[]byte](cache, "new", now)
+
+	// Assert
+	if oldOK {
+		t.Error("Expected the session older than the TTL to expire")
+	}
+	if !newOK {
+		t.Error("Expected the session within the TTL to be kept")
+	}
+	if cache.order.Len() != 1 {
+		t.Errorf("Expected 1 remaining entry, got %d", cache.order.Len())
+	}
+}
+
+func TestLRUClientSessionCache_ExpiresSessionIDs(t *testing.T) {
+	// Arrange
+	now := time.Unix(1_700_000_000, 0)
+	cache := NewLRUClientSessionCache(2, time.Hour)
+	cachePut(cache, "server", &ClientSessionState{sessionID: []byte{1}, receivedAt: now}, now)
+
+	// Act
+	_, keptOK := cacheGet(cache, "server", now.Add(time.Hour))
+	_, expiredOK := cacheGet(cache, "server", now.Add(time.Hour+time.Second))
+
+	// Assert
+	if !keptOK {
+		t.Error("Expected the session within the TTL to be kept")
+	}
+	if expiredOK {
+		t.Error("Expected the session older than the TTL to expire")
+	}
+}
@@ -0,0 +1,179 @@
+package spec
+
+import "fmt"
+
+type AlertLevel uint8
+
+const (
+	AlertLevelWarning AlertLevel = 1
+	AlertLevelFatal   AlertLevel = 2
+)
+
+func (l AlertLevel) String() string {
+	switch l {
+	case AlertLevelWarning:
+		return "Warning"
+	case AlertLevelFatal:
+		return "Fatal"
+	default:
+		return fmt.Sprintf("AlertLevel(%d)", uint8(l))
+	}
+}
+
+type AlertDescription uint8
+
+const (
+	AlertDescriptionCloseNotify                  AlertDescription = 0
+	AlertDescriptionUnexpectedMessage            AlertDescription = 10
+	AlertDescriptionBadRecordMAC                 AlertDescription = 20
+	AlertDescriptionDecryptionFailed             AlertDescription = 21 // reserved
+	AlertDescriptionRecordOverflow               AlertDescription = 22
+	AlertDescriptionDecompressionFailure         AlertDescription = 30 // reserved
+	AlertDescriptionHandshakeFailure             AlertDescription = 40
+	AlertDescriptionNoCertificate                AlertDescription = 41 // reserved
+	AlertDescriptionBadCertificate               AlertDescription = 42
+	AlertDescriptionUnsupportedCertificate       AlertDescription = 43
+	AlertDescriptionCertificateRevoked           AlertDescription = 44
+	AlertDescriptionCertificateExpired           AlertDescription = 45
+	AlertDescriptionCertificateUnknown           AlertDescription = 46
+	AlertDescriptionIllegalParameter             AlertDescription = 47
+	AlertDescriptionUnknownCA                    AlertDescription = 48
+	AlertDescriptionAccessDenied                 AlertDescription = 49
+	AlertDescriptionDecodeError                  AlertDescription = 50
+	AlertDescriptionDecryptError                 AlertDescription = 51
+	AlertDescriptionExportRestriction            AlertDescription = 60 // reserved
+	AlertDescriptionProtocolVersion              AlertDescription = 70
+	AlertDescriptionInsufficientSecurity         AlertDescription = 71
+	AlertDescriptionInternalError                AlertDescription = 80
+	AlertDescriptionInappropriateFallback        AlertDescription = 86
+	AlertDescriptionUserCanceled                 AlertDescription = 90
+	AlertDescriptionNoRenegotiation              AlertDescription = 100
+	AlertDescriptionMissingExtension             AlertDescription = 109
+	AlertDescriptionUnsupportedExtension         AlertDescription = 110
+	AlertDescriptionCertificateUnobtainable      AlertDescription = 111 // reserved
+	AlertDescriptionUnrecognizedName             AlertDescription = 112
+	AlertDescriptionBadCertificateStatusResponse AlertDescription = 113
+	AlertDescriptionBadCertificateHashValue      AlertDescription = 114 // reserved
+	AlertDescriptionUnknownPSKIdentity           AlertDescription = 115
+	AlertDescriptionCertificateRequired          AlertDescription = 116
+	AlertDescriptionNoApplicationProtocol        AlertDescription = 120
+)
+
+func AlertDescriptions() []AlertDescription {
+	return []AlertDescription{
+		AlertDescriptionCloseNotify,
+		AlertDescriptionUnexpectedMessage,
+		AlertDescriptionBadRecordMAC,
+		AlertDescriptionDecryptionFailed,
+		AlertDescriptionRecordOverflow,
+		AlertDescriptionDecompressionFailure,
+		AlertDescriptionHandshakeFailure,
+		AlertDescriptionNoCertificate,
+		AlertDescriptionBadCertificate,
+		AlertDescriptionUnsupportedCertificate,
+		AlertDescriptionCertificateRevoked,
+		AlertDescriptionCertificateExpired,
+		AlertDescriptionCertificateUnknown,
+		AlertDescriptionIllegalParameter,
+		AlertDescriptionUnknownCA,
+		AlertDescriptionAccessDenied,
+		AlertDescriptionDecodeError,
+		AlertDescriptionDecryptError,
+		AlertDescriptionExportRestriction,
+		AlertDescriptionProtocolVersion,
+		AlertDescriptionInsufficientSecurity,
+		AlertDescriptionInternalError,
+		AlertDescriptionInappropriateFallback,
+		AlertDescriptionUserCanceled,
+		AlertDescriptionNoRenegotiation,
+		AlertDescriptionMissingExtension,
+		AlertDescriptionUnsupportedExtension,
+		AlertDescriptionCertificateUnobtainable,
+		AlertDescriptionUnrecognizedName,
+		AlertDescriptionBadCertificateStatusResponse,
+		AlertDescriptionBadCertificateHashValue,
+		AlertDescriptionUnknownPSKIdentity,
+		AlertDescriptionCertificateRequired,
+		AlertDescriptionNoApplicationProtocol,
+	}
+}
+
+func (d AlertDescription) String() string {
+	switch d {
+	case AlertDescriptionCloseNotify:
+		return "CloseNotify"
+	case AlertDescriptionUnexpectedMessage:
+		return "UnexpectedMessage"
+	case AlertDescriptionBadRecordMAC:
+		return "BadRecordMAC"
+	case AlertDescriptionDecryptionFailed:
+		return "DecryptionFailed"
+	case AlertDescriptionRecordOverflow:
+		return "RecordOverflow"
+	case AlertDescriptionDecompressionFailure:
+		return "DecompressionFailure"
+	case AlertDescriptionHandshakeFailure:
+		return "HandshakeFailure"
+	case AlertDescriptionNoCertificate:
+		return "NoCertificate"
+	case AlertDescriptionBadCertificate:
+		return "BadCertificate"
+	case AlertDescriptionUnsupportedCertificate:
+		return "UnsupportedCertificate"
+	case AlertDescriptionCertificateRevoked:
+		return "CertificateRevoked"
+	case AlertDescriptionCertificateExpired:
+		return "CertificateExpired"
+	case AlertDescriptionCertificateUnknown:
+		return "CertificateUnknown"
+	case AlertDescriptionIllegalParameter:
+		return "IllegalParameter"
+	case AlertDescriptionUnknownCA:
+		return "UnknownCA"
+	case AlertDescriptionAccessDenied:
+		return "AccessDenied"
+	case AlertDescriptionDecodeError:
+		return "DecodeError"
+	case AlertDescriptionDecryptError:
+		return "DecryptError"
+	case AlertDescriptionExportRestriction:
+		return "ExportRestriction"
+	case AlertDescriptionProtocolVersion:
+		return "ProtocolVersion"
+	case AlertDescriptionInsufficientSecurity:
+		return "InsufficientSecurity"
+	case AlertDescriptionInternalError:
+		return "InternalError"
+	case AlertDescriptionInappropriateFallback:
+		return "InappropriateFallback"
+	case AlertDescriptionUserCanceled:
+		return "UserCanceled"
+	case AlertDescriptionNoRenegotiation:
+		return "NoRenegotiation"
+	case AlertDescriptionMissingExtension:
+		return "MissingExtension"
+	case AlertDescriptionUnsupportedExtension:
+		return "UnsupportedExtension"
+	case AlertDescriptionCertificateUnobtainable:
+		return "CertificateUnobtainable"
+	case AlertDescriptionUnrecognizedName:
+		return "UnrecognizedName"
+	case AlertDescriptionBadCertificateStatusResponse:
+		return "BadCertificateStatusResponse"
+	case AlertDescriptionBadCertificateHashValue:
+		return "BadCertificateHashValue"
+	case AlertDescriptionUnknownPSKIdentity:
+		return "UnknownPSKIdentity"
+	case AlertDescriptionCertificateRequired:
+		return "CertificateRequired"
+	case AlertDescriptionNoApplicationProtocol:
+		return "NoApplicationProtocol"
+	default:
+		return fmt.Sprintf("AlertDescription(%d)", uint8(d))
+	}
+}
+
+type Alert struct {
+	Level       AlertLevel
+	Description AlertDescription
+}
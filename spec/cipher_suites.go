@@ -62,13 +62,6 @@ const (
 	CipherSuiteRSA_WITH_3DES_EDE_CBC_SHA            CipherSuite = 0x000a
 )
 
-// todo: move to client/server side from spec
-func SupportedCipherSuites() []CipherSuite {
-	return []CipherSuite{
-		CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256,
-	}
-}
-
 func (c CipherSuite) String() string {
 	switch c {
 	case CipherSuiteECDHE_ECDSA_WITH_AES_128_CBC_SHA:
@@ -103,6 +96,18 @@ func (c CipherSuite) String() string {
 		return "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA384"
 	case CipherSuiteDHE_RSA_WITH_CHACHA20_POLY1305_SHA256:
 		return "TLS_DHE_RSA_WITH_CHACHA20_POLY1305_SHA256"
+	case CipherSuiteRSA_WITH_AES_128_GCM_SHA256:
+		return "TLS_RSA_WITH_AES_128_GCM_SHA256"
+	case CipherSuiteRSA_WITH_AES_256_GCM_SHA384:
+		return "TLS_RSA_WITH_AES_256_GCM_SHA384"
+	case CipherSuiteRSA_WITH_AES_128_CBC_SHA256:
+		return "TLS_RSA_WITH_AES_128_CBC_SHA256"
+	case CipherSuiteRSA_WITH_AES_256_CBC_SHA256:
+		return "TLS_RSA_WITH_AES_256_CBC_SHA256"
+	case CipherSuiteRSA_WITH_AES_128_CBC_SHA:
+		return "TLS_RSA_WITH_AES_128_CBC_SHA"
+	case CipherSuiteRSA_WITH_AES_256_CBC_SHA:
+		return "TLS_RSA_WITH_AES_256_CBC_SHA"
 	case CipherSuiteGOSTR341112_256_WITH_28147_CNT_IMIT:
 		return "TLS_GOSTR341112_256_WITH_28147_CNT_IMIT"
 	case CipherSuiteDraftGOSTR341112_256_WITH_28147_CNT_IMIT:
@@ -0,0 +1,8 @@
+package spec
+
+type ClientKeyExchange struct {
+	// ECDH public point of the client for ECDHE cipher suites
+	PublicKey []byte
+	// pre-master secret encrypted to the server's RSA key for RSA cipher suites
+	EncryptedPreMasterSecret []byte
+}
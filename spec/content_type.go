@@ -0,0 +1,36 @@
+package spec
+
+import "fmt"
+
+type ContentType uint8
+
+const (
+	ContentTypeChangeCipherSpec ContentType = 20
+	ContentTypeAlert            ContentType = 21
+	ContentTypeHandshake        ContentType = 22
+	ContentTypeApplicationData  ContentType = 23
+)
+
+func ContentTypes() []ContentType {
+	return []ContentType{
+		ContentTypeChangeCipherSpec,
+		ContentTypeAlert,
+		ContentTypeHandshake,
+		ContentTypeApplicationData,
+	}
+}
+
+func (c ContentType) String() string {
+	switch c {
+	case ContentTypeChangeCipherSpec:
+		return "ChangeCipherSpec"
+	case ContentTypeAlert:
+		return "Alert"
+	case ContentTypeHandshake:
+		return "Handshake"
+	case ContentTypeApplicationData:
+		return "ApplicationData"
+	default:
+		return fmt.Sprintf("ContentType(%d)", uint8(c))
+	}
+}
@@ -22,3 +22,7 @@ func (p ECPointFormat) String() string {
 		return fmt.Sprintf("ECPointFormat(0x%02x)", uint8(p))
 	}
 }
+
+type ECPointFormatList struct {
+	Formats []ECPointFormat
+}
@@ -9,6 +9,7 @@ const (
 	ExtensionTypeSupportedGroups      ExtensionType = 0x000a // previously called elliptic_curves
 	ExtensionTypeECPointFormats       ExtensionType = 0x000b
 	ExtensionTypeSignatureAlgorithms  ExtensionType = 0x000d
+	ExtensionTypeEncryptThenMAC       ExtensionType = 0x0016
 	ExtensionTypeSupportedVersions    ExtensionType = 0x002b
 	ExtensionTypeRenegotiationInfo    ExtensionType = 0xff01
 	ExtensionTypeExtendedMasterSecret ExtensionType = 0x0017
@@ -21,6 +22,7 @@ func ExtensionTypes() []ExtensionType {
 		ExtensionTypeSupportedGroups,
 		ExtensionTypeECPointFormats,
 		ExtensionTypeSignatureAlgorithms,
+		ExtensionTypeEncryptThenMAC,
 		ExtensionTypeRenegotiationInfo,
 		ExtensionTypeExtendedMasterSecret,
 		ExtensionTypeSessionTicket,
@@ -37,6 +39,8 @@ func (e ExtensionType) String() string {
 		return "ECPointFormats"
 	case ExtensionTypeSignatureAlgorithms:
 		return "SignatureAlgorithms"
+	case ExtensionTypeEncryptThenMAC:
+		return "EncryptThenMAC"
 	case ExtensionTypeRenegotiationInfo:
 		return "RenegotiationInfo"
 	case ExtensionTypeExtendedMasterSecret:
@@ -0,0 +1,5 @@
+package spec
+
+type Finished struct {
+	VerifyData []byte
+}
@@ -0,0 +1,6 @@
+package spec
+
+type Handshake struct {
+	MsgType HandshakeType
+	Body    []byte
+}
@@ -0,0 +1,69 @@
+package spec
+
+import "fmt"
+
+type HandshakeType byte
+
+const (
+	HandshakeTypeHelloRequest HandshakeType = 0x00
+	HandshakeTypeClientHello  HandshakeType = 0x01
+	HandshakeTypeServerHello  HandshakeType = 0x02
+	// 0x03 is hello_verify_request, which is only used by DTLS
+	HandshakeTypeNewSessionTicket   HandshakeType = 0x04
+	HandshakeTypeCertificate        HandshakeType = 0x0b
+	HandshakeTypeServerKeyExchange  HandshakeType = 0x0c
+	HandshakeTypeCertificateRequest HandshakeType = 0x0d
+	HandshakeTypeServerHelloDone    HandshakeType = 0x0e
+	HandshakeTypeCertificateVerify  HandshakeType = 0x0f
+	HandshakeTypeClientKeyExchange  HandshakeType = 0x10
+	HandshakeTypeFinished           HandshakeType = 0x14
+	HandshakeTypeCertificateStatus  HandshakeType = 0x16
+)
+
+func HandshakeTypes() []HandshakeType {
+	return []HandshakeType{
+		HandshakeTypeHelloRequest,
+		HandshakeTypeClientHello,
+		HandshakeTypeServerHello,
+		HandshakeTypeNewSessionTicket,
+		HandshakeTypeCertificate,
+		HandshakeTypeServerKeyExchange,
+		HandshakeTypeCertificateRequest,
+		HandshakeTypeServerHelloDone,
+		HandshakeTypeCertificateVerify,
+		HandshakeTypeClientKeyExchange,
+		HandshakeTypeFinished,
+		HandshakeTypeCertificateStatus,
+	}
+}
+
+func (h HandshakeType) String() string {
+	switch h {
+	case HandshakeTypeHelloRequest:
+		return "HelloRequest"
+	case HandshakeTypeClientHello:
+		return "ClientHello"
+	case HandshakeTypeServerHello:
+		return "ServerHello"
+	case HandshakeTypeNewSessionTicket:
+		return "NewSessionTicket"
+	case HandshakeTypeCertificate:
+		return "Certificate"
+	case HandshakeTypeServerKeyExchange:
+		return "ServerKeyExchange"
+	case HandshakeTypeCertificateRequest:
+		return "CertificateRequest"
+	case HandshakeTypeServerHelloDone:
+		return "ServerHelloDone"
+	case HandshakeTypeCertificateVerify:
+		return "CertificateVerify"
+	case HandshakeTypeClientKeyExchange:
+		return "ClientKeyExchange"
+	case HandshakeTypeFinished:
+		return "Finished"
+	case HandshakeTypeCertificateStatus:
+		return "CertificateStatus"
+	default:
+		return fmt.Sprintf("HandshakeType(%d)", uint8(h))
+	}
+}
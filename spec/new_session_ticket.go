@@ -0,0 +1,7 @@
+package spec
+
+type NewSessionTicket struct {
+	// LifetimeHint is in seconds, zero leaves the lifetime unspecified
+	LifetimeHint uint32
+	Ticket       []byte
+}
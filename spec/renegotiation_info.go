@@ -0,0 +1,5 @@
+package spec
+
+type RenegotiationInfo struct {
+	RenegotiatedConnection []byte
+}
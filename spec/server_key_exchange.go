@@ -0,0 +1,19 @@
+package spec
+
+type ECCurveType uint8
+
+const (
+	ECCurveTypeNamedCurve ECCurveType = 0x03
+)
+
+type ServerECDHParams struct {
+	CurveType  ECCurveType
+	NamedCurve SupportedGroup
+	PublicKey  []byte
+}
+
+type ServerKeyExchange struct {
+	Params             ServerECDHParams
+	SignatureAlgorithm SignatureAlgorithm
+	Signature          []byte
+}
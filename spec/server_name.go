@@ -0,0 +1,27 @@
+package spec
+
+import "fmt"
+
+type ServerNameType uint8
+
+const (
+	ServerNameTypeHostName ServerNameType = 0x00
+)
+
+func (n ServerNameType) String() string {
+	switch n {
+	case ServerNameTypeHostName:
+		return "HostName"
+	default:
+		return fmt.Sprintf("ServerNameType(0x%02x)", uint8(n))
+	}
+}
+
+type ServerName struct {
+	NameType ServerNameType
+	HostName string
+}
+
+type ServerNameList struct {
+	ServerNames []ServerName
+}
@@ -4,8 +4,15 @@ type SupportedGroup uint16
 
 const (
 	SupportedGroupsSecp256r1 SupportedGroup = 0x0017
+	SupportedGroupsSecp384r1 SupportedGroup = 0x0018
+	SupportedGroupsSecp521r1 SupportedGroup = 0x0019
+	SupportedGroupsX25519    SupportedGroup = 0x001d
 )
 
+type SupportedGroupList struct {
+	Groups []SupportedGroup
+}
+
 type SignatureAlgorithm uint16
 
 const (
@@ -36,3 +43,7 @@ const (
 	SignatureAlgorithmRsaPkcs1Sha1 SignatureAlgorithm = 0x0201
 	SignatureAlgorithmEcdsaSha1    SignatureAlgorithm = 0x0203
 )
+
+type SignatureAlgorithmList struct {
+	Algorithms []SignatureAlgorithm
+}
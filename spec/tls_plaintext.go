@@ -0,0 +1,7 @@
+package spec
+
+type TLSPlaintext struct {
+	ContentType ContentType
+	Version     ProtocolVersion
+	Fragment    []byte
+}
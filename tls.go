@@ -0,0 +1,72 @@
+// Package tls implements the client and server sides of TLS 1.2 (RFC 5246).
+package tls
+
+import (
+	"errors"
+	"net"
+)
+
+type listener struct {
+	net.Listener
+	config *Config
+}
+
+// Accept waits for the next connection and returns its server side. The
+// handshake runs on the first Read or Write of the returned connection.
+func (l *listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return Server(conn, l.config), nil
+}
+
+// NewListener wraps every connection accepted by inner into the server side of TLS.
+func NewListener(inner net.Listener, config *Config) net.Listener {
+	return &listener{Listener: inner, config: config}
+}
+
+// Listen creates a TLS listener accepting connections on the given network address.
+func Listen(network, address string, config *Config) (net.Listener, error) {
+	if config == nil || len(config.Certificates) == 0 {
+		return nil, errors.New("server config must contain at least one certificate")
+	}
+
+	inner, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewListener(inner, config), nil
+}
+
+// Dial connects to the given network address and runs the handshake. When the
+// config has no server name, the host part of the address is used instead.
+func Dial(network, address string, config *Config) (*Conn, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+
+		config = config.Clone()
+		config.ServerName = host
+	}
+
+	rawConn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := Client(rawConn, config)
+	if err := conn.Handshake(); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,1168 @@
+package tls
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/piligrimm/tls/internal/alert"
+	"github.com/piligrimm/tls/internal/ciphersuite"
+	"github.com/piligrimm/tls/internal/handshake"
+	"github.com/piligrimm/tls/spec"
+)
+
+func newTestCertificate(t *testing.T) Certificate {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	return newTestCertificateWithKey(t, key)
+}
+
+func newTestECDSACertificate(t *testing.T, curve elliptic.Curve) Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	return newTestCertificateWithKey(t, key)
+}
+
+// newTestCertificateWithKey returns a self-signed certificate for dnsNames,
+// localhost when none are given.
+func newTestCertificateWithKey(t *testing.T, key crypto.Signer, dnsNames ...string) Certificate {
+	t.Helper()
+
+	if len(dnsNames) == 0 {
+		dnsNames = []string{"localhost"}
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     dnsNames,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+
+	return Certificate{Chain: []*x509.Certificate{certificate}, PrivateKey: key}
+}
+
+func newTestConfigs(t *testing.T) (clientConfig, serverConfig *Config) {
+	t.Helper()
+
+	return newTestConfigsWithCertificate(t, newTestCertificate(t))
+}
+
+// newTestConfigsForSuite returns configs whose server certificate has the key
+// type cipherSuite authenticates with.
+func newTestConfigsForSuite(t *testing.T, cipherSuite spec.CipherSuite) (clientConfig, serverConfig *Config) {
+	t.Helper()
+
+	params, err := ciphersuite.Lookup(cipherSuite)
+	if err != nil {
+		t.Fatalf("failed to look up cipher suite: %v", err)
+	}
+	if params.Authentication == ciphersuite.AuthenticationECDSA {
+		return newTestConfigsWithCertificate(t, newTestECDSACertificate(t, elliptic.P256()))
+	}
+
+	return newTestConfigs(t)
+}
+
+func newTestConfigsWithCertificate(t *testing.T, certificate Certificate) (clientConfig, serverConfig *Config) {
+	t.Helper()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(certificate.Chain[0])
+
+	return &Config{ServerName: "localhost", RootCAs: roots}, &Config{Certificates: []Certificate{certificate}}
+}
+
+// echoLines writes every line received on conn back to it until the peer closes.
+func echoLines(conn net.Conn) {
+	defer conn.Close()
+
+	lines := bufio.NewScanner(conn)
+	for lines.Scan() {
+		if _, err := conn.Write(append(lines.Bytes(), '\n')); err != nil {
+			return
+		}
+	}
+}
+
+func TestConn_ClientAndServer(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientSide, serverSide := net.Pipe()
+	server := Server(serverSide, serverConfig)
+	go echoLines(server)
+	client := Client(clientSide, clientConfig)
+
+	replies := bufio.NewReader(client)
+	for _, line := range []string{"first\n", "second\n"} {
+		if _, err := client.Write([]byte(line)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		reply, err := replies.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if reply != line {
+			t.Errorf("Expected %q, got %q", line, reply)
+		}
+	}
+
+	if err := client.Close(); err != nil {
+		t.Errorf("Expected no error on close, got %v", err)
+	}
+}
+
+func TestConn_ReadsCloseNotifyAsEOF(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientSide, serverSide := net.Pipe()
+	server := Server(serverSide, serverConfig)
+	go func() {
+		if server.Handshake() == nil {
+			server.Close()
+		}
+	}()
+	client := Client(clientSide, clientConfig)
+	defer clientSide.Close()
+
+	_, err := client.Read(make([]byte, 1))
+
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("Expected %v, got %v", io.EOF, err)
+	}
+}
+
+func TestConn_ReadDeadline(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientSide, serverSide := net.Pipe()
+	server := Server(serverSide, serverConfig)
+	defer serverSide.Close()
+	go server.Handshake()
+	client := Client(clientSide, clientConfig)
+	defer clientSide.Close()
+	if err := client.Handshake(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := client.SetReadDeadline(time.Now().Add(10 * time.Millisecond)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_, err := client.Read(make([]byte, 1))
+
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Expected %v, got %v", os.ErrDeadlineExceeded, err)
+	}
+}
+
+func TestConn_CloseUnblocksWrite(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	client, _ := handshakeConfigs(t, clientConfig, serverConfig)
+	writeErr := make(chan error, 1)
+	// the server never reads, so the write blocks
+	go func() {
+		_, err := client.Write(make([]byte, 1024))
+		writeErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan error, 1)
+	go func() { closed <- client.Close() }()
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected Close to return while a Write is blocked")
+	}
+	select {
+	case err := <-writeErr:
+		if err == nil {
+			t.Error("Expected the blocked Write to fail")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected Close to unblock the Write")
+	}
+}
+
+// deadlineConn records the write deadlines set on a net.Conn.
+type deadlineConn struct {
+	net.Conn
+	writeDeadline time.Time
+}
+
+func (c *deadlineConn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline = t
+	return c.Conn.SetWriteDeadline(t)
+}
+
+func TestConn_AlertRestoresWriteDeadline(t *testing.T) {
+	clientSide, serverSide := net.Pipe()
+	defer serverSide.Close()
+	go io.Copy(io.Discard, serverSide)
+	conn := &deadlineConn{Conn: clientSide}
+	client := Client(conn, &Config{ServerName: "localhost"})
+	deadline := time.Now().Add(time.Hour)
+	if err := client.SetWriteDeadline(deadline); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	client.sendAlert(spec.AlertLevelWarning, spec.AlertDescriptionCloseNotify)
+
+	if !conn.writeDeadline.Equal(deadline) {
+		t.Errorf("Expected write deadline %v, got %v", deadline, conn.writeDeadline)
+	}
+}
+
+func TestListen_ServesConcurrentConnections(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	listener, err := Listen("tcp", "127.0.0.1:0", serverConfig)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go echoLines(conn)
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	// the server name is taken from the address
+	clientConfig.ServerName = ""
+
+	const clients = 8
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			client, err := Dial("tcp", net.JoinHostPort("localhost", port), clientConfig)
+			if err != nil {
+				t.Errorf("client %d failed to dial: %v", i, err)
+				return
+			}
+			defer client.Close()
+
+			if _, err := client.Write([]byte("ping\n")); err != nil {
+				t.Errorf("client %d failed to write: %v", i, err)
+				return
+			}
+			reply, err := bufio.NewReader(client).ReadString('\n')
+			if err != nil {
+				t.Errorf("client %d failed to read: %v", i, err)
+				return
+			}
+			if reply != "ping\n" {
+				t.Errorf("Expected %q, got %q", "ping\n", reply)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDial_VerifiesAddressHost(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	listener, err := Listen("tcp", "127.0.0.1:0", serverConfig)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Read(make([]byte, 1))
+	}()
+	clientConfig.ServerName = ""
+
+	_, err = Dial("tcp", listener.Addr().String(), clientConfig)
+
+	// the certificate is only valid for localhost, not for 127.0.0.1
+	if err == nil || !strings.Contains(err.Error(), "failed to verify server certificate") {
+		t.Errorf("Expected certificate verification error, got %v", err)
+	}
+}
+
+func TestListen_RequiresCertificate(t *testing.T) {
+	_, err := Listen("tcp", "127.0.0.1:0", &Config{})
+
+	expected := "server config must contain at least one certificate"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+}
+
+func TestConn_CipherSuiteByServerPreference(t *testing.T) {
+	testCases := []struct {
+		name         string
+		clientSuites []spec.CipherSuite
+		serverSuites []spec.CipherSuite
+		expected     spec.CipherSuite
+	}{
+		{
+			name:     "defaults",
+			expected: spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		},
+		{
+			name:         "server prefers AES-256",
+			clientSuites: []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256, spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384},
+			serverSuites: []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384, spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256},
+			expected:     spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		},
+		{
+			name:         "only one shared suite",
+			clientSuites: []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384},
+			expected:     spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		},
+		{
+			name:         "client without AES hardware",
+			clientSuites: []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256},
+			expected:     spec.CipherSuiteECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+		{
+			name:         "server prefers ChaCha20-Poly1305",
+			serverSuites: []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256, spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256},
+			expected:     spec.CipherSuiteECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientConfig, serverConfig := newTestConfigs(t)
+			clientConfig.CipherSuites = tc.clientSuites
+			serverConfig.CipherSuites = tc.serverSuites
+			clientSide, serverSide := net.Pipe()
+			server := Server(serverSide, serverConfig)
+			go echoLines(server)
+			client := Client(clientSide, clientConfig)
+			defer client.Close()
+
+			if _, err := client.Write([]byte("ping\n")); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if _, err := bufio.NewReader(client).ReadString('\n'); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			state := client.ConnectionState()
+			if !state.HandshakeComplete {
+				t.Fatal("Expected the handshake to be complete")
+			}
+			if state.CipherSuite != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, state.CipherSuite)
+			}
+		})
+	}
+}
+
+func TestConn_NoSharedCipherSuite(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientConfig.CipherSuites = []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	serverConfig.CipherSuites = []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384}
+	clientSide, serverSide := net.Pipe()
+	clientErr := make(chan error, 1)
+	go func() { clientErr <- Client(clientSide, clientConfig).Handshake() }()
+	server := Server(serverSide, serverConfig)
+	defer server.Close()
+
+	err := server.Handshake()
+
+	if err == nil || err.Error() != "no cipher suite shared with the client" {
+		t.Errorf("Expected no shared cipher suite error, got %v", err)
+	}
+	if server.ConnectionState().HandshakeComplete {
+		t.Error("Expected the handshake to be incomplete")
+	}
+	var alertErr *AlertError
+	if err := <-clientErr; !errors.As(err, &alertErr) || alertErr.Description != spec.AlertDescriptionHandshakeFailure {
+		t.Errorf("Expected the client to receive a HandshakeFailure alert, got %v", err)
+	}
+}
+
+func TestConn_ReadsAlertsAfterHandshake(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientSide, serverSide := net.Pipe()
+	server := Server(serverSide, serverConfig)
+	defer serverSide.Close()
+	go func() {
+		if server.Handshake() != nil {
+			return
+		}
+		server.sendAlert(spec.AlertLevelWarning, spec.AlertDescriptionNoRenegotiation)
+		_, _ = server.Write([]byte("data"))
+		server.sendAlert(spec.AlertLevelFatal, spec.AlertDescriptionInternalError)
+	}()
+	client := Client(clientSide, clientConfig)
+	defer clientSide.Close()
+
+	buf := make([]byte, 4)
+	n, err := client.Read(buf)
+	if err != nil {
+		t.Fatalf("Expected the warning to be skipped, got %v", err)
+	}
+	if string(buf[:n]) != "data" {
+		t.Errorf("Expected %q, got %q", "data", buf[:n])
+	}
+
+	_, err = client.Read(buf)
+
+	var alertErr *AlertError
+	if !errors.As(err, &alertErr) || alertErr.Description != spec.AlertDescriptionInternalError {
+		t.Errorf("Expected an InternalError alert, got %v", err)
+	}
+}
+
+func TestConn_HandshakeMessagesAfterHandshake(t *testing.T) {
+	testCases := []struct {
+		name          string
+		fromServer    bool
+		msgType       spec.HandshakeType
+		expectedAlert spec.Alert
+		expectedErr   string
+	}{
+		{
+			name:          "HelloRequest to the client",
+			fromServer:    true,
+			msgType:       spec.HandshakeTypeHelloRequest,
+			expectedAlert: spec.Alert{Level: spec.AlertLevelWarning, Description: spec.AlertDescriptionNoRenegotiation},
+		},
+		{
+			name:          "ClientHello to the server",
+			msgType:       spec.HandshakeTypeClientHello,
+			expectedAlert: spec.Alert{Level: spec.AlertLevelWarning, Description: spec.AlertDescriptionNoRenegotiation},
+		},
+		{
+			name:          "Finished to the client",
+			fromServer:    true,
+			msgType:       spec.HandshakeTypeFinished,
+			expectedAlert: spec.Alert{Level: spec.AlertLevelFatal, Description: spec.AlertDescriptionUnexpectedMessage},
+			expectedErr:   "unexpected Finished message after handshake",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientConfig, serverConfig := newTestConfigs(t)
+			client, server := handshakeConfigs(t, clientConfig, serverConfig)
+			sender, receiver := client, server
+			if tc.fromServer {
+				sender, receiver = server, client
+			}
+			type result struct {
+				data string
+				err  error
+			}
+			received := make(chan result, 1)
+			go func() {
+				buf := make([]byte, 4)
+				n, err := receiver.Read(buf)
+				received <- result{string(buf[:n]), err}
+			}()
+
+			if err := sender.writeHandshake(handshake.NewTranscript(), &spec.Handshake{MsgType: tc.msgType}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			rec, err := sender.records.ReadRecord()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			expected := alert.MarshalAlert(&tc.expectedAlert)
+			if rec.ContentType != spec.ContentTypeAlert || !bytes.Equal(rec.Fragment, expected) {
+				t.Fatalf("Expected alert %x, got %v record %x", expected, rec.ContentType, rec.Fragment)
+			}
+			if tc.expectedErr != "" {
+				if res := <-received; res.err == nil || res.err.Error() != tc.expectedErr {
+					t.Errorf("Expected error %q, got %v", tc.expectedErr, res.err)
+				}
+				return
+			}
+			if _, err := sender.Write([]byte("data")); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if res := <-received; res.err != nil || res.data != "data" {
+				t.Errorf("Expected %q, got %q and %v", "data", res.data, res.err)
+			}
+		})
+	}
+}
+
+func TestConn_ServerReadsServerName(t *testing.T) {
+	testCases := []struct {
+		name       string
+		serverName string
+		expected   string
+	}{
+		{name: "host name", serverName: "localhost", expected: "localhost"},
+		{name: "trailing dot is stripped", serverName: "localhost.", expected: "localhost"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientConfig, serverConfig := newTestConfigs(t)
+			clientConfig.ServerName = tc.serverName
+			clientSide, serverSide := net.Pipe()
+			go Client(clientSide, clientConfig).Handshake()
+			server := Server(serverSide, serverConfig)
+			defer serverSide.Close()
+
+			if err := server.Handshake(); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if state := server.ConnectionState(); state.ServerName != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, state.ServerName)
+			}
+		})
+	}
+}
+
+func TestConn_UnrecognizedServerName(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientConfig.ServerName = "other.example"
+	clientSide, serverSide := net.Pipe()
+	go func() { _ = Server(serverSide, serverConfig).Handshake() }()
+	client := Client(clientSide, clientConfig)
+	defer clientSide.Close()
+
+	err := client.Handshake()
+
+	var alertErr *AlertError
+	if !errors.As(err, &alertErr) || alertErr.Description != spec.AlertDescriptionUnrecognizedName {
+		t.Errorf("Expected an UnrecognizedName alert, got %v", err)
+	}
+}
+
+func TestConn_NegotiatesSharedGroup(t *testing.T) {
+	testCases := []struct {
+		name         string
+		clientGroups []spec.SupportedGroup
+		serverGroups []spec.SupportedGroup
+	}{
+		{name: "defaults"},
+		{name: "secp384r1 only", clientGroups: []spec.SupportedGroup{spec.SupportedGroupsSecp384r1}},
+		{name: "secp521r1 only", serverGroups: []spec.SupportedGroup{spec.SupportedGroupsSecp521r1}},
+		{
+			name:         "one shared group",
+			clientGroups: []spec.SupportedGroup{spec.SupportedGroupsX25519, spec.SupportedGroupsSecp256r1},
+			serverGroups: []spec.SupportedGroup{spec.SupportedGroupsSecp256r1, spec.SupportedGroupsSecp384r1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientConfig, serverConfig := newTestConfigs(t)
+			clientConfig.SupportedGroups = tc.clientGroups
+			serverConfig.SupportedGroups = tc.serverGroups
+			clientSide, serverSide := net.Pipe()
+			go Server(serverSide, serverConfig).Handshake()
+			client := Client(clientSide, clientConfig)
+			defer clientSide.Close()
+
+			if err := client.Handshake(); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConn_NoSharedGroup(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientConfig.SupportedGroups = []spec.SupportedGroup{spec.SupportedGroupsSecp256r1}
+	serverConfig.SupportedGroups = []spec.SupportedGroup{spec.SupportedGroupsX25519}
+	clientSide, serverSide := net.Pipe()
+	go func() { _ = Server(serverSide, serverConfig).Handshake() }()
+	client := Client(clientSide, clientConfig)
+	defer clientSide.Close()
+
+	err := client.Handshake()
+
+	var alertErr *AlertError
+	if !errors.As(err, &alertErr) || alertErr.Description != spec.AlertDescriptionHandshakeFailure {
+		t.Errorf("Expected a HandshakeFailure alert, got %v", err)
+	}
+}
+
+func TestConn_StrictPolicies(t *testing.T) {
+	testCases := []struct {
+		name string
+		scsv bool
+	}{
+		{name: "renegotiation_info extension", scsv: false},
+		{name: "signaling cipher suite", scsv: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientConfig, serverConfig := newTestConfigs(t)
+			clientConfig.RenegotiationSCSV = tc.scsv
+			clientConfig.RequireSecureRenegotiation = true
+			clientConfig.RequireExtendedMasterSecret = true
+			serverConfig.RequireSecureRenegotiation = true
+			serverConfig.RequireExtendedMasterSecret = true
+			clientSide, serverSide := net.Pipe()
+			server := Server(serverSide, serverConfig)
+			serverErr := make(chan error, 1)
+			go func() { serverErr <- server.Handshake() }()
+			client := Client(clientSide, clientConfig)
+			defer clientSide.Close()
+			defer serverSide.Close()
+
+			if err := client.Handshake(); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := <-serverErr; err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if !client.ConnectionState().SecureRenegotiation {
+				t.Error("Expected the client to see secure renegotiation")
+			}
+			if !server.ConnectionState().SecureRenegotiation {
+				t.Error("Expected the server to see secure renegotiation")
+			}
+			if !client.ConnectionState().ExtendedMasterSecret || !server.ConnectionState().ExtendedMasterSecret {
+				t.Error("Expected both sides to use the extended master secret")
+			}
+			if len(client.clientVerifyData) == 0 || !bytes.Equal(client.clientVerifyData, server.clientVerifyData) {
+				t.Errorf("Expected matching client verify_data, got %x and %x", client.clientVerifyData, server.clientVerifyData)
+			}
+			if len(client.serverVerifyData) == 0 || !bytes.Equal(client.serverVerifyData, server.serverVerifyData) {
+				t.Errorf("Expected matching server verify_data, got %x and %x", client.serverVerifyData, server.serverVerifyData)
+			}
+		})
+	}
+}
+
+// handshakeConfigs runs a handshake over a pipe and returns both connections.
+func handshakeConfigs(t *testing.T, clientConfig, serverConfig *Config) (client, server *Conn) {
+	t.Helper()
+
+	clientSide, serverSide := net.Pipe()
+	t.Cleanup(func() {
+		clientSide.Close()
+		serverSide.Close()
+	})
+	server = Server(serverSide, serverConfig)
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- server.Handshake() }()
+	client = Client(clientSide, clientConfig)
+
+	if err := client.Handshake(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := <-serverErr; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return client, server
+}
+
+func TestConn_ResumesWithSessionTicket(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientConfig.ClientSessionCache = NewLRUClientSessionCache(4, time.Hour)
+
+	client, server := handshakeConfigs(t, clientConfig, serverConfig)
+	if client.ConnectionState().DidResume || server.ConnectionState().DidResume {
+		t.Fatal("Expected the first handshake to be a full one")
+	}
+	if _, ok := clientConfig.ClientSessionCache.Get("localhost@pipe"); !ok {
+		t.Fatal("Expected the client to cache the session")
+	}
+
+	for range 2 {
+		client, server = handshakeConfigs(t, clientConfig, serverConfig)
+
+		if !client.ConnectionState().DidResume || !server.ConnectionState().DidResume {
+			t.Fatal("Expected both sides to resume the session")
+		}
+		if !client.ConnectionState().ExtendedMasterSecret || !server.ConnectionState().ExtendedMasterSecret {
+			t.Error("Expected the resumed session to keep the extended master secret")
+		}
+		if !bytes.Equal(client.clientVerifyData, server.clientVerifyData) || !bytes.Equal(client.serverVerifyData, server.serverVerifyData) {
+			t.Error("Expected matching verify_data on both sides")
+		}
+
+		go echoLines(server)
+		if _, err := client.Write([]byte("ping\n")); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		line, err := bufio.NewReader(client).ReadString('\n')
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if line != "ping\n" {
+			t.Errorf("Expected %q, got %q", "ping\n", line)
+		}
+	}
+}
+
+func TestConn_RenewsSessionTicket(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientConfig.ClientSessionCache = NewLRUClientSessionCache(4, sessionTicketLifetime)
+	clientConfig.Time, serverConfig.Time = clock, clock
+	handshakeConfigs(t, clientConfig, serverConfig)
+	first, _ := clientConfig.ClientSessionCache.Get("localhost@pipe")
+
+	// each resumption comes within the lifetime of the last ticket, but the
+	// last one comes after the first ticket has expired
+	for range 2 {
+		now = now.Add(sessionTicketLifetime * 2 / 3)
+		client, server := handshakeConfigs(t, clientConfig, serverConfig)
+
+		if !client.ConnectionState().DidResume || !server.ConnectionState().DidResume {
+			t.Fatal("Expected both sides to resume the session")
+		}
+	}
+
+	renewed, ok := clientConfig.ClientSessionCache.Get("localhost@pipe")
+	if !ok || bytes.Equal(renewed.ticket, first.ticket) {
+		t.Error("Expected the client to cache a renewed ticket")
+	}
+	if !bytes.Equal(renewed.masterSecret, first.masterSecret) {
+		t.Error("Expected the renewed ticket to carry the same session")
+	}
+}
+
+func TestConn_SessionTicketFallsBackToFullHandshake(t *testing.T) {
+	testCases := []struct {
+		name         string
+		changeServer func(config *Config)
+		expectCached bool
+	}{
+		{
+			name:         "ticket sealed with another key",
+			changeServer: func(config *Config) { config.SessionTicketKey = [32]byte{1} },
+			expectCached: true,
+		},
+		{
+			name:         "expired ticket",
+			changeServer: func(config *Config) { config.Time = func() time.Time { return time.Now().Add(8 * 24 * time.Hour) } },
+			expectCached: true,
+		},
+		{
+			name: "cipher suite no longer enabled",
+			changeServer: func(config *Config) {
+				config.CipherSuites = []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_256_GCM_SHA384}
+			},
+			expectCached: true,
+		},
+		{
+			name:         "tickets disabled on the server",
+			changeServer: func(config *Config) { config.SessionTicketsDisabled = true },
+			expectCached: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientConfig, serverConfig := newTestConfigs(t)
+			clientConfig.ClientSessionCache = NewLRUClientSessionCache(4, time.Hour)
+			serverConfig.CipherSuites = []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256}
+			serverConfig.SessionTicketKey = [32]byte{0x42}
+			handshakeConfigs(t, clientConfig, serverConfig)
+			first, _ := clientConfig.ClientSessionCache.Get("localhost@pipe")
+
+			otherServerConfig := serverConfig.Clone()
+			otherServerConfig.CipherSuites = nil
+			tc.changeServer(otherServerConfig)
+			client, server := handshakeConfigs(t, clientConfig, otherServerConfig)
+
+			if client.ConnectionState().DidResume || server.ConnectionState().DidResume {
+				t.Fatal("Expected a full handshake")
+			}
+			second, ok := clientConfig.ClientSessionCache.Get("localhost@pipe")
+			if ok != tc.expectCached {
+				t.Fatalf("Expected a cached session %t, got %t", tc.expectCached, ok)
+			}
+			if ok && second == first {
+				t.Error("Expected the declined session to be replaced")
+			}
+		})
+	}
+}
+
+func TestConn_SessionTicketsDisabledOnClient(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientConfig.ClientSessionCache = NewLRUClientSessionCache(4, time.Hour)
+	clientConfig.SessionTicketsDisabled = true
+
+	handshakeConfigs(t, clientConfig, serverConfig)
+	client, _ := handshakeConfigs(t, clientConfig, serverConfig)
+
+	if client.ConnectionState().DidResume {
+		t.Error("Expected a full handshake")
+	}
+	if _, ok := clientConfig.ClientSessionCache.Get("localhost@pipe"); ok {
+		t.Error("Expected no cached session")
+	}
+}
+
+func TestConn_ResumesWithSessionID(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		clientTicketsEnabled bool
+		serverTicketsEnabled bool
+	}{
+		{name: "tickets disabled on both sides"},
+		{name: "tickets disabled on the server", clientTicketsEnabled: true},
+		{name: "tickets disabled on the client", serverTicketsEnabled: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientConfig, serverConfig := newTestConfigs(t)
+			clientConfig.ClientSessionCache = NewLRUClientSessionCache(4, time.Hour)
+			clientConfig.SessionTicketsDisabled = !tc.clientTicketsEnabled
+			serverConfig.SessionCache = NewLRUSessionCache(4, time.Hour)
+			serverConfig.SessionTicketsDisabled = !tc.serverTicketsEnabled
+
+			handshakeConfigs(t, clientConfig, serverConfig)
+			cached, ok := clientConfig.ClientSessionCache.Get("localhost@pipe")
+			if !ok || len(cached.sessionID) != 32 {
+				t.Fatal("Expected the client to cache the session under its session ID")
+			}
+			client, server := handshakeConfigs(t, clientConfig, serverConfig)
+
+			if !client.ConnectionState().DidResume || !server.ConnectionState().DidResume {
+				t.Fatal("Expected both sides to resume the session")
+			}
+			if !bytes.Equal(client.clientVerifyData, server.clientVerifyData) || !bytes.Equal(client.serverVerifyData, server.serverVerifyData) {
+				t.Error("Expected matching verify_data on both sides")
+			}
+		})
+	}
+}
+
+func TestConn_SessionNotResumedForOtherServerName(t *testing.T) {
+	testCases := []struct {
+		name           string
+		ticketsEnabled bool
+	}{
+		{name: "session ticket", ticketsEnabled: true},
+		{name: "session ID"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				t.Fatalf("failed to generate key: %v", err)
+			}
+			clientConfig, serverConfig := newTestConfigsWithCertificate(t, newTestCertificateWithKey(t, key, "localhost", "www.localhost"))
+			clientConfig.ClientSessionCache = NewLRUClientSessionCache(4, time.Hour)
+			clientConfig.SessionTicketsDisabled = !tc.ticketsEnabled
+			serverConfig.SessionCache = NewLRUSessionCache(4, time.Hour)
+			serverConfig.SessionTicketsDisabled = !tc.ticketsEnabled
+			handshakeConfigs(t, clientConfig, serverConfig)
+			cached, ok := clientConfig.ClientSessionCache.Get("localhost@pipe")
+			if !ok {
+				t.Fatal("Expected the client to cache the session")
+			}
+			// the client offers the session of localhost to www.localhost
+			otherConfig := clientConfig.Clone()
+			otherConfig.ServerName = "www.localhost"
+			otherConfig.ClientSessionCache.Put("www.localhost@pipe", cached)
+
+			client, server := handshakeConfigs(t, otherConfig, serverConfig)
+
+			if client.ConnectionState().DidResume || server.ConnectionState().DidResume {
+				t.Error("Expected a full handshake for another server name")
+			}
+		})
+	}
+}
+
+func TestConn_SessionIDFallsBackToFullHandshake(t *testing.T) {
+	testCases := []struct {
+		name         string
+		changeServer func(config *Config)
+	}{
+		{
+			name:         "session evicted from the server cache",
+			changeServer: func(config *Config) { config.SessionCache = NewLRUSessionCache(4, time.Hour) },
+		},
+		{
+			name:         "server without a session cache",
+			changeServer: func(config *Config) { config.SessionCache = nil },
+		},
+		{
+			name: "session older than the cache TTL",
+			changeServer: func(config *Config) {
+				config.Time = func() time.Time { return time.Now().Add(2 * time.Hour) }
+			},
+		},
+		{
+			name: "session older than its lifetime",
+			changeServer: func(config *Config) {
+				config.Time = func() time.Time { return time.Now().Add(sessionTicketLifetime + time.Minute) }
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientConfig, serverConfig := newTestConfigs(t)
+			clientConfig.ClientSessionCache = NewLRUClientSessionCache(4, time.Hour)
+			clientConfig.SessionTicketsDisabled = true
+			serverConfig.SessionCache = NewLRUSessionCache(4, time.Hour)
+			handshakeConfigs(t, clientConfig, serverConfig)
+
+			otherServerConfig := serverConfig.Clone()
+			tc.changeServer(otherServerConfig)
+			client, server := handshakeConfigs(t, clientConfig, otherServerConfig)
+
+			if client.ConnectionState().DidResume || server.ConnectionState().DidResume {
+				t.Fatal("Expected a full handshake")
+			}
+		})
+	}
+}
+
+func TestConn_LegacyCipherSuites(t *testing.T) {
+	for _, cipherSuite := range legacyCipherSuites {
+		t.Run(cipherSuite.String(), func(t *testing.T) {
+			params, err := ciphersuite.Lookup(cipherSuite)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			clientConfig, serverConfig := newTestConfigsForSuite(t, cipherSuite)
+			clientConfig.CipherSuites = []spec.CipherSuite{cipherSuite}
+			serverConfig.CipherSuites = append(slices.Clone(supportedCipherSuites), legacyCipherSuites...)
+			client, server := handshakeConfigs(t, clientConfig, serverConfig)
+			go echoLines(server)
+			line := append(bytes.Repeat([]byte{'x'}, 40000), '\n')
+
+			if _, err := client.Write(line); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			echoed, err := bufio.NewReaderSize(client, len(line)).ReadBytes('\n')
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !bytes.Equal(echoed, line) {
+				t.Error("Expected the line to be echoed unchanged")
+			}
+			if state := client.ConnectionState(); state.CipherSuite != cipherSuite {
+				t.Errorf("Expected %v, got %v", cipherSuite, state.CipherSuite)
+			}
+			// encrypt_then_mac only applies to the CBC suites
+			if expected := !params.AEAD(); client.ConnectionState().EncryptThenMAC != expected || server.ConnectionState().EncryptThenMAC != expected {
+				t.Errorf("Expected encrypt_then_mac to be %v on both sides", expected)
+			}
+		})
+	}
+}
+
+func TestConn_CBCCipherSuitesDisabledByDefault(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientConfig.CipherSuites = legacyCipherSuites
+	clientSide, serverSide := net.Pipe()
+	server := Server(serverSide, serverConfig)
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- server.Handshake() }()
+	client := Client(clientSide, clientConfig)
+	defer client.Close()
+
+	err := client.Handshake()
+
+	var alertErr *AlertError
+	if !errors.As(err, &alertErr) || alertErr.Description != spec.AlertDescriptionHandshakeFailure {
+		t.Errorf("Expected a HandshakeFailure alert, got %v", err)
+	}
+	if err := <-serverErr; err == nil || err.Error() != "no cipher suite shared with the client" {
+		t.Errorf("Expected error %q, got %v", "no cipher suite shared with the client", err)
+	}
+}
+
+func TestConn_EncryptThenMACOnlyForCBC(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientConfig.CipherSuites = append(slices.Clone(supportedCipherSuites), legacyCipherSuites...)
+
+	client, server := handshakeConfigs(t, clientConfig, serverConfig)
+
+	if client.ConnectionState().EncryptThenMAC || server.ConnectionState().EncryptThenMAC {
+		t.Error("Expected no encrypt_then_mac with an AEAD suite")
+	}
+}
+
+func TestConn_ResumptionKeepsEncryptThenMAC(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientConfig.ClientSessionCache = NewLRUClientSessionCache(4, time.Hour)
+	clientConfig.CipherSuites = []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_CBC_SHA256}
+	serverConfig.CipherSuites = clientConfig.CipherSuites
+	serverConfig.SessionCache = NewLRUSessionCache(4, time.Hour)
+	handshakeConfigs(t, clientConfig, serverConfig)
+
+	client, server := handshakeConfigs(t, clientConfig, serverConfig)
+	go echoLines(server)
+	if _, err := client.Write([]byte("ping\n")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	line, err := bufio.NewReader(client).ReadString('\n')
+
+	if err != nil || line != "ping\n" {
+		t.Fatalf("Expected %q, got %q (%v)", "ping\n", line, err)
+	}
+	if !client.ConnectionState().DidResume {
+		t.Fatal("Expected the session to be resumed")
+	}
+	if !client.ConnectionState().EncryptThenMAC || !server.ConnectionState().EncryptThenMAC {
+		t.Error("Expected the resumed session to keep encrypt_then_mac")
+	}
+}
+
+func TestConn_ECDSACertificate(t *testing.T) {
+	testCases := []struct {
+		name               string
+		curve              elliptic.Curve
+		signatureAlgorithm spec.SignatureAlgorithm
+		clientSuites       []spec.CipherSuite
+		expected           spec.CipherSuite
+	}{
+		{
+			name:               "P-256",
+			curve:              elliptic.P256(),
+			signatureAlgorithm: spec.SignatureAlgorithmEcdsaSecp256r1Sha256,
+			expected:           spec.CipherSuiteECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		},
+		{
+			name:               "P-384",
+			curve:              elliptic.P384(),
+			signatureAlgorithm: spec.SignatureAlgorithmEcdsaSecp384r1Sha384,
+			clientSuites:       []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256, spec.CipherSuiteECDHE_ECDSA_WITH_AES_256_GCM_SHA384},
+			expected:           spec.CipherSuiteECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		},
+		{
+			name:               "P-521",
+			curve:              elliptic.P521(),
+			signatureAlgorithm: spec.SignatureAlgorithmEcdsaSecp521r1Sha512,
+			clientSuites:       []spec.CipherSuite{spec.CipherSuiteECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256},
+			expected:           spec.CipherSuiteECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientConfig, serverConfig := newTestConfigsWithCertificate(t, newTestECDSACertificate(t, tc.curve))
+			clientConfig.CipherSuites = tc.clientSuites
+			clientConfig.SignatureAlgorithms = []spec.SignatureAlgorithm{spec.SignatureAlgorithmRsaPkcs1Sha256, tc.signatureAlgorithm}
+
+			client, server := handshakeConfigs(t, clientConfig, serverConfig)
+			go echoLines(server)
+			if _, err := client.Write([]byte("ping\n")); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			line, err := bufio.NewReader(client).ReadString('\n')
+
+			if err != nil || line != "ping\n" {
+				t.Fatalf("Expected %q, got %q (%v)", "ping\n", line, err)
+			}
+			if state := client.ConnectionState(); state.CipherSuite != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, state.CipherSuite)
+			}
+		})
+	}
+}
+
+func TestConn_NoCipherSuiteForCertificateKey(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigsWithCertificate(t, newTestECDSACertificate(t, elliptic.P256()))
+	clientConfig.CipherSuites = []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	clientSide, serverSide := net.Pipe()
+	clientErr := make(chan error, 1)
+	go func() { clientErr <- Client(clientSide, clientConfig).Handshake() }()
+	server := Server(serverSide, serverConfig)
+	defer server.Close()
+
+	err := server.Handshake()
+
+	expected := "no cipher suite shared with the client for a key of type *ecdsa.PublicKey"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+	var alertErr *AlertError
+	if err := <-clientErr; !errors.As(err, &alertErr) || alertErr.Description != spec.AlertDescriptionHandshakeFailure {
+		t.Errorf("Expected the client to receive a HandshakeFailure alert, got %v", err)
+	}
+}
+
+func TestConn_CipherSuiteNeedsSignatureAlgorithm(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	// the client can verify no RSA signature, so ECDHE_RSA cannot be used
+	clientConfig.SignatureAlgorithms = []spec.SignatureAlgorithm{spec.SignatureAlgorithmEcdsaSecp256r1Sha256}
+	clientConfig.CipherSuites = []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256, spec.CipherSuiteRSA_WITH_AES_128_GCM_SHA256}
+	serverConfig.CipherSuites = clientConfig.CipherSuites
+
+	client, server := handshakeConfigs(t, clientConfig, serverConfig)
+
+	expected := spec.CipherSuiteRSA_WITH_AES_128_GCM_SHA256
+	if suite := client.ConnectionState().CipherSuite; suite != expected {
+		t.Errorf("Expected client cipher suite %v, got %v", expected, suite)
+	}
+	if suite := server.ConnectionState().CipherSuite; suite != expected {
+		t.Errorf("Expected server cipher suite %v, got %v", expected, suite)
+	}
+}
+
+func TestConn_NoSignatureAlgorithmForECDHE(t *testing.T) {
+	clientConfig, serverConfig := newTestConfigs(t)
+	clientConfig.SignatureAlgorithms = []spec.SignatureAlgorithm{spec.SignatureAlgorithmEcdsaSecp256r1Sha256}
+	clientConfig.CipherSuites = []spec.CipherSuite{spec.CipherSuiteECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	clientSide, serverSide := net.Pipe()
+	clientErr := make(chan error, 1)
+	go func() { clientErr <- Client(clientSide, clientConfig).Handshake() }()
+	server := Server(serverSide, serverConfig)
+	defer server.Close()
+
+	err := server.Handshake()
+
+	expected := "no signature algorithm shared with the peer for a key of type *rsa.PublicKey"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+	var alertErr *AlertError
+	if err := <-clientErr; !errors.As(err, &alertErr) || alertErr.Description != spec.AlertDescriptionHandshakeFailure {
+		t.Errorf("Expected the client to receive a HandshakeFailure alert, got %v", err)
+	}
+}